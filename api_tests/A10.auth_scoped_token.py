@@ -0,0 +1,54 @@
+import sys
+import os
+sys.path.append(os.path.abspath(os.path.dirname(__file__)))
+from utils import send_and_print, BASE_URL, load_config, save_config
+
+print("--- CREATE SCOPED TOKEN (READ-ONLY) ---")
+
+token = load_config("accessToken")
+if not token:
+    print("Error: No access token. Run A2.auth_login.py first.")
+    sys.exit(1)
+
+url = f"{BASE_URL}/auth/scoped-token"
+headers = {
+    "Authorization": f"Bearer {token}"
+}
+payload = {
+    "scopes": ["users:read"],
+    "expiresInMinutes": 15
+}
+
+response = send_and_print(
+    url=url,
+    headers=headers,
+    method="POST",
+    body=payload,
+    output_file=f"{os.path.splitext(os.path.basename(__file__))[0]}.json"
+)
+
+if response.status_code != 201:
+    sys.exit(1)
+
+scoped_token = response.json()['token']
+save_config("scopedAccessToken", scoped_token)
+print(">>> Scoped token saved.")
+
+print("\n--- LIST USERS WITH SCOPED TOKEN (ALLOWED) ---")
+
+send_and_print(
+    url=f"{BASE_URL}/users",
+    headers={"Authorization": f"Bearer {scoped_token}"},
+    method="GET",
+    output_file=f"{os.path.splitext(os.path.basename(__file__))[0]}_list.json"
+)
+
+print("\n--- CREATE USER WITH SCOPED TOKEN (EXPECT 403 insufficient_scope) ---")
+
+send_and_print(
+    url=f"{BASE_URL}/users",
+    headers={"Authorization": f"Bearer {scoped_token}"},
+    method="POST",
+    body={"name": "Scoped", "email": "scoped@example.com", "password": "password123"},
+    output_file=f"{os.path.splitext(os.path.basename(__file__))[0]}_create.json"
+)
@@ -0,0 +1,18 @@
+import sys
+import os
+sys.path.append(os.path.abspath(os.path.dirname(__file__)))
+from utils import send_and_print, BASE_URL
+
+print("--- REVOKE SESSIONS (THIS WASN'T ME) ---")
+
+# Copy the link token from the "New sign-in" email printed in the server logs
+mock_token = "PUT_VALID_TOKEN_HERE_FROM_LOGS"
+
+# Opening the link (GET) only shows a confirmation page; submitting it revokes
+url = f"{BASE_URL}/auth/revoke-sessions?token={mock_token}"
+
+response = send_and_print(
+    url=url,
+    method="POST",
+    output_file=f"{os.path.splitext(os.path.basename(__file__))[0]}.json"
+)
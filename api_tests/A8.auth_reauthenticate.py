@@ -0,0 +1,33 @@
+import sys
+import os
+sys.path.append(os.path.abspath(os.path.dirname(__file__)))
+from utils import send_and_print, BASE_URL, load_config, save_config
+
+print("--- REAUTHENTICATE (STEP-UP) ---")
+
+token = load_config("accessToken")
+if not token:
+    print("Error: No access token. Run A2.auth_login.py first.")
+    sys.exit(1)
+
+url = f"{BASE_URL}/auth/reauthenticate"
+headers = {
+    "Authorization": f"Bearer {token}"
+}
+payload = {
+    "password": "password123"
+}
+
+response = send_and_print(
+    url=url,
+    headers=headers,
+    method="POST",
+    body=payload,
+    output_file=f"{os.path.splitext(os.path.basename(__file__))[0]}.json"
+)
+
+if response.status_code == 200:
+    data = response.json()
+    save_config("accessToken", data['access']['token'])
+    save_config("refreshToken", data['refresh']['token'])
+    print(">>> Reauthenticated. Fresh tokens saved.")
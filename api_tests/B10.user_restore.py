@@ -0,0 +1,39 @@
+import sys
+import os
+sys.path.append(os.path.abspath(os.path.dirname(__file__)))
+from utils import send_and_print, BASE_URL, load_config
+
+print("--- LIST DELETED USERS ---")
+
+token = load_config("accessToken")
+target_id = load_config("target_user_id")
+
+if not token:
+    print("Error: No access token. Run A2.auth_login.py first.")
+    sys.exit(1)
+if not target_id:
+    print("Error: No target User ID. Run B1.user_create.py and B5.user_delete.py first.")
+    sys.exit(1)
+
+headers = {
+    "Authorization": f"Bearer {token}"
+}
+
+send_and_print(
+    url=f"{BASE_URL}/users/deleted?page=1&limit=10",
+    headers=headers,
+    method="GET",
+    output_file=f"{os.path.splitext(os.path.basename(__file__))[0]}_list.json"
+)
+
+print("\n--- RESTORE USER ---")
+
+response = send_and_print(
+    url=f"{BASE_URL}/users/deleted/{target_id}/restore",
+    headers=headers,
+    method="POST",
+    output_file=f"{os.path.splitext(os.path.basename(__file__))[0]}.json"
+)
+
+if response.status_code == 200:
+    print(">>> User restored. Run B5.user_delete.py again to delete it.")
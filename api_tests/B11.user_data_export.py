@@ -0,0 +1,59 @@
+import sys
+import os
+import time
+sys.path.append(os.path.abspath(os.path.dirname(__file__)))
+from utils import send_and_print, BASE_URL, load_config
+
+print("--- REQUEST DATA EXPORT ---")
+
+token = load_config("accessToken")
+
+if not token:
+    print("Error: No access token. Run A2.auth_login.py first.")
+    sys.exit(1)
+
+headers = {
+    "Authorization": f"Bearer {token}"
+}
+
+response = send_and_print(
+    url=f"{BASE_URL}/users/me/export",
+    headers=headers,
+    method="POST",
+    output_file=f"{os.path.splitext(os.path.basename(__file__))[0]}.json"
+)
+
+if response.status_code != 202:
+    sys.exit(1)
+
+request_id = response.json()["id"]
+
+print("\n--- WAIT FOR EXPORT ---")
+
+# The bundle is built in the background
+status = "pending"
+for _ in range(10):
+    time.sleep(1)
+    response = send_and_print(
+        url=f"{BASE_URL}/users/me/data-requests?page=1&limit=10",
+        headers=headers,
+        method="GET",
+        output_file=f"{os.path.splitext(os.path.basename(__file__))[0]}_status.json"
+    )
+    requests = [r for r in response.json().get("results", []) if r["id"] == request_id]
+    status = requests[0]["status"] if requests else "missing"
+    if status not in ("pending", "processing"):
+        break
+
+if status != "completed":
+    print(f">>> Export did not complete (status: {status}).")
+    sys.exit(1)
+
+print("\n--- DOWNLOAD EXPORT ---")
+
+send_and_print(
+    url=f"{BASE_URL}/users/me/data-requests/{request_id}/download",
+    headers=headers,
+    method="GET",
+    output_file=f"{os.path.splitext(os.path.basename(__file__))[0]}_download.json"
+)
@@ -0,0 +1,45 @@
+import sys
+import os
+sys.path.append(os.path.abspath(os.path.dirname(__file__)))
+from utils import send_and_print, BASE_URL, load_config
+
+print("--- REQUEST DATA ERASURE ---")
+
+token = load_config("accessToken")
+
+if not token:
+    print("Error: No access token. Run A2.auth_login.py first.")
+    sys.exit(1)
+
+headers = {
+    "Authorization": f"Bearer {token}"
+}
+payload = {
+    "reason": "I no longer use this service"
+}
+
+# Requires a recent login (run A8.auth_reauthenticate.py if this returns 401)
+response = send_and_print(
+    url=f"{BASE_URL}/users/me/erasure",
+    headers=headers,
+    method="POST",
+    body=payload,
+    output_file=f"{os.path.splitext(os.path.basename(__file__))[0]}.json"
+)
+
+if response.status_code != 202:
+    sys.exit(1)
+
+request_id = response.json()["id"]
+print(f">>> Erasure request {request_id} is waiting for review.")
+
+print("\n--- CANCEL ERASURE REQUEST ---")
+
+# Withdraw it again so the test account survives; an admin would approve it with
+# POST /data-requests/{id}/approve or decline it with POST /data-requests/{id}/reject
+send_and_print(
+    url=f"{BASE_URL}/users/me/data-requests/{request_id}",
+    headers=headers,
+    method="DELETE",
+    output_file=f"{os.path.splitext(os.path.basename(__file__))[0]}_cancel.json"
+)
@@ -0,0 +1,40 @@
+import sys
+import os
+from urllib.parse import quote
+sys.path.append(os.path.abspath(os.path.dirname(__file__)))
+from utils import send_and_print, BASE_URL, load_config
+
+print("--- GET ALL USERS (CURSOR PAGINATION) ---")
+
+token = load_config("accessToken")
+if not token:
+    print("Error: No access token. Run A2.auth_login.py first.")
+    sys.exit(1)
+
+headers = {
+    "Authorization": f"Bearer {token}"
+}
+
+# First page: 2 users sorted by name, with the total count
+url = f"{BASE_URL}/users?pagination=cursor&limit=2&sortBy=name:asc&total=true"
+response = send_and_print(
+    url=url,
+    headers=headers,
+    method="GET",
+    output_file=f"{os.path.splitext(os.path.basename(__file__))[0]}.json"
+)
+
+next_cursor = response.json().get("nextCursor") if response.status_code == 200 else None
+if not next_cursor:
+    print(">>> Only one page of users.")
+    sys.exit(0)
+
+print("\n--- NEXT PAGE ---")
+
+# The cursor already encodes the sort, so sortBy must stay the same
+response = send_and_print(
+    url=f"{BASE_URL}/users?limit=2&sortBy=name:asc&after={quote(next_cursor)}",
+    headers=headers,
+    method="GET",
+    output_file=f"{os.path.splitext(os.path.basename(__file__))[0]}_next.json"
+)
@@ -0,0 +1,38 @@
+import sys
+import os
+from urllib.parse import urlencode
+sys.path.append(os.path.abspath(os.path.dirname(__file__)))
+from utils import send_and_print, BASE_URL, load_config
+
+print("--- GET ALL USERS (FILTER EXPRESSION) ---")
+
+token = load_config("accessToken")
+if not token:
+    print("Error: No access token. Run A2.auth_login.py first.")
+    sys.exit(1)
+
+headers = {
+    "Authorization": f"Bearer {token}"
+}
+
+# Regular users and admins created since 2024, sorted by role then newest first
+params = urlencode({
+    "filter": "createdAt>=2024-01-01,role:in:user|admin",
+    "sortBy": "role:asc,createdAt:desc",
+    "limit": 5,
+})
+send_and_print(
+    url=f"{BASE_URL}/users?{params}",
+    headers=headers,
+    method="GET",
+    output_file=f"{os.path.splitext(os.path.basename(__file__))[0]}.json"
+)
+
+print("\n--- INVALID FILTER (expect 400) ---")
+
+send_and_print(
+    url=f"{BASE_URL}/users?{urlencode({'filter': 'password:eq:secret'})}",
+    headers=headers,
+    method="GET",
+    output_file=f"{os.path.splitext(os.path.basename(__file__))[0]}_invalid.json"
+)
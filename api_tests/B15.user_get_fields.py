@@ -0,0 +1,37 @@
+import sys
+import os
+sys.path.append(os.path.abspath(os.path.dirname(__file__)))
+from utils import send_and_print, BASE_URL, load_config
+
+print("--- GET USER (SPARSE FIELDSET + INCLUDES) ---")
+
+token = load_config("accessToken")
+target_id = load_config("target_user_id")
+
+if not token:
+    print("Error: No access token. Run A2.auth_login.py first.")
+    sys.exit(1)
+if not target_id:
+    print("Error: No target User ID. Run B1.user_create.py first.")
+    sys.exit(1)
+
+headers = {
+    "Authorization": f"Bearer {token}"
+}
+
+# Only a few fields, with the user's groups and active sessions embedded
+send_and_print(
+    url=f"{BASE_URL}/users/{target_id}?fields=id,name,email&include=groups,sessions",
+    headers=headers,
+    method="GET",
+    output_file=f"{os.path.splitext(os.path.basename(__file__))[0]}.json"
+)
+
+print("\n--- LIST USERS (NAMES ONLY) ---")
+
+send_and_print(
+    url=f"{BASE_URL}/users?fields=id,name&limit=5",
+    headers=headers,
+    method="GET",
+    output_file=f"{os.path.splitext(os.path.basename(__file__))[0]}_list.json"
+)
@@ -0,0 +1,54 @@
+import sys
+import os
+sys.path.append(os.path.abspath(os.path.dirname(__file__)))
+from utils import send_and_print, BASE_URL, load_config
+
+print("--- IMPORT USERS (DRY RUN) ---")
+
+token = load_config("accessToken")
+
+if not token:
+    print("Error: No access token. Run A2.auth_login.py first.")
+    sys.exit(1)
+
+headers = {
+    "Authorization": f"Bearer {token}",
+    "Content-Type": "text/csv"
+}
+
+# Rows without a password are invited instead of created
+csv_body = (
+    "name,email,password\n"
+    "Import One,import.one@example.com,password123\n"
+    "Import Two,import.two@example.com,\n"
+    "Broken Row,not-an-email,short\n"
+)
+
+send_and_print(
+    url=f"{BASE_URL}/users/import?dryRun=true",
+    headers=headers,
+    body=csv_body,
+    method="POST",
+    output_file=f"{os.path.splitext(os.path.basename(__file__))[0]}_dry_run.json"
+)
+
+print("\n--- IMPORT USERS ---")
+
+response = send_and_print(
+    url=f"{BASE_URL}/users/import",
+    headers=headers,
+    body=csv_body,
+    method="POST",
+    output_file=f"{os.path.splitext(os.path.basename(__file__))[0]}.json"
+)
+
+job_id = (response.json() or {}).get("id")
+if job_id:
+    print("\n--- IMPORT JOB STATUS ---")
+
+    send_and_print(
+        url=f"{BASE_URL}/users/import/{job_id}",
+        headers={"Authorization": f"Bearer {token}"},
+        method="GET",
+        output_file=f"{os.path.splitext(os.path.basename(__file__))[0]}_status.json"
+    )
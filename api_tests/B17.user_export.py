@@ -0,0 +1,33 @@
+import sys
+import os
+sys.path.append(os.path.abspath(os.path.dirname(__file__)))
+from utils import send_and_print, BASE_URL, load_config
+
+print("--- EXPORT USERS (CSV) ---")
+
+token = load_config("accessToken")
+
+if not token:
+    print("Error: No access token. Run A2.auth_login.py first.")
+    sys.exit(1)
+
+headers = {
+    "Authorization": f"Bearer {token}"
+}
+
+# Same filters as the user list; the file is streamed in batches
+send_and_print(
+    url=f"{BASE_URL}/users/export?format=csv&sortBy=name:asc&fields=id,name,email,role,status",
+    headers=headers,
+    method="GET",
+    output_file=f"{os.path.splitext(os.path.basename(__file__))[0]}.json"
+)
+
+print("\n--- EXPORT USERS (NDJSON) ---")
+
+send_and_print(
+    url=f"{BASE_URL}/users/export?format=ndjson&filter=status:eq:active",
+    headers=headers,
+    method="GET",
+    output_file=f"{os.path.splitext(os.path.basename(__file__))[0]}_ndjson.json"
+)
@@ -0,0 +1,56 @@
+import sys
+import os
+sys.path.append(os.path.abspath(os.path.dirname(__file__)))
+from utils import send_and_print, BASE_URL, load_config
+
+print("--- BULK UPDATE USERS ---")
+
+token = load_config("accessToken")
+target_id = load_config("target_user_id")
+
+if not token:
+    print("Error: No access token. Run A2.auth_login.py first.")
+    sys.exit(1)
+if not target_id:
+    print("Error: No target User ID. Run B1.user_create.py first.")
+    sys.exit(1)
+
+headers = {
+    "Authorization": f"Bearer {token}",
+    "Content-Type": "application/json"
+}
+
+# Atomic: both changes are stored together, or neither when one fails
+payload = {
+    "mode": "atomic",
+    "operations": [
+        {"op": "update", "ids": [target_id], "data": {"name": "Bulk Renamed User"}},
+        {"op": "status", "ids": [target_id], "status": "suspended", "reason": "Bulk suspension"}
+    ]
+}
+
+send_and_print(
+    url=f"{BASE_URL}/users/bulk",
+    headers=headers,
+    body=payload,
+    method="POST",
+    output_file=f"{os.path.splitext(os.path.basename(__file__))[0]}.json"
+)
+
+print("\n--- BULK UPDATE USERS (BEST EFFORT) ---")
+
+# The unknown user fails on its own; the reactivation is still stored
+payload = {
+    "mode": "bestEffort",
+    "operations": [
+        {"op": "status", "ids": [target_id, 999999], "status": "active"}
+    ]
+}
+
+send_and_print(
+    url=f"{BASE_URL}/users/bulk",
+    headers=headers,
+    body=payload,
+    method="POST",
+    output_file=f"{os.path.splitext(os.path.basename(__file__))[0]}_best_effort.json"
+)
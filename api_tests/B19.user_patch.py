@@ -0,0 +1,64 @@
+import sys
+import os
+sys.path.append(os.path.abspath(os.path.dirname(__file__)))
+from utils import send_and_print, BASE_URL, load_config
+
+print("--- PATCH USER (MERGE PATCH) ---")
+
+token = load_config("accessToken")
+target_id = load_config("target_user_id")
+
+if not token:
+    print("Error: No access token. Run A2.auth_login.py first.")
+    sys.exit(1)
+if not target_id:
+    print("Error: No target User ID. Run B1.user_create.py first.")
+    sys.exit(1)
+
+url = f"{BASE_URL}/users/{target_id}"
+headers = {
+    "Authorization": f"Bearer {token}"
+}
+output_name = os.path.splitext(os.path.basename(__file__))[0]
+
+current = send_and_print(url=url, headers=headers, method="GET", output_file=f"{output_name}.json")
+if current.status_code != 200:
+    print("Error: Could not read the target user.")
+    sys.exit(1)
+version = current.json()["version"]
+
+# Members replace those of the user; fields that cannot be patched are rejected
+response = send_and_print(
+    url=url,
+    headers={**headers, "Content-Type": "application/merge-patch+json", "If-Match": f'"{version}"'},
+    method="PATCH",
+    body={"name": "Merge Patched User"},
+    output_file=f"{output_name}.json"
+)
+if response.status_code == 200:
+    version = response.json()["version"]
+
+print("\n--- PATCH USER (JSON PATCH) ---")
+
+# Operations run in order and apply completely or not at all; test guards the change
+response = send_and_print(
+    url=url,
+    headers={**headers, "Content-Type": "application/json-patch+json", "If-Match": f'"{version}"'},
+    method="PATCH",
+    body=[
+        {"op": "test", "path": "/name", "value": "Merge Patched User"},
+        {"op": "replace", "path": "/name", "value": "JSON Patched User"}
+    ],
+    output_file=f"{output_name}_json_patch.json"
+)
+
+print("\n--- PATCH USER (READ-ONLY FIELD) ---")
+
+# Expected: 400 with a field-level error pointing to the role endpoint
+send_and_print(
+    url=url,
+    headers={**headers, "Content-Type": "application/merge-patch+json", "If-Match": "*"},
+    method="PATCH",
+    body={"role": "admin", "nickname": "unknown field"},
+    output_file=f"{output_name}_rejected.json"
+)
@@ -19,6 +19,18 @@ url = f"{BASE_URL}/users/{target_id}"
 headers = {
     "Authorization": f"Bearer {token}"
 }
+
+# The update must name the version it was based on, as sent in the ETag of a GET
+current = send_and_print(
+    url=url,
+    headers=headers,
+    method="GET",
+    output_file=f"{os.path.splitext(os.path.basename(__file__))[0]}.json"
+)
+if current.status_code != 200:
+    print("Error: Could not read the target user.")
+    sys.exit(1)
+headers["If-Match"] = f'"{current.json()["version"]}"'
 payload = {
     "name": "Updated Name via Python"
 }
@@ -20,6 +20,18 @@ headers = {
     "Authorization": f"Bearer {token}"
 }
 
+# The delete must name the version it was based on, as sent in the ETag of a GET
+current = send_and_print(
+    url=url,
+    headers=headers,
+    method="GET",
+    output_file=f"{os.path.splitext(os.path.basename(__file__))[0]}.json"
+)
+if current.status_code != 200:
+    print("Error: Could not read the target user.")
+    sys.exit(1)
+headers["If-Match"] = f'"{current.json()["version"]}"'
+
 response = send_and_print(
     url=url,
     headers=headers,
@@ -0,0 +1,43 @@
+import sys
+import os
+import time
+sys.path.append(os.path.abspath(os.path.dirname(__file__)))
+from utils import send_and_print, BASE_URL, load_config, save_config
+
+print("--- INVITE USER (ADMIN) ---")
+
+token = load_config("accessToken")
+if not token:
+    print("Error: No access token. Run A2.auth_login.py first.")
+    sys.exit(1)
+
+url = f"{BASE_URL}/users/invitations"
+headers = {
+    "Authorization": f"Bearer {token}"
+}
+payload = {
+    "name": "Invited User",
+    "email": f"invited_{int(time.time())}@example.com",
+    "role": "user"
+}
+
+response = send_and_print(
+    url=url,
+    headers=headers,
+    method="POST",
+    body=payload,
+    output_file=f"{os.path.splitext(os.path.basename(__file__))[0]}.json"
+)
+
+if response.status_code == 201:
+    save_config("invitation_id", response.json()['id'])
+    print(">>> Invitation created. Copy the invite token from the server logs and POST /auth/accept-invite?token=...")
+
+print("\n--- LIST PENDING INVITATIONS ---")
+
+response = send_and_print(
+    url=url,
+    headers=headers,
+    method="GET",
+    output_file=f"{os.path.splitext(os.path.basename(__file__))[0]}_list.json"
+)
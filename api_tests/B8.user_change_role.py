@@ -0,0 +1,33 @@
+import sys
+import os
+sys.path.append(os.path.abspath(os.path.dirname(__file__)))
+from utils import send_and_print, BASE_URL, load_config
+
+print("--- CHANGE USER ROLE ---")
+
+token = load_config("accessToken")
+target_id = load_config("target_user_id")
+
+if not token or not target_id:
+    print("Error: Missing token or target_user_id. Run A2 (login) and B1 (create user) first.")
+    sys.exit(1)
+
+url = f"{BASE_URL}/users/{target_id}/role"
+headers = {
+    "Authorization": f"Bearer {token}"
+}
+payload = {
+    "role": "admin"
+}
+
+# Requires a recent login (run A8.auth_reauthenticate.py if this returns 401)
+response = send_and_print(
+    url=url,
+    headers=headers,
+    method="PUT",
+    body=payload,
+    output_file=f"{os.path.splitext(os.path.basename(__file__))[0]}.json"
+)
+
+if response.status_code == 200:
+    print(">>> Role changed. The user's existing sessions were revoked.")
@@ -0,0 +1,47 @@
+import sys
+import os
+from datetime import datetime, timedelta, timezone
+sys.path.append(os.path.abspath(os.path.dirname(__file__)))
+from utils import send_and_print, BASE_URL, load_config
+
+print("--- SUSPEND USER ---")
+
+token = load_config("accessToken")
+target_id = load_config("target_user_id")
+
+if not token or not target_id:
+    print("Error: Missing token or target_user_id. Run A2 (login) and B1 (create user) first.")
+    sys.exit(1)
+
+url = f"{BASE_URL}/users/{target_id}/status"
+headers = {
+    "Authorization": f"Bearer {token}"
+}
+until = (datetime.now(timezone.utc) + timedelta(days=7)).strftime("%Y-%m-%dT%H:%M:%SZ")
+payload = {
+    "status": "suspended",
+    "reason": "Suspicious activity",
+    "suspendedUntil": until
+}
+
+# Requires a recent login (run A8.auth_reauthenticate.py if this returns 401)
+response = send_and_print(
+    url=url,
+    headers=headers,
+    method="PUT",
+    body=payload,
+    output_file=f"{os.path.splitext(os.path.basename(__file__))[0]}.json"
+)
+
+if response.status_code == 200:
+    print(f">>> User suspended until {until}. Their sessions were revoked.")
+
+print("\n--- REACTIVATE USER ---")
+
+response = send_and_print(
+    url=url,
+    headers=headers,
+    method="PUT",
+    body={"status": "active"},
+    output_file=f"{os.path.splitext(os.path.basename(__file__))[0]}_reactivate.json"
+)
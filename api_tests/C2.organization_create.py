@@ -0,0 +1,59 @@
+import sys
+import os
+sys.path.append(os.path.abspath(os.path.dirname(__file__)))
+from utils import send_and_print, BASE_URL, load_config, save_config
+
+print("--- CREATE ORGANIZATION ---")
+
+token = load_config("accessToken")
+if not token:
+    print("Error: No access token. Run A2.auth_login.py first.")
+    sys.exit(1)
+
+url = f"{BASE_URL}/organizations"
+headers = {
+    "Authorization": f"Bearer {token}"
+}
+payload = {
+    "name": "Acme Inc."
+}
+
+response = send_and_print(
+    url=url,
+    headers=headers,
+    method="POST",
+    body=payload,
+    output_file=f"{os.path.splitext(os.path.basename(__file__))[0]}.json"
+)
+
+if response.status_code != 201:
+    sys.exit(1)
+
+org_id = response.json()['id']
+save_config("target_org_id", org_id)
+print(">>> Organization created.")
+
+print("\n--- INVITE MEMBER ---")
+
+response = send_and_print(
+    url=f"{url}/{org_id}/members",
+    headers=headers,
+    method="POST",
+    body={"name": "Acme Member", "email": "member@acme.example", "role": "member"},
+    output_file=f"{os.path.splitext(os.path.basename(__file__))[0]}_invite.json"
+)
+
+print("\n--- SWITCH ORGANIZATION ---")
+
+response = send_and_print(
+    url=f"{url}/{org_id}/switch",
+    headers=headers,
+    method="POST",
+    output_file=f"{os.path.splitext(os.path.basename(__file__))[0]}_switch.json"
+)
+
+if response.status_code == 200:
+    tokens = response.json()
+    save_config("accessToken", tokens['access']['token'])
+    save_config("refreshToken", tokens['refresh']['token'])
+    print(">>> Switched. User endpoints now act in the new organization.")
@@ -0,0 +1,59 @@
+import sys
+import os
+sys.path.append(os.path.abspath(os.path.dirname(__file__)))
+from utils import send_and_print, BASE_URL, load_config, save_config
+
+print("--- CREATE GROUP (SUPPORT TEAM) ---")
+
+token = load_config("accessToken")
+target_id = load_config("target_user_id")
+
+if not token or not target_id:
+    print("Error: Missing token or target_user_id. Run A2 (login) and B1 (create user) first.")
+    sys.exit(1)
+
+url = f"{BASE_URL}/groups"
+headers = {
+    "Authorization": f"Bearer {token}"
+}
+payload = {
+    "name": "support-team",
+    "description": "Can look up users",
+    "permissions": ["users:read"]
+}
+
+response = send_and_print(
+    url=url,
+    headers=headers,
+    method="POST",
+    body=payload,
+    output_file=f"{os.path.splitext(os.path.basename(__file__))[0]}.json"
+)
+
+if response.status_code != 201:
+    sys.exit(1)
+
+group_id = response.json()['id']
+save_config("target_group_id", group_id)
+print(">>> Group created.")
+
+print("\n--- ADD MEMBER ---")
+
+response = send_and_print(
+    url=f"{url}/{group_id}/members/{target_id}",
+    headers=headers,
+    method="PUT",
+    output_file=f"{os.path.splitext(os.path.basename(__file__))[0]}_member.json"
+)
+
+if response.status_code == 204:
+    print(">>> User added. They get 'users:read' at their next login.")
+
+print("\n--- LIST USERS IN GROUP ---")
+
+response = send_and_print(
+    url=f"{BASE_URL}/users?group={group_id}",
+    headers=headers,
+    method="GET",
+    output_file=f"{os.path.splitext(os.path.basename(__file__))[0]}_users.json"
+)
@@ -88,12 +88,14 @@ def run_test():
         
     user_data = resp_create.json()
     created_id = user_data.get('id')
+    # Updates and deletes must name the version they are based on (If-Match)
+    version = user_data.get('version')
     
     # ASSERTION 1: Verify Response matches Input
     if not assert_payload_matches_response(create_payload, user_data, "Create User"):
         print_fail("Stopping test due to data mismatch in Create.")
         # Attempt cleanup
-        send_and_print(f"{BASE_URL}/users/{created_id}", headers, method="DELETE")
+        send_and_print(f"{BASE_URL}/users/{created_id}", {**headers, "If-Match": "*"}, method="DELETE")
         sys.exit(1)
 
     # --- STEP 2: GET USER ---
@@ -106,6 +108,7 @@ def run_test():
         print_fail(f"Get failed with status {resp_get.status_code}")
     else:
         get_data = resp_get.json()
+        version = get_data.get('version')
         # ASSERTION 2: Verify GET data matches original Input
         assert_payload_matches_response(create_payload, get_data, "Get User")
 
@@ -119,12 +122,14 @@ def run_test():
     }
     
     url_update = f"{BASE_URL}/users/{created_id}"
-    resp_update = send_and_print(url_update, headers, method="PATCH", body=update_payload, output_file="test_admin_3_update.json")
+    update_headers = {**headers, "If-Match": f'"{version}"'}
+    resp_update = send_and_print(url_update, update_headers, method="PATCH", body=update_payload, output_file="test_admin_3_update.json")
     
     if resp_update.status_code != 200:
         print_fail(f"Update failed with status {resp_update.status_code}")
     else:
         update_data = resp_update.json()
+        version = update_data.get('version')
         # ASSERTION 3: Verify Response matches Update Input
         assert_payload_matches_response(update_payload, update_data, "Update User")
         
@@ -138,7 +143,8 @@ def run_test():
     print_header("4. DELETE USER (DELETE)")
     
     url_delete = f"{BASE_URL}/users/{created_id}"
-    resp_delete = send_and_print(url_delete, headers, method="DELETE", output_file="test_admin_4_delete.json")
+    delete_headers = {**headers, "If-Match": f'"{version}"'}
+    resp_delete = send_and_print(url_delete, delete_headers, method="DELETE", output_file="test_admin_4_delete.json")
     
     if resp_delete.status_code == 204:
         print_pass("User deleted successfully (204 No Content).")
@@ -0,0 +1,122 @@
+import sys
+import os
+import time
+from email.utils import formatdate
+
+# Add current directory to path to import utils
+sys.path.append(os.path.abspath(os.path.dirname(__file__)))
+from utils import send_and_print, BASE_URL, load_config
+
+# --- COLORS ---
+class Colors:
+    OKGREEN = '\033[92m'
+    FAIL = '\033[91m'
+    ENDC = '\033[0m'
+    BOLD = '\033[1m'
+
+def print_header(msg):
+    print(f"\n{Colors.BOLD}=== {msg} ==={Colors.ENDC}")
+
+def print_pass(msg):
+    print(f"{Colors.OKGREEN}[PASS] {msg}{Colors.ENDC}")
+
+def print_fail(msg):
+    print(f"{Colors.FAIL}[FAIL] {msg}{Colors.ENDC}")
+
+def response_headers(resp):
+    """Returns the response headers with lower-case names."""
+    headers = resp.result_dict.get("response", {}).get("headers", {})
+    return {k.lower(): v for k, v in headers.items()}
+
+# --- MAIN TEST FLOW ---
+
+def run_test():
+    print_header("TEST: USER LIST REVALIDATION AFTER A DELETE")
+
+    token = load_config("accessToken")
+    if not token:
+        print_fail("No access token found. Run A2.auth_login.py first.")
+        sys.exit(1)
+
+    headers = {"Authorization": f"Bearer {token}"}
+    timestamp = int(time.time())
+
+    # --- STEP 1: CREATE A USER TO DELETE ---
+    print_header("1. CREATE USER")
+    resp_create = send_and_print(
+        f"{BASE_URL}/users", headers, method="POST",
+        body={
+            "name": f"Revalidate {timestamp}",
+            "email": f"revalidate.{timestamp}@check.com",
+            "role": "user",
+            "password": "password123"
+        },
+        output_file="test_revalidate_1_create.json"
+    )
+    if resp_create.status_code != 201:
+        print_fail(f"Create failed with status {resp_create.status_code}")
+        sys.exit(1)
+    created = resp_create.json()
+
+    # --- STEP 2: LIST USERS ---
+    print_header("2. LIST USERS")
+    url_list = f"{BASE_URL}/users?limit=100"
+    resp_list = send_and_print(url_list, headers, method="GET", output_file="test_revalidate_2_list.json")
+    if resp_list.status_code != 200:
+        print_fail(f"List failed with status {resp_list.status_code}")
+        sys.exit(1)
+
+    list_headers = response_headers(resp_list)
+    etag = list_headers.get("etag")
+    if etag:
+        print_pass(f"List sent an ETag ({etag}).")
+    else:
+        print_fail("List sent no ETag.")
+    if "last-modified" not in list_headers:
+        print_pass("List sent no Last-Modified.")
+    else:
+        print_fail(f"List sent Last-Modified: {list_headers['last-modified']}")
+
+    # --- STEP 3: DELETE THE USER ---
+    print_header("3. DELETE USER")
+    resp_delete = send_and_print(
+        f"{BASE_URL}/users/{created['id']}",
+        {**headers, "If-Match": f'"{created["version"]}"'},
+        method="DELETE", output_file="test_revalidate_3_delete.json"
+    )
+    if resp_delete.status_code != 204:
+        print_fail(f"Delete failed with status {resp_delete.status_code}")
+        sys.exit(1)
+
+    # --- STEP 4: REVALIDATE BY DATE ---
+    # No remaining user changed after this date, yet the list did
+    print_header("4. LIST WITH If-Modified-Since (expect 200)")
+    since = formatdate(time.time() + 60, usegmt=True)
+    resp_since = send_and_print(
+        url_list, {**headers, "If-Modified-Since": since},
+        method="GET", output_file="test_revalidate_4_since.json"
+    )
+    if resp_since.status_code == 200:
+        print_pass("If-Modified-Since returned the changed list (200).")
+    else:
+        print_fail(f"Expected 200 but got {resp_since.status_code}")
+
+    # --- STEP 5: REVALIDATE BY ETAG ---
+    print_header("5. LIST WITH THE OLD If-None-Match (expect 200)")
+    if etag:
+        resp_etag = send_and_print(
+            url_list, {**headers, "If-None-Match": etag},
+            method="GET", output_file="test_revalidate_5_etag.json"
+        )
+        if resp_etag.status_code == 200:
+            print_pass("The old ETag no longer matches the list (200).")
+        else:
+            print_fail(f"Expected 200 but got {resp_etag.status_code}")
+
+if __name__ == "__main__":
+    try:
+        run_test()
+    except Exception as e:
+        print(f"\n{Colors.FAIL}CRITICAL ERROR: {e}{Colors.ENDC}")
+        import traceback
+        traceback.print_exc()
@@ -0,0 +1,110 @@
+import sys
+import os
+import time
+
+# Add current directory to path to import utils
+sys.path.append(os.path.abspath(os.path.dirname(__file__)))
+from utils import send_and_print, BASE_URL, load_config
+
+# --- COLORS ---
+class Colors:
+    OKGREEN = '\033[92m'
+    FAIL = '\033[91m'
+    ENDC = '\033[0m'
+    BOLD = '\033[1m'
+
+def print_header(msg):
+    print(f"\n{Colors.BOLD}=== {msg} ==={Colors.ENDC}")
+
+def print_pass(msg):
+    print(f"{Colors.OKGREEN}[PASS] {msg}{Colors.ENDC}")
+
+def print_fail(msg):
+    print(f"{Colors.FAIL}[FAIL] {msg}{Colors.ENDC}")
+
+def scoped_token(headers, scopes, output_file):
+    """Mints a scoped token from the admin's login token."""
+    resp = send_and_print(
+        f"{BASE_URL}/auth/scoped-token", headers, method="POST",
+        body={"scopes": scopes, "expiresInMinutes": 15},
+        output_file=output_file
+    )
+    if resp.status_code != 201:
+        print_fail(f"Creating a token with {scopes} failed with status {resp.status_code}")
+        sys.exit(1)
+    return resp.json()["token"]
+
+def expect_status(resp, expected, msg):
+    if resp.status_code == expected:
+        print_pass(f"{msg} ({expected}).")
+    else:
+        print_fail(f"{msg}: expected {expected} but got {resp.status_code}")
+
+# --- MAIN TEST FLOW ---
+
+def run_test():
+    print_header("TEST: include NEEDS THE PERMISSION AS A SCOPE")
+
+    # The admin holds users:read, groups:read and security-events:read
+    token = load_config("accessToken")
+    if not token:
+        print_fail("No access token found. Run A2.auth_login.py first.")
+        sys.exit(1)
+
+    headers = {"Authorization": f"Bearer {token}"}
+    timestamp = int(time.time())
+
+    # --- STEP 0: ANOTHER USER TO LIST ---
+    # Anyone may embed their own resources, so the list must hold someone else
+    print_header("0. CREATE USER")
+    resp_create = send_and_print(
+        f"{BASE_URL}/users", headers, method="POST",
+        body={
+            "name": f"Include {timestamp}",
+            "email": f"include.{timestamp}@check.com",
+            "role": "user",
+            "password": "password123"
+        },
+        output_file="test_include_0_create.json"
+    )
+    if resp_create.status_code != 201:
+        print_fail(f"Create failed with status {resp_create.status_code}")
+        sys.exit(1)
+    created = resp_create.json()
+
+    # --- STEP 1: TOKEN WITHOUT THE INCLUDE SCOPES ---
+    print_header("1. SCOPED TOKEN WITH users:read ONLY")
+    read_only = {"Authorization": f"Bearer {scoped_token(headers, ['users:read'], 'test_include_1_token.json')}"}
+
+    resp = send_and_print(f"{BASE_URL}/users", read_only, method="GET", output_file="test_include_1_list.json")
+    expect_status(resp, 200, "Listing users without include is allowed")
+
+    resp = send_and_print(f"{BASE_URL}/users?include=sessions", read_only, method="GET", output_file="test_include_1_sessions.json")
+    expect_status(resp, 403, "Embedding other users' sessions is refused")
+
+    resp = send_and_print(f"{BASE_URL}/users?include=groups", read_only, method="GET", output_file="test_include_1_groups.json")
+    expect_status(resp, 403, "Embedding other users' groups is refused")
+
+    # --- STEP 2: TOKEN WITH THE INCLUDE SCOPES ---
+    print_header("2. SCOPED TOKEN WITH THE INCLUDE SCOPES")
+    scopes = ["users:read", "groups:read", "security-events:read"]
+    full = {"Authorization": f"Bearer {scoped_token(headers, scopes, 'test_include_2_token.json')}"}
+
+    resp = send_and_print(f"{BASE_URL}/users?include=sessions,groups", full, method="GET", output_file="test_include_2_list.json")
+    expect_status(resp, 200, "Embedding sessions and groups with the scopes is allowed")
+
+    # --- CLEANUP ---
+    print_header("CLEANUP")
+    send_and_print(
+        f"{BASE_URL}/users/{created['id']}",
+        {**headers, "If-Match": f'"{created["version"]}"'},
+        method="DELETE", output_file="test_include_3_cleanup.json"
+    )
+
+if __name__ == "__main__":
+    try:
+        run_test()
+    except Exception as e:
+        print(f"\n{Colors.FAIL}CRITICAL ERROR: {e}{Colors.ENDC}")
+        import traceback
+        traceback.print_exc()
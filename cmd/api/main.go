@@ -6,13 +6,16 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/config"
 	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/database"
 	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/http/handler"
 	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/http/router"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/policy"
 	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/repository"
 	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/service"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/pkg/mailer"
 	"github.com/valyala/fasthttp"
 	_ "github.com/mnabielap/starter-kit-restapi-gofasthttp/docs"
 )
@@ -46,20 +49,94 @@ func main() {
 	// 3. Initialize Repositories
 	userRepo := repository.NewUserRepository(db)
 	tokenRepo := repository.NewTokenRepository(db)
+	securityEventRepo := repository.NewSecurityEventRepository(db)
+	deviceRepo := repository.NewDeviceRepository(db)
+	invitationRepo := repository.NewInvitationRepository(db)
+	roleRepo := repository.NewRoleRepository(db)
+	permissionRepo := repository.NewPermissionRepository(db)
+	organizationRepo := repository.NewOrganizationRepository(db)
+	membershipRepo := repository.NewMembershipRepository(db)
+	groupRepo := repository.NewGroupRepository(db)
+	dataRequestRepo := repository.NewDataRequestRepository(db)
+	importJobRepo := repository.NewImportJobRepository(db)
+
+	// Mailer: fall back to logging emails when no SMTP server is configured
+	var appMailer mailer.Mailer = mailer.NewLogMailer(config.AppConfig.EmailFrom)
+	if config.AppConfig.SMTPHost != "" {
+		appMailer = mailer.NewSMTPMailer(
+			config.AppConfig.SMTPHost,
+			config.AppConfig.SMTPPort,
+			config.AppConfig.SMTPUsername,
+			config.AppConfig.SMTPPassword,
+			config.AppConfig.EmailFrom,
+		)
+	}
+
+	// Access policy: embedded defaults unless POLICY_FILE points to a custom file
+	policyEngine, err := policy.Load(config.AppConfig.PolicyFile)
+	if err != nil {
+		log.Fatalf("❌ Failed to load access policy: %v", err)
+	}
+	policyEngine.Debug = config.AppConfig.PolicyDebug
 
 	// 4. Initialize Services
 	tokenService := service.NewTokenService(tokenRepo, config.AppConfig)
-	userService := service.NewUserService(userRepo)
-	authService := service.NewAuthService(userService, tokenService)
+	securityEventService := service.NewSecurityEventService(securityEventRepo)
+	deviceService := service.NewDeviceService(deviceRepo)
+	emailService := service.NewEmailService(appMailer, config.AppConfig)
+	roleService := service.NewRoleService(roleRepo, permissionRepo, userRepo, groupRepo)
+	userService := service.NewUserService(userRepo, membershipRepo, groupRepo, securityEventService, tokenService, emailService, roleService, policyEngine)
+	invitationService := service.NewInvitationService(invitationRepo, membershipRepo, userService, tokenService, emailService, config.AppConfig)
+	organizationService := service.NewOrganizationService(organizationRepo, membershipRepo, userService, invitationService)
+	groupService := service.NewGroupService(groupRepo, roleService, userService)
+	dataRequestService := service.NewDataRequestService(dataRequestRepo, userRepo, membershipRepo, groupRepo, tokenRepo, securityEventRepo, deviceRepo, userService, emailService, config.AppConfig)
+	userImportService := service.NewUserImportService(importJobRepo, userRepo, userService, roleService, invitationService, config.AppConfig)
+	authService := service.NewAuthService(userService, tokenService, securityEventService, deviceService, emailService, invitationService, organizationService)
 
 	// 5. Initialize Handlers
 	authHandler := handler.NewAuthHandler(authService)
 	userHandler := handler.NewUserHandler(userService)
+	securityEventHandler := handler.NewSecurityEventHandler(securityEventService)
+	invitationHandler := handler.NewInvitationHandler(invitationService)
+	roleHandler := handler.NewRoleHandler(roleService)
+	policyHandler := handler.NewPolicyHandler(userService)
+	organizationHandler := handler.NewOrganizationHandler(organizationService, authService)
+	groupHandler := handler.NewGroupHandler(groupService)
+	dataRequestHandler := handler.NewDataRequestHandler(dataRequestService)
+	userImportHandler := handler.NewUserImportHandler(userImportService)
 
 	// 6. Setup Router
-	appRouter := router.SetupRouter(authHandler, userHandler, tokenService)
+	appRouter := router.SetupRouter(
+		authHandler,
+		userHandler,
+		securityEventHandler,
+		invitationHandler,
+		roleHandler,
+		policyHandler,
+		organizationHandler,
+		groupHandler,
+		dataRequestHandler,
+		userImportHandler,
+		tokenService,
+		userService,
+		roleService,
+		config.AppConfig,
+	)
 	log.Println("✅ API router initialized.")
 
+	// Imports still running when the server stopped cannot be resumed
+	if failed, err := userImportService.FailInterruptedJobs(); err != nil {
+		log.Printf("⚠️ Failed to close interrupted user imports: %v", err)
+	} else if failed > 0 {
+		log.Printf("🧹 Marked %d interrupted user import(s) as failed", failed)
+	}
+	// Exports are built in the background too, so the same goes for them
+	if failed, err := dataRequestService.FailInterruptedExports(); err != nil {
+		log.Printf("⚠️ Failed to close interrupted data exports: %v", err)
+	} else if failed > 0 {
+		log.Printf("🧹 Marked %d interrupted data export(s) as failed", failed)
+	}
+
 	// 7. Start Server
 	serverAddr := fmt.Sprintf(":%d", config.AppConfig.Port)
 	server := &fasthttp.Server{
@@ -75,6 +152,19 @@ func main() {
 		}
 	}()
 
+	// Background jobs: soft-deleted users are purged for good once the retention
+	// period has passed, and data export bundles are removed when they expire
+	if config.AppConfig.UserRetention > 0 {
+		go runPeriodically("User retention", time.Hour, func() error {
+			purged, err := userService.PurgeDeletedBefore(time.Now().Add(-config.AppConfig.UserRetention))
+			if purged > 0 {
+				log.Printf("🧹 Purged %d deleted user(s) past the retention period", purged)
+			}
+			return err
+		})
+	}
+	go runPeriodically("Data export cleanup", time.Hour, dataRequestService.DeleteExpiredExports)
+
 	stopChan := make(chan os.Signal, 1)
 	signal.Notify(stopChan, os.Interrupt, syscall.SIGTERM)
 	<-stopChan
@@ -84,4 +174,17 @@ func main() {
 		log.Fatalf("❌ Server shutdown failed: %v", err)
 	}
 	log.Println("👋 Server stopped.")
+}
+
+// runPeriodically runs job right away and then once per interval, logging failures
+func runPeriodically(name string, interval time.Duration, job func() error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := job(); err != nil {
+			log.Printf("⚠️ %s job failed: %v", name, err)
+		}
+		<-ticker.C
+	}
 }
\ No newline at end of file
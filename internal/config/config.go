@@ -17,6 +17,23 @@ type Config struct {
 	JWTSecret                  string
 	JWTAccessExpirationMinutes time.Duration
 	JWTRefreshExpirationDays   time.Duration
+	TrustProxyHeaders          bool
+	AppURL                     string
+	SMTPHost                   string
+	SMTPPort                   int
+	SMTPUsername               string
+	SMTPPassword               string
+	EmailFrom                  string
+	JWTResetPasswordExpiration time.Duration
+	InvitationExpiration       time.Duration
+	ReauthMaxAge               time.Duration
+	PolicyFile                 string
+	PolicyDebug                bool
+	UserRetention              time.Duration
+	DataExportDir              string
+	DataExportExpiration       time.Duration
+	ImportMaxRows              int
+	ImportSyncRows             int
 }
 
 var AppConfig *Config
@@ -29,6 +46,16 @@ func LoadConfig() {
 	port, _ := strconv.Atoi(getEnv("PORT", "3000"))
 	accessExp, _ := strconv.Atoi(getEnv("JWT_ACCESS_EXPIRATION_MINUTES", "30"))
 	refreshExp, _ := strconv.Atoi(getEnv("JWT_REFRESH_EXPIRATION_DAYS", "30"))
+	trustProxy, _ := strconv.ParseBool(getEnv("TRUST_PROXY_HEADERS", "false"))
+	smtpPort, _ := strconv.Atoi(getEnv("SMTP_PORT", "587"))
+	resetExp, _ := strconv.Atoi(getEnv("JWT_RESET_PASSWORD_EXPIRATION_MINUTES", "10"))
+	inviteExp, _ := strconv.Atoi(getEnv("INVITATION_EXPIRATION_DAYS", "7"))
+	reauthMaxAge, _ := strconv.Atoi(getEnv("REAUTH_MAX_AGE_MINUTES", "5"))
+	policyDebug, _ := strconv.ParseBool(getEnv("POLICY_DEBUG", "false"))
+	userRetention, _ := strconv.Atoi(getEnv("USER_RETENTION_DAYS", "0"))
+	exportExp, _ := strconv.Atoi(getEnv("DATA_EXPORT_EXPIRATION_DAYS", "7"))
+	importMaxRows, _ := strconv.Atoi(getEnv("IMPORT_MAX_ROWS", "5000"))
+	importSyncRows, _ := strconv.Atoi(getEnv("IMPORT_SYNC_ROWS", "50"))
 
 	AppConfig = &Config{
 		Port:                       port,
@@ -38,6 +65,23 @@ func LoadConfig() {
 		JWTSecret:                  getEnv("JWT_SECRET", "secret"),
 		JWTAccessExpirationMinutes: time.Duration(accessExp) * time.Minute,
 		JWTRefreshExpirationDays:   time.Duration(refreshExp) * 24 * time.Hour,
+		TrustProxyHeaders:          trustProxy,
+		AppURL:                     getEnv("APP_URL", "http://localhost:"+strconv.Itoa(port)),
+		SMTPHost:                   getEnv("SMTP_HOST", ""),
+		SMTPPort:                   smtpPort,
+		SMTPUsername:               getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:               getEnv("SMTP_PASSWORD", ""),
+		EmailFrom:                  getEnv("EMAIL_FROM", "noreply@example.com"),
+		JWTResetPasswordExpiration: time.Duration(resetExp) * time.Minute,
+		InvitationExpiration:       time.Duration(inviteExp) * 24 * time.Hour,
+		ReauthMaxAge:               time.Duration(reauthMaxAge) * time.Minute,
+		PolicyFile:                 getEnv("POLICY_FILE", ""),
+		PolicyDebug:                policyDebug,
+		UserRetention:              time.Duration(userRetention) * 24 * time.Hour,
+		DataExportDir:              getEnv("DATA_EXPORT_DIR", "exports"),
+		DataExportExpiration:       time.Duration(exportExp) * 24 * time.Hour,
+		ImportMaxRows:              importMaxRows,
+		ImportSyncRows:             importSyncRows,
 	}
 }
 
@@ -6,6 +6,7 @@ import (
 	"github.com/glebarez/sqlite"
 	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/config"
 	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/model"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/repository"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -36,11 +37,27 @@ func ConnectDB(cfg *config.Config) *gorm.DB {
 
 	// AutoMigrate creates tables based on structs
 	log.Println("🔄 Running Auto Migration...")
-	err = db.AutoMigrate(&model.User{}, &model.Token{})
+	err = db.AutoMigrate(&model.User{}, &model.Token{}, &model.SecurityEvent{}, &model.UserDevice{}, &model.Invitation{}, &model.Permission{}, &model.Role{}, &model.Organization{}, &model.Membership{}, &model.Group{}, &model.DataRequest{}, &model.ImportJob{})
 	if err != nil {
 		log.Fatalf("❌ Auto Migration failed: %v", err)
 	}
 	log.Println("✅ Auto Migration completed.")
 
+	if err := repository.UserSearch(db).Migrate(); err != nil {
+		log.Fatalf("❌ Creating the user search index failed: %v", err)
+	}
+
+	if err := seedRolesAndPermissions(db); err != nil {
+		log.Fatalf("❌ Seeding roles and permissions failed: %v", err)
+	}
+
+	if err := seedDefaultOrganization(db); err != nil {
+		log.Fatalf("❌ Seeding default organization failed: %v", err)
+	}
+
+	if err := seedPendingStatus(db); err != nil {
+		log.Fatalf("❌ Seeding account statuses failed: %v", err)
+	}
+
 	return db
 }
\ No newline at end of file
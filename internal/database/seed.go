@@ -0,0 +1,116 @@
+package database
+
+import (
+	"log"
+
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/model"
+	"gorm.io/gorm"
+)
+
+// defaultPermissions is the catalogue of permissions the code checks for
+var defaultPermissions = []model.Permission{
+	{Name: model.PermissionUsersRead, Description: "List and view users"},
+	{Name: model.PermissionUsersWrite, Description: "Create, invite and update users"},
+	{Name: model.PermissionUsersDelete, Description: "Delete users"},
+	{Name: model.PermissionRolesRead, Description: "List and view roles and permissions"},
+	{Name: model.PermissionRolesWrite, Description: "Create, update and delete roles"},
+	{Name: model.PermissionRolesAssign, Description: "Assign roles to users"},
+	{Name: model.PermissionSecurityEventsRead, Description: "View security events of all users"},
+	{Name: model.PermissionGroupsRead, Description: "List and view groups and their members"},
+	{Name: model.PermissionGroupsWrite, Description: "Create, update and delete groups and manage their members"},
+	{Name: model.PermissionDataRequestsManage, Description: "Review data export and erasure requests"},
+}
+
+// seedRolesAndPermissions makes sure every known permission and the built-in roles exist.
+// Existing roles are left untouched, except that permissions introduced by a newer
+// release are granted to the admin role so it keeps full access.
+func seedRolesAndPermissions(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		admin := model.Role{Name: model.RoleAdmin}
+		if err := tx.Where(&admin).Attrs(model.Role{
+			Description: "Full access to every resource",
+			IsSystem:    true,
+		}).FirstOrCreate(&admin).Error; err != nil {
+			return err
+		}
+
+		user := model.Role{Name: model.RoleUser}
+		if err := tx.Where(&user).Attrs(model.Role{
+			Description: "Regular account with access to its own data",
+			IsSystem:    true,
+		}).FirstOrCreate(&user).Error; err != nil {
+			return err
+		}
+
+		for _, p := range defaultPermissions {
+			permission := p
+			result := tx.Where(model.Permission{Name: permission.Name}).FirstOrCreate(&permission)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				continue
+			}
+
+			log.Printf("🌱 Seeded permission %s", permission.Name)
+			if err := tx.Model(&admin).Association("Permissions").Append(&permission); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// seedDefaultOrganization moves users that do not belong to any organization, e.g. accounts
+// created before organizations existed, into a shared "Default Organization". Admins become
+// its owners, everyone else joins as a member.
+func seedDefaultOrganization(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var orphans []model.User
+		if err := tx.Where("id NOT IN (?)", tx.Model(&model.Membership{}).Select("user_id")).
+			Find(&orphans).Error; err != nil {
+			return err
+		}
+		if len(orphans) == 0 {
+			return nil
+		}
+
+		organization := model.Organization{Name: "Default Organization"}
+		if err := tx.Where(&organization).FirstOrCreate(&organization).Error; err != nil {
+			return err
+		}
+
+		for _, user := range orphans {
+			role := model.OrgRoleMember
+			if user.Role == model.RoleAdmin {
+				role = model.OrgRoleOwner
+			}
+			if err := tx.Create(&model.Membership{
+				OrganizationID: organization.ID,
+				UserID:         user.ID,
+				Role:           role,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		log.Printf("🌱 Added %d users to organization %q", len(orphans), organization.Name)
+		return nil
+	})
+}
+
+// seedPendingStatus marks invited users that never set a password, and were created
+// before account statuses existed, as pending instead of active
+func seedPendingStatus(db *gorm.DB) error {
+	result := db.Model(&model.User{}).
+		Where("status = ? AND password = ?", model.UserStatusActive, "").
+		Update("status", model.UserStatusPending)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("🌱 Marked %d invited users as pending", result.RowsAffected)
+	}
+	return nil
+}
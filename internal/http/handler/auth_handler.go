@@ -2,10 +2,13 @@ package handler
 
 import (
 	"encoding/json"
+	"html/template"
+	"time"
 
 	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/model"
 	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/service"
 	"github.com/mnabielap/starter-kit-restapi-gofasthttp/pkg/utils"
+	routing "github.com/qiangxue/fasthttp-routing"
 	"github.com/valyala/fasthttp"
 )
 
@@ -45,7 +48,7 @@ func (h *AuthHandler) Register(ctx *fasthttp.RequestCtx) {
 
 	user.Role = "user"
 
-	createdUser, tokens, err := h.authService.Register(&user)
+	createdUser, tokens, err := h.authService.Register(&user, clientInfo(ctx))
 	if err != nil {
 		utils.WriteError(ctx, fasthttp.StatusBadRequest, err.Error())
 		return
@@ -59,7 +62,7 @@ func (h *AuthHandler) Register(ctx *fasthttp.RequestCtx) {
 
 // Login godoc
 // @Summary      Login user
-// @Description  Login with email and password to get tokens
+// @Description  Login with email and password to get tokens. Suspended, banned and pending accounts are rejected with 403.
 // @Tags         Auth
 // @Accept       json
 // @Produce      json
@@ -67,6 +70,7 @@ func (h *AuthHandler) Register(ctx *fasthttp.RequestCtx) {
 // @Success      200  {object}  map[string]interface{}
 // @Failure      400  {object}  utils.Response
 // @Failure      401  {object}  utils.Response
+// @Failure      403  {object}  utils.Response
 // @Router       /auth/login [post]
 func (h *AuthHandler) Login(ctx *fasthttp.RequestCtx) {
 	var loginData LoginRequest
@@ -84,9 +88,9 @@ func (h *AuthHandler) Login(ctx *fasthttp.RequestCtx) {
 		return
 	}
 
-	user, tokens, err := h.authService.Login(loginData.Email, loginData.Password)
+	user, tokens, err := h.authService.Login(loginData.Email, loginData.Password, clientInfo(ctx))
 	if err != nil {
-		utils.WriteError(ctx, fasthttp.StatusUnauthorized, err.Error())
+		utils.WriteError(ctx, errorStatus(err, fasthttp.StatusUnauthorized), err.Error())
 		return
 	}
 
@@ -110,7 +114,7 @@ func (h *AuthHandler) Logout(ctx *fasthttp.RequestCtx) {
 	var body RefreshTokenRequest
 	json.Unmarshal(ctx.PostBody(), &body)
 
-	if err := h.authService.Logout(body.RefreshToken); err != nil {
+	if err := h.authService.Logout(body.RefreshToken, clientInfo(ctx)); err != nil {
 		utils.WriteError(ctx, fasthttp.StatusNotFound, "Token not found")
 		return
 	}
@@ -119,27 +123,271 @@ func (h *AuthHandler) Logout(ctx *fasthttp.RequestCtx) {
 
 // RefreshTokens godoc
 // @Summary      Refresh auth tokens
-// @Description  Get new access and refresh tokens using a valid refresh token
+// @Description  Get new access and refresh tokens using a valid refresh token. Fails with 403 once the account is no longer active.
 // @Tags         Auth
 // @Accept       json
 // @Produce      json
 // @Param        request body RefreshTokenRequest true "Refresh Token"
 // @Success      200  {object}  service.AuthTokens
 // @Failure      401  {object}  utils.Response
+// @Failure      403  {object}  utils.Response
 // @Router       /auth/refresh-tokens [post]
 func (h *AuthHandler) RefreshTokens(ctx *fasthttp.RequestCtx) {
 	var body RefreshTokenRequest
 	json.Unmarshal(ctx.PostBody(), &body)
 
-	tokens, err := h.authService.RefreshAuth(body.RefreshToken)
+	tokens, err := h.authService.RefreshAuth(body.RefreshToken, clientInfo(ctx))
 	if err != nil {
-		utils.WriteError(ctx, fasthttp.StatusUnauthorized, err.Error())
+		utils.WriteError(ctx, errorStatus(err, fasthttp.StatusUnauthorized), err.Error())
 		return
 	}
 
 	utils.WriteSuccess(ctx, fasthttp.StatusOK, tokens)
 }
 
+// ForgotPassword godoc
+// @Summary      Forgot password
+// @Description  Send a password reset link to the given email. Always succeeds so registered emails cannot be discovered.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request body ForgotPasswordRequest true "Account Email"
+// @Success      204
+// @Failure      400  {object}  utils.Response
+// @Router       /auth/forgot-password [post]
+func (h *AuthHandler) ForgotPassword(ctx *fasthttp.RequestCtx) {
+	var body ForgotPasswordRequest
+	if err := json.Unmarshal(ctx.PostBody(), &body); err != nil {
+		utils.WriteError(ctx, fasthttp.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if validationErrors := utils.ValidateStruct(&body); validationErrors != nil {
+		utils.WriteJSON(ctx, fasthttp.StatusBadRequest, map[string]interface{}{
+			"status": "error",
+			"errors": validationErrors,
+		})
+		return
+	}
+
+	if err := h.authService.ForgotPassword(body.Email); err != nil {
+		utils.WriteError(ctx, fasthttp.StatusInternalServerError, err.Error())
+		return
+	}
+	ctx.SetStatusCode(fasthttp.StatusNoContent)
+}
+
+// ResetPassword godoc
+// @Summary      Reset password
+// @Description  Set a new password using the token from a reset password email. Signs out all sessions.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        token   query string               true "Reset Password Token"
+// @Param        request body  ResetPasswordRequest true "New Password"
+// @Success      204
+// @Failure      400  {object}  utils.Response
+// @Failure      401  {object}  utils.Response
+// @Router       /auth/reset-password [post]
+func (h *AuthHandler) ResetPassword(ctx *fasthttp.RequestCtx) {
+	var body ResetPasswordRequest
+	if err := json.Unmarshal(ctx.PostBody(), &body); err != nil {
+		utils.WriteError(ctx, fasthttp.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if validationErrors := utils.ValidateStruct(&body); validationErrors != nil {
+		utils.WriteJSON(ctx, fasthttp.StatusBadRequest, map[string]interface{}{
+			"status": "error",
+			"errors": validationErrors,
+		})
+		return
+	}
+
+	token := string(ctx.QueryArgs().Peek("token"))
+	if err := h.authService.ResetPassword(token, body.Password, clientInfo(ctx)); err != nil {
+		utils.WriteError(ctx, fasthttp.StatusUnauthorized, err.Error())
+		return
+	}
+	ctx.SetStatusCode(fasthttp.StatusNoContent)
+}
+
+// revokeSessionsPage asks for confirmation before the sessions are revoked, so link
+// scanners and prefetchers opening the email link change nothing
+var revokeSessionsPage = template.Must(template.New("revoke-sessions").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><meta name="robots" content="noindex"><title>Secure your account</title></head>
+<body>
+<h1>Wasn't you?</h1>
+<p>This signs you out on every device and requires a password reset before anyone can log in again.</p>
+<form method="post" action="/v1/auth/revoke-sessions?token={{.}}">
+<button type="submit">Sign out everywhere</button>
+</form>
+</body>
+</html>
+`))
+
+// ConfirmRevokeSessions godoc
+// @Summary      Confirm reporting an unrecognised login
+// @Description  Target of the "this wasn't me" link from a new device email. Shows a page asking to confirm; nothing is changed until it is submitted to POST /auth/revoke-sessions.
+// @Tags         Auth
+// @Produce      html
+// @Param        token query string true "Revoke Sessions Token"
+// @Success      200  {string}  string
+// @Router       /auth/revoke-sessions [get]
+func (h *AuthHandler) ConfirmRevokeSessions(ctx *fasthttp.RequestCtx) {
+	ctx.SetContentType("text/html; charset=utf-8")
+	ctx.Response.Header.Set("Cache-Control", "no-store")
+	ctx.Response.Header.Set("Referrer-Policy", "no-referrer")
+	if err := revokeSessionsPage.Execute(ctx, string(ctx.QueryArgs().Peek("token"))); err != nil {
+		utils.WriteError(ctx, fasthttp.StatusInternalServerError, err.Error())
+	}
+}
+
+// RevokeSessions godoc
+// @Summary      Report an unrecognised login
+// @Description  Submitted from the confirmation page of the "this wasn't me" link in a new device email. Signs out every session, blocks login until the password is reset and emails a reset link.
+// @Tags         Auth
+// @Produce      json
+// @Param        token query string true "Revoke Sessions Token"
+// @Success      200  {object}  utils.Response
+// @Failure      401  {object}  utils.Response
+// @Router       /auth/revoke-sessions [post]
+func (h *AuthHandler) RevokeSessions(ctx *fasthttp.RequestCtx) {
+	token := string(ctx.QueryArgs().Peek("token"))
+	if err := h.authService.RevokeSessions(token, clientInfo(ctx)); err != nil {
+		utils.WriteError(ctx, fasthttp.StatusUnauthorized, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(ctx, fasthttp.StatusOK, utils.Response{
+		Status:  "success",
+		Message: "All sessions have been signed out. Check your email to reset your password.",
+	})
+}
+
+// AcceptInvite godoc
+// @Summary      Accept an invitation
+// @Description  Set the password of an invited account using the token from the invitation email, and log in.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        token   query string               true "Invite Token"
+// @Param        request body  ResetPasswordRequest true "New Password"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  utils.Response
+// @Failure      401  {object}  utils.Response
+// @Router       /auth/accept-invite [post]
+func (h *AuthHandler) AcceptInvite(ctx *fasthttp.RequestCtx) {
+	var body ResetPasswordRequest
+	if err := json.Unmarshal(ctx.PostBody(), &body); err != nil {
+		utils.WriteError(ctx, fasthttp.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if validationErrors := utils.ValidateStruct(&body); validationErrors != nil {
+		utils.WriteJSON(ctx, fasthttp.StatusBadRequest, map[string]interface{}{
+			"status": "error",
+			"errors": validationErrors,
+		})
+		return
+	}
+
+	token := string(ctx.QueryArgs().Peek("token"))
+	user, tokens, err := h.authService.AcceptInvite(token, body.Password, clientInfo(ctx))
+	if err != nil {
+		utils.WriteError(ctx, fasthttp.StatusUnauthorized, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(ctx, fasthttp.StatusOK, map[string]interface{}{
+		"user":   user.ToResponse(),
+		"tokens": tokens,
+	})
+}
+
+// Reauthenticate godoc
+// @Summary      Confirm password for sensitive operations
+// @Description  Re-enter the password to get new tokens with a fresh auth_time. Required when an endpoint answers with code 'reauthentication_required'.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body ReauthenticateRequest true "Current Password"
+// @Success      200  {object}  service.AuthTokens
+// @Failure      400  {object}  utils.Response
+// @Failure      401  {object}  utils.Response
+// @Router       /auth/reauthenticate [post]
+func (h *AuthHandler) Reauthenticate(c *routing.Context) error {
+	var body ReauthenticateRequest
+	if err := json.Unmarshal(c.PostBody(), &body); err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid request body")
+		return nil
+	}
+
+	if validationErrors := utils.ValidateStruct(&body); validationErrors != nil {
+		utils.WriteJSON(c.RequestCtx, fasthttp.StatusBadRequest, map[string]interface{}{
+			"status": "error",
+			"errors": validationErrors,
+		})
+		return nil
+	}
+
+	userID, _ := c.Get("userID").(uint)
+	claims, _ := c.Get("claims").(*service.Claims)
+
+	tokens, err := h.authService.Reauthenticate(userID, body.Password, claims.OrgID, clientInfo(c.RequestCtx))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusUnauthorized, err.Error())
+		return nil
+	}
+
+	utils.WriteSuccess(c.RequestCtx, fasthttp.StatusOK, tokens)
+	return nil
+}
+
+// CreateScopedToken godoc
+// @Summary      Create a scoped access token
+// @Description  Issue an access token limited to a subset of the current token's scopes, e.g. a read-only token for a script or integration. Only a token from a login can create one, and it expires no later than that token. The token has no refresh token and cannot be used for operations that require a recent login or to create other tokens. It is revoked with the user's other sessions.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body ScopedTokenRequest true "Scopes and lifetime"
+// @Success      201  {object}  service.AuthTokenResponse
+// @Failure      400  {object}  utils.Response
+// @Failure      401  {object}  utils.Response
+// @Failure      403  {object}  utils.Response
+// @Router       /auth/scoped-token [post]
+func (h *AuthHandler) CreateScopedToken(c *routing.Context) error {
+	var body ScopedTokenRequest
+	if err := json.Unmarshal(c.PostBody(), &body); err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid request body")
+		return nil
+	}
+
+	if validationErrors := utils.ValidateStruct(&body); validationErrors != nil {
+		utils.WriteJSON(c.RequestCtx, fasthttp.StatusBadRequest, map[string]interface{}{
+			"status": "error",
+			"errors": validationErrors,
+		})
+		return nil
+	}
+
+	userID, _ := c.Get("userID").(uint)
+	claims, _ := c.Get("claims").(*service.Claims)
+	ttl := time.Duration(body.ExpiresInMinutes) * time.Minute
+
+	token, err := h.authService.IssueScopedToken(userID, claims, body.Scopes, ttl)
+	if err != nil {
+		utils.WriteError(c.RequestCtx, errorStatus(err, fasthttp.StatusBadRequest), err.Error())
+		return nil
+	}
+
+	utils.WriteSuccess(c.RequestCtx, fasthttp.StatusCreated, token)
+	return nil
+}
+
 // --- Request Structs for Swagger & Validation ---
 
 type LoginRequest struct {
@@ -149,4 +397,21 @@ type LoginRequest struct {
 
 type RefreshTokenRequest struct {
 	RefreshToken string `json:"refreshToken"`
-}
\ No newline at end of file
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Password string `json:"password" validate:"required,min=8"`
+}
+
+type ReauthenticateRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+type ScopedTokenRequest struct {
+	Scopes           []string `json:"scopes" validate:"required,min=1"`
+	ExpiresInMinutes int      `json:"expiresInMinutes" validate:"omitempty,min=1"`
+}
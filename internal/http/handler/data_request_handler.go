@@ -0,0 +1,345 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/repository"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/service"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/pkg/utils"
+	routing "github.com/qiangxue/fasthttp-routing"
+	"github.com/valyala/fasthttp"
+)
+
+type DataRequestHandler struct {
+	dataRequestService *service.DataRequestService
+}
+
+func NewDataRequestHandler(dataRequestService *service.DataRequestService) *DataRequestHandler {
+	return &DataRequestHandler{dataRequestService: dataRequestService}
+}
+
+// RequestExport godoc
+// @Summary      Export my data
+// @Description  Start building a ZIP bundle of JSON files with everything stored about the authenticated user: profile and preferences, organizations, groups, token metadata, devices, security events and data requests. Poll /users/me/data-requests and download the bundle once the request is completed; an email is sent as well.
+// @Tags         Privacy
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      202  {object}  model.DataRequestResponse
+// @Failure      401  {object}  utils.Response
+// @Failure      409  {object}  utils.Response
+// @Router       /users/me/export [post]
+func (h *DataRequestHandler) RequestExport(c *routing.Context) error {
+	request, err := h.dataRequestService.RequestExport(currentUser(c))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, errorStatus(err, fasthttp.StatusInternalServerError), err.Error())
+		return nil
+	}
+
+	utils.WriteSuccess(c.RequestCtx, fasthttp.StatusAccepted, request.ToResponse())
+	return nil
+}
+
+// RequestErasure godoc
+// @Summary      Request erasure of my data
+// @Description  Ask for the authenticated user's account to be closed and their personal data erased. The request is reviewed by an admin of the current organization. Requires a recent login.
+// @Tags         Privacy
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body body      ErasureRequest false "Optional reason"
+// @Success      202  {object}  model.DataRequestResponse
+// @Failure      400  {object}  utils.Response
+// @Failure      401  {object}  utils.Response
+// @Failure      409  {object}  utils.Response
+// @Router       /users/me/erasure [post]
+func (h *DataRequestHandler) RequestErasure(c *routing.Context) error {
+	var body ErasureRequest
+	if len(c.PostBody()) > 0 {
+		if err := json.Unmarshal(c.PostBody(), &body); err != nil {
+			utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid request body")
+			return nil
+		}
+	}
+
+	if validationErrors := utils.ValidateStruct(&body); validationErrors != nil {
+		utils.WriteJSON(c.RequestCtx, fasthttp.StatusBadRequest, map[string]interface{}{
+			"status": "error",
+			"errors": validationErrors,
+		})
+		return nil
+	}
+
+	request, err := h.dataRequestService.RequestErasure(currentUser(c), body.Reason)
+	if err != nil {
+		utils.WriteError(c.RequestCtx, errorStatus(err, fasthttp.StatusInternalServerError), err.Error())
+		return nil
+	}
+
+	utils.WriteSuccess(c.RequestCtx, fasthttp.StatusAccepted, request.ToResponse())
+	return nil
+}
+
+// GetMyDataRequests godoc
+// @Summary      List my data requests
+// @Description  Get a paginated list of the authenticated user's export and erasure requests, newest first.
+// @Tags         Privacy
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        page    query    int  false  "Page number" default(1)
+// @Param        limit   query    int  false  "Page limit"  default(10)
+// @Success      200     {object} map[string]interface{}
+// @Failure      401     {object} utils.Response
+// @Router       /users/me/data-requests [get]
+func (h *DataRequestHandler) GetMyDataRequests(c *routing.Context) error {
+	ctx := c.RequestCtx
+	page, _ := strconv.Atoi(string(ctx.QueryArgs().Peek("page")))
+	limit, _ := strconv.Atoi(string(ctx.QueryArgs().Peek("limit")))
+
+	// Defaults
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+
+	requests, total, err := h.dataRequestService.GetMyRequests(currentUser(c), page, limit)
+	if err != nil {
+		utils.WriteError(ctx, fasthttp.StatusInternalServerError, err.Error())
+		return nil
+	}
+
+	utils.WriteSuccess(ctx, fasthttp.StatusOK, map[string]interface{}{
+		"results": requests,
+		"page":    page,
+		"limit":   limit,
+		"total":   total,
+	})
+	return nil
+}
+
+// CancelDataRequest godoc
+// @Summary      Cancel my erasure request
+// @Description  Withdraw an erasure request that has not been reviewed yet.
+// @Tags         Privacy
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        requestId path      int  true  "Data Request ID"
+// @Success      200       {object}  model.DataRequestResponse
+// @Failure      400       {object}  utils.Response
+// @Failure      404       {object}  utils.Response
+// @Router       /users/me/data-requests/{requestId} [delete]
+func (h *DataRequestHandler) CancelDataRequest(c *routing.Context) error {
+	id, err := strconv.Atoi(c.Param("requestId"))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid data request ID")
+		return nil
+	}
+
+	request, err := h.dataRequestService.CancelRequest(currentUser(c), uint(id))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, errorStatus(err, fasthttp.StatusBadRequest), err.Error())
+		return nil
+	}
+
+	utils.WriteSuccess(c.RequestCtx, fasthttp.StatusOK, request.ToResponse())
+	return nil
+}
+
+// DownloadExport godoc
+// @Summary      Download my data export
+// @Description  Download the ZIP bundle of a completed export. Bundles are deleted once they expire.
+// @Tags         Privacy
+// @Produce      application/zip
+// @Security     BearerAuth
+// @Param        requestId path      int  true  "Data Request ID"
+// @Success      200       {file}    file
+// @Failure      404       {object}  utils.Response
+// @Failure      409       {object}  utils.Response
+// @Failure      410       {object}  utils.Response
+// @Router       /users/me/data-requests/{requestId}/download [get]
+func (h *DataRequestHandler) DownloadExport(c *routing.Context) error {
+	id, err := strconv.Atoi(c.Param("requestId"))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid data request ID")
+		return nil
+	}
+
+	path, err := h.dataRequestService.ExportFile(currentUser(c), uint(id))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, errorStatus(err, fasthttp.StatusConflict), err.Error())
+		return nil
+	}
+
+	c.SendFile(path)
+	c.Response.Header.SetContentType("application/zip")
+	c.Response.Header.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="data-export-%d.zip"`, id))
+	return nil
+}
+
+// GetDataRequests godoc
+// @Summary      List data requests
+// @Description  Get a paginated list of export and erasure requests made by users of the current organization, newest first. Requires 'data-requests:manage' permission.
+// @Tags         Privacy
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        page    query    int     false  "Page number" default(1)
+// @Param        limit   query    int     false  "Page limit"  default(10)
+// @Param        type    query    string  false  "Filter by type (export, erasure)"
+// @Param        status  query    string  false  "Filter by status (pending, processing, completed, failed, rejected, cancelled)"
+// @Success      200     {object} map[string]interface{}
+// @Failure      403     {object} utils.Response
+// @Router       /data-requests [get]
+func (h *DataRequestHandler) GetDataRequests(c *routing.Context) error {
+	ctx := c.RequestCtx
+	page, _ := strconv.Atoi(string(ctx.QueryArgs().Peek("page")))
+	limit, _ := strconv.Atoi(string(ctx.QueryArgs().Peek("limit")))
+
+	// Defaults
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+
+	requests, total, err := h.dataRequestService.GetRequests(currentUser(c), repository.DataRequestFilter{
+		Page:   page,
+		Limit:  limit,
+		Type:   string(ctx.QueryArgs().Peek("type")),
+		Status: string(ctx.QueryArgs().Peek("status")),
+	})
+	if err != nil {
+		utils.WriteError(ctx, fasthttp.StatusInternalServerError, err.Error())
+		return nil
+	}
+
+	utils.WriteSuccess(ctx, fasthttp.StatusOK, map[string]interface{}{
+		"results": requests,
+		"page":    page,
+		"limit":   limit,
+		"total":   total,
+	})
+	return nil
+}
+
+// GetDataRequest godoc
+// @Summary      Get a data request by ID
+// @Description  Get an export or erasure request made by a user of the current organization. Requires 'data-requests:manage' permission.
+// @Tags         Privacy
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        requestId path      int  true  "Data Request ID"
+// @Success      200       {object}  model.DataRequestResponse
+// @Failure      403       {object}  utils.Response
+// @Failure      404       {object}  utils.Response
+// @Router       /data-requests/{requestId} [get]
+func (h *DataRequestHandler) GetDataRequest(c *routing.Context) error {
+	id, err := strconv.Atoi(c.Param("requestId"))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid data request ID")
+		return nil
+	}
+
+	request, err := h.dataRequestService.GetRequest(currentUser(c), uint(id))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, errorStatus(err, fasthttp.StatusInternalServerError), err.Error())
+		return nil
+	}
+
+	utils.WriteSuccess(c.RequestCtx, fasthttp.StatusOK, request.ToResponse())
+	return nil
+}
+
+// ApproveDataRequest godoc
+// @Summary      Approve an erasure request
+// @Description  Erase the user of a pending erasure request: name and email are replaced, the password, tokens, devices and export bundles are removed, security events are stripped of personal data, and the account is deleted. This cannot be undone. Requires 'data-requests:manage' permission, permission to delete the user and a recent login.
+// @Tags         Privacy
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        requestId path      int  true  "Data Request ID"
+// @Success      200       {object}  model.DataRequestResponse
+// @Failure      400       {object}  utils.Response
+// @Failure      403       {object}  utils.Response
+// @Failure      404       {object}  utils.Response
+// @Failure      409       {object}  utils.Response
+// @Router       /data-requests/{requestId}/approve [post]
+func (h *DataRequestHandler) ApproveDataRequest(c *routing.Context) error {
+	id, err := strconv.Atoi(c.Param("requestId"))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid data request ID")
+		return nil
+	}
+
+	request, err := h.dataRequestService.ApproveErasure(currentUser(c), uint(id))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, errorStatus(err, fasthttp.StatusBadRequest), err.Error())
+		return nil
+	}
+
+	utils.WriteSuccess(c.RequestCtx, fasthttp.StatusOK, request.ToResponse())
+	return nil
+}
+
+// RejectDataRequest godoc
+// @Summary      Reject an erasure request
+// @Description  Decline a pending erasure request, e.g. because the data must be retained by law. The resolution is shown to the user. Requires 'data-requests:manage' permission.
+// @Tags         Privacy
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        requestId path      int                      true  "Data Request ID"
+// @Param        body      body      RejectDataRequestRequest true  "Resolution"
+// @Success      200       {object}  model.DataRequestResponse
+// @Failure      400       {object}  utils.Response
+// @Failure      403       {object}  utils.Response
+// @Failure      404       {object}  utils.Response
+// @Router       /data-requests/{requestId}/reject [post]
+func (h *DataRequestHandler) RejectDataRequest(c *routing.Context) error {
+	id, err := strconv.Atoi(c.Param("requestId"))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid data request ID")
+		return nil
+	}
+
+	var body RejectDataRequestRequest
+	if err := json.Unmarshal(c.PostBody(), &body); err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid request body")
+		return nil
+	}
+
+	if validationErrors := utils.ValidateStruct(&body); validationErrors != nil {
+		utils.WriteJSON(c.RequestCtx, fasthttp.StatusBadRequest, map[string]interface{}{
+			"status": "error",
+			"errors": validationErrors,
+		})
+		return nil
+	}
+
+	request, err := h.dataRequestService.RejectErasure(currentUser(c), uint(id), body.Resolution)
+	if err != nil {
+		utils.WriteError(c.RequestCtx, errorStatus(err, fasthttp.StatusBadRequest), err.Error())
+		return nil
+	}
+
+	utils.WriteSuccess(c.RequestCtx, fasthttp.StatusOK, request.ToResponse())
+	return nil
+}
+
+// --- Request Structs for Swagger & Validation ---
+
+type ErasureRequest struct {
+	Reason string `json:"reason" validate:"max=1000"`
+}
+
+type RejectDataRequestRequest struct {
+	Resolution string `json:"resolution" validate:"required,max=1000"`
+}
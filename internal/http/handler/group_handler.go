@@ -0,0 +1,318 @@
+package handler
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/service"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/pkg/utils"
+	routing "github.com/qiangxue/fasthttp-routing"
+	"github.com/valyala/fasthttp"
+)
+
+type GroupHandler struct {
+	groupService *service.GroupService
+}
+
+func NewGroupHandler(groupService *service.GroupService) *GroupHandler {
+	return &GroupHandler{groupService: groupService}
+}
+
+// GetGroups godoc
+// @Summary      List groups
+// @Description  Get every group of the current organization with its permission set. Requires 'groups:read' permission.
+// @Tags         Groups
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {array}   model.GroupResponse
+// @Failure      403  {object}  utils.Response
+// @Router       /groups [get]
+func (h *GroupHandler) GetGroups(c *routing.Context) error {
+	groups, err := h.groupService.GetGroups(currentUser(c))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusInternalServerError, err.Error())
+		return nil
+	}
+
+	utils.WriteSuccess(c.RequestCtx, fasthttp.StatusOK, groups)
+	return nil
+}
+
+// GetGroup godoc
+// @Summary      Get a group by ID
+// @Description  Get a group of the current organization and its permission set. Requires 'groups:read' permission.
+// @Tags         Groups
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        groupId path      int  true  "Group ID"
+// @Success      200     {object}  model.GroupResponse
+// @Failure      404     {object}  utils.Response
+// @Failure      403     {object}  utils.Response
+// @Router       /groups/{groupId} [get]
+func (h *GroupHandler) GetGroup(c *routing.Context) error {
+	id, err := strconv.Atoi(c.Param("groupId"))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid group ID")
+		return nil
+	}
+
+	group, err := h.groupService.GetGroup(currentUser(c), uint(id))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, errorStatus(err, fasthttp.StatusInternalServerError), err.Error())
+		return nil
+	}
+
+	utils.WriteSuccess(c.RequestCtx, fasthttp.StatusOK, group.ToResponse())
+	return nil
+}
+
+// CreateGroup godoc
+// @Summary      Create a group
+// @Description  Create a group in the current organization. Its permissions are granted to every member in addition to their role's. Only permissions the caller holds can be granted. Requires 'groups:write' permission.
+// @Tags         Groups
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        group body      CreateGroupRequest true "Group Data"
+// @Success      201   {object}  model.GroupResponse
+// @Failure      400   {object}  utils.Response
+// @Failure      403   {object}  utils.Response
+// @Router       /groups [post]
+func (h *GroupHandler) CreateGroup(c *routing.Context) error {
+	var body CreateGroupRequest
+	if err := json.Unmarshal(c.PostBody(), &body); err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid request body")
+		return nil
+	}
+
+	if validationErrors := utils.ValidateStruct(&body); validationErrors != nil {
+		utils.WriteJSON(c.RequestCtx, fasthttp.StatusBadRequest, map[string]interface{}{
+			"status": "error",
+			"errors": validationErrors,
+		})
+		return nil
+	}
+
+	group, err := h.groupService.CreateGroup(currentUser(c), body.Name, body.Description, body.Permissions)
+	if err != nil {
+		utils.WriteError(c.RequestCtx, errorStatus(err, fasthttp.StatusBadRequest), err.Error())
+		return nil
+	}
+
+	utils.WriteSuccess(c.RequestCtx, fasthttp.StatusCreated, group.ToResponse())
+	return nil
+}
+
+// UpdateGroup godoc
+// @Summary      Update a group
+// @Description  Rename a group, change its description and/or replace its permission set. Only permissions the caller holds can be granted. Requires 'groups:write' permission.
+// @Tags         Groups
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        groupId path      int                 true  "Group ID"
+// @Param        group   body      UpdateGroupRequest  true  "Update Data"
+// @Success      200     {object}  model.GroupResponse
+// @Failure      400     {object}  utils.Response
+// @Failure      403     {object}  utils.Response
+// @Failure      404     {object}  utils.Response
+// @Router       /groups/{groupId} [patch]
+func (h *GroupHandler) UpdateGroup(c *routing.Context) error {
+	id, err := strconv.Atoi(c.Param("groupId"))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid group ID")
+		return nil
+	}
+
+	var body UpdateGroupRequest
+	if err := json.Unmarshal(c.PostBody(), &body); err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid request body")
+		return nil
+	}
+
+	if validationErrors := utils.ValidateStruct(&body); validationErrors != nil {
+		utils.WriteJSON(c.RequestCtx, fasthttp.StatusBadRequest, map[string]interface{}{
+			"status": "error",
+			"errors": validationErrors,
+		})
+		return nil
+	}
+
+	var permissions []string
+	if body.Permissions != nil {
+		permissions = *body.Permissions
+		if permissions == nil {
+			permissions = []string{}
+		}
+	}
+
+	group, err := h.groupService.UpdateGroup(currentUser(c), uint(id), body.Name, body.Description, permissions)
+	if err != nil {
+		utils.WriteError(c.RequestCtx, errorStatus(err, fasthttp.StatusBadRequest), err.Error())
+		return nil
+	}
+
+	utils.WriteSuccess(c.RequestCtx, fasthttp.StatusOK, group.ToResponse())
+	return nil
+}
+
+// DeleteGroup godoc
+// @Summary      Delete a group
+// @Description  Delete a group. Its members lose the permissions it granted. Requires 'groups:write' permission.
+// @Tags         Groups
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        groupId path  int  true  "Group ID"
+// @Success      204
+// @Failure      403     {object}  utils.Response
+// @Failure      404     {object}  utils.Response
+// @Router       /groups/{groupId} [delete]
+func (h *GroupHandler) DeleteGroup(c *routing.Context) error {
+	id, err := strconv.Atoi(c.Param("groupId"))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid group ID")
+		return nil
+	}
+
+	if err := h.groupService.DeleteGroup(currentUser(c), uint(id)); err != nil {
+		utils.WriteError(c.RequestCtx, errorStatus(err, fasthttp.StatusInternalServerError), err.Error())
+		return nil
+	}
+
+	c.SetStatusCode(fasthttp.StatusNoContent)
+	return nil
+}
+
+// GetGroupMembers godoc
+// @Summary      List group members
+// @Description  Get a paginated list of the users in a group. Requires 'groups:read' permission.
+// @Tags         Groups
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        groupId path     int  true   "Group ID"
+// @Param        page    query    int  false  "Page number" default(1)
+// @Param        limit   query    int  false  "Page limit"  default(10)
+// @Success      200     {object} map[string]interface{}
+// @Failure      403     {object} utils.Response
+// @Failure      404     {object} utils.Response
+// @Router       /groups/{groupId}/members [get]
+func (h *GroupHandler) GetGroupMembers(c *routing.Context) error {
+	ctx := c.RequestCtx
+	id, err := strconv.Atoi(c.Param("groupId"))
+	if err != nil {
+		utils.WriteError(ctx, fasthttp.StatusBadRequest, "Invalid group ID")
+		return nil
+	}
+
+	page, _ := strconv.Atoi(string(ctx.QueryArgs().Peek("page")))
+	limit, _ := strconv.Atoi(string(ctx.QueryArgs().Peek("limit")))
+
+	// Defaults
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+
+	members, total, err := h.groupService.GetMembers(currentUser(c), uint(id), page, limit)
+	if err != nil {
+		utils.WriteError(ctx, errorStatus(err, fasthttp.StatusInternalServerError), err.Error())
+		return nil
+	}
+
+	utils.WriteSuccess(ctx, fasthttp.StatusOK, map[string]interface{}{
+		"results": members,
+		"page":    page,
+		"limit":   limit,
+		"total":   total,
+	})
+	return nil
+}
+
+// AddGroupMember godoc
+// @Summary      Add a user to a group
+// @Description  Add a member of the current organization to a group. The caller must hold every permission the group grants. Requires 'groups:write' permission.
+// @Tags         Groups
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        groupId path  int  true  "Group ID"
+// @Param        userId  path  int  true  "User ID"
+// @Success      204
+// @Failure      400     {object}  utils.Response
+// @Failure      403     {object}  utils.Response
+// @Failure      404     {object}  utils.Response
+// @Router       /groups/{groupId}/members/{userId} [put]
+func (h *GroupHandler) AddGroupMember(c *routing.Context) error {
+	id, err := strconv.Atoi(c.Param("groupId"))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid group ID")
+		return nil
+	}
+	userID, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid user ID")
+		return nil
+	}
+
+	if err := h.groupService.AddMember(currentUser(c), uint(id), uint(userID)); err != nil {
+		utils.WriteError(c.RequestCtx, errorStatus(err, fasthttp.StatusBadRequest), err.Error())
+		return nil
+	}
+
+	c.SetStatusCode(fasthttp.StatusNoContent)
+	return nil
+}
+
+// RemoveGroupMember godoc
+// @Summary      Remove a user from a group
+// @Description  Remove a user from a group. They lose the permissions it granted. Requires 'groups:write' permission.
+// @Tags         Groups
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        groupId path  int  true  "Group ID"
+// @Param        userId  path  int  true  "User ID"
+// @Success      204
+// @Failure      403     {object}  utils.Response
+// @Failure      404     {object}  utils.Response
+// @Router       /groups/{groupId}/members/{userId} [delete]
+func (h *GroupHandler) RemoveGroupMember(c *routing.Context) error {
+	id, err := strconv.Atoi(c.Param("groupId"))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid group ID")
+		return nil
+	}
+	userID, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid user ID")
+		return nil
+	}
+
+	if err := h.groupService.RemoveMember(currentUser(c), uint(id), uint(userID)); err != nil {
+		utils.WriteError(c.RequestCtx, errorStatus(err, fasthttp.StatusBadRequest), err.Error())
+		return nil
+	}
+
+	c.SetStatusCode(fasthttp.StatusNoContent)
+	return nil
+}
+
+// --- Request Structs for Swagger & Validation ---
+
+type CreateGroupRequest struct {
+	Name        string   `json:"name" validate:"required,max=50"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type UpdateGroupRequest struct {
+	Name        *string   `json:"name" validate:"omitempty,min=1,max=50"`
+	Description *string   `json:"description"`
+	Permissions *[]string `json:"permissions"`
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/model"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/service"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/pkg/utils"
+	routing "github.com/qiangxue/fasthttp-routing"
+	"github.com/valyala/fasthttp"
+)
+
+type InvitationHandler struct {
+	invitationService *service.InvitationService
+}
+
+func NewInvitationHandler(invitationService *service.InvitationService) *InvitationHandler {
+	return &InvitationHandler{invitationService: invitationService}
+}
+
+// CreateInvitation godoc
+// @Summary      Invite a user (Admin)
+// @Description  Create a pending user with a role and email them an invite link to set their own password. Requires 'admin' role.
+// @Tags         Invitations
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        invitation body InvitationRequest true "Invitee"
+// @Success      201  {object}  model.InvitationResponse
+// @Failure      400  {object}  utils.Response
+// @Failure      403  {object}  utils.Response
+// @Router       /users/invitations [post]
+func (h *InvitationHandler) CreateInvitation(c *routing.Context) error {
+	var body InvitationRequest
+	if err := json.Unmarshal(c.PostBody(), &body); err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid request body")
+		return nil
+	}
+
+	if validationErrors := utils.ValidateStruct(&body); validationErrors != nil {
+		utils.WriteJSON(c.RequestCtx, fasthttp.StatusBadRequest, map[string]interface{}{
+			"status": "error",
+			"errors": validationErrors,
+		})
+		return nil
+	}
+
+	actor := currentUser(c)
+
+	invitation, err := h.invitationService.Invite(actor, actor.TenantID, body.Name, body.Email, body.Role, model.OrgRoleMember)
+	if err != nil {
+		utils.WriteError(c.RequestCtx, errorStatus(err, fasthttp.StatusBadRequest), err.Error())
+		return nil
+	}
+
+	utils.WriteSuccess(c.RequestCtx, fasthttp.StatusCreated, invitation.ToResponse())
+	return nil
+}
+
+// GetInvitations godoc
+// @Summary      List pending invitations (Admin)
+// @Description  Get a paginated list of invitations that have not been accepted yet. Requires 'admin' role.
+// @Tags         Invitations
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        page   query    int  false  "Page number" default(1)
+// @Param        limit  query    int  false  "Page limit"  default(10)
+// @Success      200    {object} map[string]interface{}
+// @Failure      403    {object} utils.Response
+// @Router       /users/invitations [get]
+func (h *InvitationHandler) GetInvitations(c *routing.Context) error {
+	ctx := c.RequestCtx
+	page, _ := strconv.Atoi(string(ctx.QueryArgs().Peek("page")))
+	limit, _ := strconv.Atoi(string(ctx.QueryArgs().Peek("limit")))
+
+	// Defaults
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+
+	invitations, total, err := h.invitationService.GetPendingInvitations(currentUser(c), page, limit)
+	if err != nil {
+		utils.WriteError(ctx, fasthttp.StatusInternalServerError, err.Error())
+		return nil
+	}
+
+	utils.WriteSuccess(ctx, fasthttp.StatusOK, map[string]interface{}{
+		"results": invitations,
+		"page":    page,
+		"limit":   limit,
+		"total":   total,
+	})
+	return nil
+}
+
+// ResendInvitation godoc
+// @Summary      Resend an invitation (Admin)
+// @Description  Email a fresh invite link, invalidating the previous one and restarting the expiry window. Requires 'admin' role.
+// @Tags         Invitations
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        invitationId path      int  true  "Invitation ID"
+// @Success      200          {object}  model.InvitationResponse
+// @Failure      404          {object}  utils.Response
+// @Failure      403          {object}  utils.Response
+// @Router       /users/invitations/{invitationId}/resend [post]
+func (h *InvitationHandler) ResendInvitation(c *routing.Context) error {
+	id, err := strconv.Atoi(c.Param("invitationId"))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid invitation ID")
+		return nil
+	}
+
+	invitation, err := h.invitationService.Resend(currentUser(c), uint(id))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusNotFound, err.Error())
+		return nil
+	}
+
+	utils.WriteSuccess(c.RequestCtx, fasthttp.StatusOK, invitation.ToResponse())
+	return nil
+}
+
+// RevokeInvitation godoc
+// @Summary      Revoke an invitation (Admin)
+// @Description  Cancel a pending invitation and permanently delete the pending user. Requires 'admin' role.
+// @Tags         Invitations
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        invitationId path  int  true  "Invitation ID"
+// @Success      204
+// @Failure      404          {object}  utils.Response
+// @Failure      403          {object}  utils.Response
+// @Router       /users/invitations/{invitationId} [delete]
+func (h *InvitationHandler) RevokeInvitation(c *routing.Context) error {
+	id, err := strconv.Atoi(c.Param("invitationId"))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid invitation ID")
+		return nil
+	}
+
+	if err := h.invitationService.Revoke(currentUser(c), uint(id)); err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusNotFound, err.Error())
+		return nil
+	}
+
+	c.SetStatusCode(fasthttp.StatusNoContent)
+	return nil
+}
+
+// --- Request Structs for Swagger & Validation ---
+
+type InvitationRequest struct {
+	Name  string `json:"name" validate:"required"`
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role"`
+}
@@ -0,0 +1,412 @@
+package handler
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/model"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/service"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/pkg/utils"
+	routing "github.com/qiangxue/fasthttp-routing"
+	"github.com/valyala/fasthttp"
+)
+
+type OrganizationHandler struct {
+	organizationService *service.OrganizationService
+	authService         *service.AuthService
+}
+
+func NewOrganizationHandler(organizationService *service.OrganizationService, authService *service.AuthService) *OrganizationHandler {
+	return &OrganizationHandler{
+		organizationService: organizationService,
+		authService:         authService,
+	}
+}
+
+// GetOrganizations godoc
+// @Summary      List my organizations
+// @Description  Get every organization the current user is a member of, with their role in it. The organization the token acts in is marked as current.
+// @Tags         Organizations
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {array}   model.OrganizationResponse
+// @Failure      401  {object}  utils.Response
+// @Router       /organizations [get]
+func (h *OrganizationHandler) GetOrganizations(c *routing.Context) error {
+	organizations, err := h.organizationService.GetOrganizations(currentUser(c))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusInternalServerError, err.Error())
+		return nil
+	}
+
+	utils.WriteSuccess(c.RequestCtx, fasthttp.StatusOK, organizations)
+	return nil
+}
+
+// CreateOrganization godoc
+// @Summary      Create an organization
+// @Description  Create a new organization owned by the current user. Use the switch endpoint to start working in it.
+// @Tags         Organizations
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        organization body      CreateOrganizationRequest true "Organization Data"
+// @Success      201          {object}  model.OrganizationResponse
+// @Failure      400          {object}  utils.Response
+// @Router       /organizations [post]
+func (h *OrganizationHandler) CreateOrganization(c *routing.Context) error {
+	var body CreateOrganizationRequest
+	if err := json.Unmarshal(c.PostBody(), &body); err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid request body")
+		return nil
+	}
+
+	if validationErrors := utils.ValidateStruct(&body); validationErrors != nil {
+		utils.WriteJSON(c.RequestCtx, fasthttp.StatusBadRequest, map[string]interface{}{
+			"status": "error",
+			"errors": validationErrors,
+		})
+		return nil
+	}
+
+	organization, err := h.organizationService.CreateOrganization(currentUser(c), body.Name)
+	if err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, err.Error())
+		return nil
+	}
+
+	utils.WriteSuccess(c.RequestCtx, fasthttp.StatusCreated, organization)
+	return nil
+}
+
+// GetOrganization godoc
+// @Summary      Get an organization
+// @Description  Get an organization the current user is a member of.
+// @Tags         Organizations
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        orgId path      int  true  "Organization ID"
+// @Success      200   {object}  model.OrganizationResponse
+// @Failure      404   {object}  utils.Response
+// @Router       /organizations/{orgId} [get]
+func (h *OrganizationHandler) GetOrganization(c *routing.Context) error {
+	id, err := strconv.Atoi(c.Param("orgId"))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid organization ID")
+		return nil
+	}
+
+	organization, err := h.organizationService.GetOrganization(currentUser(c), uint(id))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, errorStatus(err, fasthttp.StatusInternalServerError), err.Error())
+		return nil
+	}
+
+	utils.WriteSuccess(c.RequestCtx, fasthttp.StatusOK, organization)
+	return nil
+}
+
+// UpdateOrganization godoc
+// @Summary      Rename an organization
+// @Description  Change the name of an organization. Requires the 'owner' or 'admin' organization role.
+// @Tags         Organizations
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        orgId        path      int                        true  "Organization ID"
+// @Param        organization body      CreateOrganizationRequest  true  "Organization Data"
+// @Success      200          {object}  model.OrganizationResponse
+// @Failure      400          {object}  utils.Response
+// @Failure      403          {object}  utils.Response
+// @Failure      404          {object}  utils.Response
+// @Router       /organizations/{orgId} [patch]
+func (h *OrganizationHandler) UpdateOrganization(c *routing.Context) error {
+	id, err := strconv.Atoi(c.Param("orgId"))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid organization ID")
+		return nil
+	}
+
+	var body CreateOrganizationRequest
+	if err := json.Unmarshal(c.PostBody(), &body); err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid request body")
+		return nil
+	}
+
+	if validationErrors := utils.ValidateStruct(&body); validationErrors != nil {
+		utils.WriteJSON(c.RequestCtx, fasthttp.StatusBadRequest, map[string]interface{}{
+			"status": "error",
+			"errors": validationErrors,
+		})
+		return nil
+	}
+
+	organization, err := h.organizationService.UpdateOrganization(currentUser(c), uint(id), body.Name)
+	if err != nil {
+		utils.WriteError(c.RequestCtx, errorStatus(err, fasthttp.StatusBadRequest), err.Error())
+		return nil
+	}
+
+	utils.WriteSuccess(c.RequestCtx, fasthttp.StatusOK, organization)
+	return nil
+}
+
+// DeleteOrganization godoc
+// @Summary      Delete an organization
+// @Description  Delete an organization and all of its memberships. User accounts are kept. Requires the 'owner' organization role and a recent login.
+// @Tags         Organizations
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        orgId path  int  true  "Organization ID"
+// @Success      204
+// @Failure      401   {object}  utils.Response
+// @Failure      403   {object}  utils.Response
+// @Failure      404   {object}  utils.Response
+// @Router       /organizations/{orgId} [delete]
+func (h *OrganizationHandler) DeleteOrganization(c *routing.Context) error {
+	id, err := strconv.Atoi(c.Param("orgId"))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid organization ID")
+		return nil
+	}
+
+	if err := h.organizationService.DeleteOrganization(currentUser(c), uint(id)); err != nil {
+		utils.WriteError(c.RequestCtx, errorStatus(err, fasthttp.StatusInternalServerError), err.Error())
+		return nil
+	}
+
+	c.SetStatusCode(fasthttp.StatusNoContent)
+	return nil
+}
+
+// SwitchOrganization godoc
+// @Summary      Switch organization
+// @Description  Make the organization the current one and get tokens acting in it. Later logins start in this organization.
+// @Tags         Organizations
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        orgId path      int  true  "Organization ID"
+// @Success      200   {object}  service.AuthTokens
+// @Failure      404   {object}  utils.Response
+// @Router       /organizations/{orgId}/switch [post]
+func (h *OrganizationHandler) SwitchOrganization(c *routing.Context) error {
+	id, err := strconv.Atoi(c.Param("orgId"))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid organization ID")
+		return nil
+	}
+
+	claims, _ := c.Get("claims").(*service.Claims)
+
+	tokens, err := h.authService.SwitchOrganization(currentUser(c), claims, uint(id))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, errorStatus(err, fasthttp.StatusInternalServerError), err.Error())
+		return nil
+	}
+
+	utils.WriteSuccess(c.RequestCtx, fasthttp.StatusOK, tokens)
+	return nil
+}
+
+// GetMembers godoc
+// @Summary      List organization members
+// @Description  Get a paginated list of the members of an organization and their roles. Any member may list the members.
+// @Tags         Organizations
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        orgId  path     int  true   "Organization ID"
+// @Param        page   query    int  false  "Page number" default(1)
+// @Param        limit  query    int  false  "Page limit"  default(10)
+// @Success      200    {object} map[string]interface{}
+// @Failure      404    {object} utils.Response
+// @Router       /organizations/{orgId}/members [get]
+func (h *OrganizationHandler) GetMembers(c *routing.Context) error {
+	ctx := c.RequestCtx
+	id, err := strconv.Atoi(c.Param("orgId"))
+	if err != nil {
+		utils.WriteError(ctx, fasthttp.StatusBadRequest, "Invalid organization ID")
+		return nil
+	}
+
+	page, _ := strconv.Atoi(string(ctx.QueryArgs().Peek("page")))
+	limit, _ := strconv.Atoi(string(ctx.QueryArgs().Peek("limit")))
+
+	// Defaults
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+
+	members, total, err := h.organizationService.GetMembers(currentUser(c), uint(id), page, limit)
+	if err != nil {
+		utils.WriteError(ctx, errorStatus(err, fasthttp.StatusInternalServerError), err.Error())
+		return nil
+	}
+
+	utils.WriteSuccess(ctx, fasthttp.StatusOK, map[string]interface{}{
+		"results": members,
+		"page":    page,
+		"limit":   limit,
+		"total":   total,
+	})
+	return nil
+}
+
+// InviteMember godoc
+// @Summary      Invite a member
+// @Description  Add a user to the organization. Existing accounts join immediately; new email addresses receive an invitation to create their account. Requires the 'owner' or 'admin' organization role; only owners may add owners.
+// @Tags         Organizations
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        orgId  path      int                  true  "Organization ID"
+// @Param        member body      InviteMemberRequest  true  "Invitee"
+// @Success      201    {object}  model.MemberResponse
+// @Failure      400    {object}  utils.Response
+// @Failure      403    {object}  utils.Response
+// @Failure      404    {object}  utils.Response
+// @Router       /organizations/{orgId}/members [post]
+func (h *OrganizationHandler) InviteMember(c *routing.Context) error {
+	id, err := strconv.Atoi(c.Param("orgId"))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid organization ID")
+		return nil
+	}
+
+	var body InviteMemberRequest
+	if err := json.Unmarshal(c.PostBody(), &body); err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid request body")
+		return nil
+	}
+
+	if validationErrors := utils.ValidateStruct(&body); validationErrors != nil {
+		utils.WriteJSON(c.RequestCtx, fasthttp.StatusBadRequest, map[string]interface{}{
+			"status": "error",
+			"errors": validationErrors,
+		})
+		return nil
+	}
+
+	if body.Role == "" {
+		body.Role = model.OrgRoleMember
+	}
+
+	member, err := h.organizationService.InviteMember(currentUser(c), uint(id), body.Name, body.Email, body.Role)
+	if err != nil {
+		utils.WriteError(c.RequestCtx, errorStatus(err, fasthttp.StatusBadRequest), err.Error())
+		return nil
+	}
+
+	utils.WriteSuccess(c.RequestCtx, fasthttp.StatusCreated, member)
+	return nil
+}
+
+// UpdateMember godoc
+// @Summary      Change a member's role
+// @Description  Change the organization role of a member. Requires the 'owner' or 'admin' organization role; only owners may grant or revoke ownership. The last owner cannot be demoted.
+// @Tags         Organizations
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        orgId  path      int                  true  "Organization ID"
+// @Param        userId path      int                  true  "User ID"
+// @Param        member body      UpdateMemberRequest  true  "New Role"
+// @Success      200    {object}  model.MemberResponse
+// @Failure      400    {object}  utils.Response
+// @Failure      403    {object}  utils.Response
+// @Failure      404    {object}  utils.Response
+// @Failure      409    {object}  utils.Response
+// @Router       /organizations/{orgId}/members/{userId} [patch]
+func (h *OrganizationHandler) UpdateMember(c *routing.Context) error {
+	id, err := strconv.Atoi(c.Param("orgId"))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid organization ID")
+		return nil
+	}
+	userID, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid user ID")
+		return nil
+	}
+
+	var body UpdateMemberRequest
+	if err := json.Unmarshal(c.PostBody(), &body); err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid request body")
+		return nil
+	}
+
+	if validationErrors := utils.ValidateStruct(&body); validationErrors != nil {
+		utils.WriteJSON(c.RequestCtx, fasthttp.StatusBadRequest, map[string]interface{}{
+			"status": "error",
+			"errors": validationErrors,
+		})
+		return nil
+	}
+
+	member, err := h.organizationService.UpdateMemberRole(currentUser(c), uint(id), uint(userID), body.Role)
+	if err != nil {
+		utils.WriteError(c.RequestCtx, errorStatus(err, fasthttp.StatusBadRequest), err.Error())
+		return nil
+	}
+
+	utils.WriteSuccess(c.RequestCtx, fasthttp.StatusOK, member)
+	return nil
+}
+
+// RemoveMember godoc
+// @Summary      Remove a member
+// @Description  Remove a user from the organization. Members may always leave; removing others requires the 'owner' or 'admin' organization role. The last owner cannot leave.
+// @Tags         Organizations
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        orgId  path  int  true  "Organization ID"
+// @Param        userId path  int  true  "User ID"
+// @Success      204
+// @Failure      403    {object}  utils.Response
+// @Failure      404    {object}  utils.Response
+// @Failure      409    {object}  utils.Response
+// @Router       /organizations/{orgId}/members/{userId} [delete]
+func (h *OrganizationHandler) RemoveMember(c *routing.Context) error {
+	id, err := strconv.Atoi(c.Param("orgId"))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid organization ID")
+		return nil
+	}
+	userID, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid user ID")
+		return nil
+	}
+
+	if err := h.organizationService.RemoveMember(currentUser(c), uint(id), uint(userID)); err != nil {
+		utils.WriteError(c.RequestCtx, errorStatus(err, fasthttp.StatusBadRequest), err.Error())
+		return nil
+	}
+
+	c.SetStatusCode(fasthttp.StatusNoContent)
+	return nil
+}
+
+// --- Request Structs for Swagger & Validation ---
+
+type CreateOrganizationRequest struct {
+	Name string `json:"name" validate:"required"`
+}
+
+type InviteMemberRequest struct {
+	Name  string `json:"name"`
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" example:"member"`
+}
+
+type UpdateMemberRequest struct {
+	Role string `json:"role" validate:"required" example:"admin"`
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"encoding/json"
+
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/model"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/policy"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/service"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/pkg/utils"
+	routing "github.com/qiangxue/fasthttp-routing"
+	"github.com/valyala/fasthttp"
+)
+
+type PolicyHandler struct {
+	userService *service.UserService
+}
+
+func NewPolicyHandler(userService *service.UserService) *PolicyHandler {
+	return &PolicyHandler{userService: userService}
+}
+
+// Decide godoc
+// @Summary      Explain an access policy decision
+// @Description  Evaluate the access policy for a user of the current organization performing an action, optionally on another user, and explain which rules matched. Permissions from the subject's groups are taken into account. Requires the 'roles:read' permission.
+// @Tags         Roles
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body body      PolicyDecisionRequest true "Access Question"
+// @Success      200  {object}  PolicyDecisionResponse
+// @Failure      400  {object}  utils.Response
+// @Failure      403  {object}  utils.Response
+// @Failure      404  {object}  utils.Response
+// @Router       /policy/decide [post]
+func (h *PolicyHandler) Decide(c *routing.Context) error {
+	ctx := c.RequestCtx
+	var req PolicyDecisionRequest
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		utils.WriteError(ctx, fasthttp.StatusBadRequest, "Invalid request body")
+		return nil
+	}
+
+	if validationErrors := utils.ValidateStruct(&req); validationErrors != nil {
+		utils.WriteJSON(ctx, fasthttp.StatusBadRequest, map[string]interface{}{
+			"status": "error",
+			"errors": validationErrors,
+		})
+		return nil
+	}
+
+	actor := currentUser(c)
+
+	subject, err := h.userService.GetMember(actor, req.SubjectID)
+	if err != nil {
+		utils.WriteError(ctx, errorStatus(err, fasthttp.StatusInternalServerError), err.Error())
+		return nil
+	}
+
+	var resource *model.User
+	if req.ResourceID != 0 {
+		if resource, err = h.userService.GetMember(actor, req.ResourceID); err != nil {
+			utils.WriteError(ctx, errorStatus(err, fasthttp.StatusInternalServerError), err.Error())
+			return nil
+		}
+	}
+
+	decision, err := h.userService.Decide(subject, req.Action, resource)
+	if err != nil {
+		utils.WriteError(ctx, fasthttp.StatusInternalServerError, err.Error())
+		return nil
+	}
+
+	utils.WriteSuccess(ctx, fasthttp.StatusOK, PolicyDecisionResponse{
+		Decision: decision,
+		Explain:  decision.Explain(),
+	})
+	return nil
+}
+
+// --- Request Structs for Swagger & Validation ---
+
+type PolicyDecisionRequest struct {
+	SubjectID  uint   `json:"subjectId" validate:"required"`
+	Action     string `json:"action" validate:"required"`
+	ResourceID uint   `json:"resourceId"`
+}
+
+type PolicyDecisionResponse struct {
+	policy.Decision
+	Explain string `json:"explain"`
+}
@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/config"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/model"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/repository"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/service"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/pkg/jsonpatch"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/pkg/utils"
+	routing "github.com/qiangxue/fasthttp-routing"
+	"github.com/valyala/fasthttp"
+)
+
+// clientInfo extracts the caller's IP address and user agent from the request.
+// X-Forwarded-For is only honoured when the app is configured to sit behind a proxy.
+func clientInfo(ctx *fasthttp.RequestCtx) service.ClientInfo {
+	ip := ctx.RemoteIP().String()
+	if config.AppConfig != nil && config.AppConfig.TrustProxyHeaders {
+		if forwarded := string(ctx.Request.Header.Peek("X-Forwarded-For")); forwarded != "" {
+			ip = strings.TrimSpace(strings.Split(forwarded, ",")[0])
+		}
+	}
+
+	return service.ClientInfo{
+		IP:        ip,
+		UserAgent: string(ctx.UserAgent()),
+	}
+}
+
+// parseTimeQuery reads an optional RFC3339 or YYYY-MM-DD timestamp from the query string
+func parseTimeQuery(ctx *fasthttp.RequestCtx, key string) (*time.Time, bool) {
+	raw := string(ctx.QueryArgs().Peek(key))
+	if raw == "" {
+		return nil, true
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return &t, true
+	}
+	if t, err := time.Parse("2006-01-02", raw); err == nil {
+		return &t, true
+	}
+	return nil, false
+}
+
+// errPreconditionRequired is answered to writes sent without an If-Match header
+var errPreconditionRequired = errors.New("If-Match header required; send the ETag of the user as last read")
+
+// setETag sends the version of a resource as a strong entity tag
+func setETag(ctx *fasthttp.RequestCtx, version uint) {
+	ctx.Response.Header.Set("ETag", `"`+strconv.FormatUint(uint64(version), 10)+`"`)
+}
+
+// setLastModified sends when a resource last changed, if known
+func setLastModified(ctx *fasthttp.RequestCtx, modified time.Time) {
+	if !modified.IsZero() {
+		ctx.Response.Header.SetLastModified(modified)
+	}
+}
+
+// ifMatchVersion reads the version a write expects from the If-Match header.
+// "*" matches any version and is returned as 0. Tags of limited representations,
+// like "3-1f2e3d4c5b6a7988", expect the version before the dash. A missing header
+// answers 428 and a tag that can never match answers 412, in which case ok is false.
+func ifMatchVersion(ctx *fasthttp.RequestCtx) (version uint, ok bool) {
+	header := strings.TrimSpace(string(ctx.Request.Header.Peek("If-Match")))
+	if header == "" {
+		utils.WriteError(ctx, fasthttp.StatusPreconditionRequired, errPreconditionRequired.Error())
+		return 0, false
+	}
+	if header == "*" {
+		return 0, true
+	}
+
+	// Weak tags never match for If-Match; of a list, the first tag is used
+	tag := strings.TrimSpace(strings.Split(header, ",")[0])
+	number, _, _ := strings.Cut(strings.Trim(tag, `"`), "-")
+	parsed, err := strconv.ParseUint(number, 10, 64)
+	if strings.HasPrefix(tag, "W/") || !strings.HasPrefix(tag, `"`) || err != nil || parsed == 0 {
+		utils.WriteError(ctx, fasthttp.StatusPreconditionFailed, repository.ErrVersionConflict.Error())
+		return 0, false
+	}
+	return uint(parsed), true
+}
+
+// acceptPatch lists the media types PATCH /users/{id} accepts
+var acceptPatch = strings.Join([]string{jsonpatch.MediaTypeMergePatch, jsonpatch.MediaTypeJSONPatch, "application/json"}, ", ")
+
+// patchFormat picks the patch format from the Content-Type. Plain JSON, and bodies
+// sent without a type, are read as merge patches.
+func patchFormat(ctx *fasthttp.RequestCtx) (string, bool) {
+	mediaType := strings.TrimSpace(strings.Split(string(ctx.Request.Header.ContentType()), ";")[0])
+	switch strings.ToLower(mediaType) {
+	case jsonpatch.MediaTypeJSONPatch:
+		return service.PatchFormatJSON, true
+	case jsonpatch.MediaTypeMergePatch, "application/json", "":
+		return service.PatchFormatMerge, true
+	}
+	ctx.Response.Header.Set("Accept-Patch", acceptPatch)
+	utils.WriteError(ctx, fasthttp.StatusUnsupportedMediaType, "Unsupported patch format; use "+acceptPatch)
+	return "", false
+}
+
+// currentUser returns the authenticated user loaded by the auth middleware
+func currentUser(c *routing.Context) *model.User {
+	user, _ := c.Get("user").(*model.User)
+	return user
+}
+
+// errorStatus maps well-known service errors to HTTP status codes
+func errorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, service.ErrForbidden), errors.Is(err, service.ErrAccountPending),
+		errors.Is(err, service.ErrAccountSuspended), errors.Is(err, service.ErrAccountBanned):
+		return fasthttp.StatusForbidden
+	case errors.Is(err, service.ErrLastAdmin), errors.Is(err, service.ErrLastOwner),
+		errors.Is(err, service.ErrDataRequestInProgress), errors.Is(err, service.ErrPatchTestFailed):
+		return fasthttp.StatusConflict
+	case errors.Is(err, service.ErrOrganizationNotFound), errors.Is(err, service.ErrMemberNotFound),
+		errors.Is(err, service.ErrGroupNotFound), errors.Is(err, service.ErrDataRequestNotFound),
+		errors.Is(err, service.ErrImportJobNotFound), errors.Is(err, service.ErrUserNotFound):
+		return fasthttp.StatusNotFound
+	case errors.Is(err, repository.ErrVersionConflict):
+		return fasthttp.StatusPreconditionFailed
+	case errors.Is(err, service.ErrExportExpired):
+		return fasthttp.StatusGone
+	case errors.Is(err, repository.ErrInvalidCursor), errors.Is(err, repository.ErrInvalidFilter),
+		errors.Is(err, service.ErrInvalidFieldset), errors.Is(err, service.ErrInvalidImport),
+		errors.Is(err, service.ErrInvalidBulk), errors.Is(err, service.ErrInvalidPatch):
+		return fasthttp.StatusBadRequest
+	}
+	return fallback
+}
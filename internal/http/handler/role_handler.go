@@ -0,0 +1,209 @@
+package handler
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/service"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/pkg/utils"
+	routing "github.com/qiangxue/fasthttp-routing"
+	"github.com/valyala/fasthttp"
+)
+
+type RoleHandler struct {
+	roleService *service.RoleService
+}
+
+func NewRoleHandler(roleService *service.RoleService) *RoleHandler {
+	return &RoleHandler{roleService: roleService}
+}
+
+// GetRoles godoc
+// @Summary      List roles
+// @Description  Get every role with its permission set. Requires 'roles:read' permission.
+// @Tags         Roles
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {array}   model.RoleResponse
+// @Failure      403  {object}  utils.Response
+// @Router       /roles [get]
+func (h *RoleHandler) GetRoles(ctx *fasthttp.RequestCtx) {
+	roles, err := h.roleService.GetRoles()
+	if err != nil {
+		utils.WriteError(ctx, fasthttp.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(ctx, fasthttp.StatusOK, roles)
+}
+
+// GetPermissions godoc
+// @Summary      List permissions
+// @Description  Get every permission that can be granted to roles. Requires 'roles:read' permission.
+// @Tags         Roles
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {array}   model.Permission
+// @Failure      403  {object}  utils.Response
+// @Router       /permissions [get]
+func (h *RoleHandler) GetPermissions(ctx *fasthttp.RequestCtx) {
+	permissions, err := h.roleService.GetPermissions()
+	if err != nil {
+		utils.WriteError(ctx, fasthttp.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(ctx, fasthttp.StatusOK, permissions)
+}
+
+// GetRole godoc
+// @Summary      Get a role by ID
+// @Description  Get a role and its permission set. Requires 'roles:read' permission.
+// @Tags         Roles
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        roleId path      int  true  "Role ID"
+// @Success      200    {object}  model.RoleResponse
+// @Failure      404    {object}  utils.Response
+// @Failure      403    {object}  utils.Response
+// @Router       /roles/{roleId} [get]
+func (h *RoleHandler) GetRole(c *routing.Context) error {
+	id, err := strconv.Atoi(c.Param("roleId"))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid role ID")
+		return nil
+	}
+
+	role, err := h.roleService.GetRoleByID(uint(id))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusNotFound, err.Error())
+		return nil
+	}
+
+	utils.WriteSuccess(c.RequestCtx, fasthttp.StatusOK, role.ToResponse())
+	return nil
+}
+
+// CreateRole godoc
+// @Summary      Create a role
+// @Description  Create a role with a set of permissions. Requires 'roles:write' permission.
+// @Tags         Roles
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        role body      CreateRoleRequest true "Role Data"
+// @Success      201  {object}  model.RoleResponse
+// @Failure      400  {object}  utils.Response
+// @Failure      403  {object}  utils.Response
+// @Router       /roles [post]
+func (h *RoleHandler) CreateRole(ctx *fasthttp.RequestCtx) {
+	var body CreateRoleRequest
+	if err := json.Unmarshal(ctx.PostBody(), &body); err != nil {
+		utils.WriteError(ctx, fasthttp.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if validationErrors := utils.ValidateStruct(&body); validationErrors != nil {
+		utils.WriteJSON(ctx, fasthttp.StatusBadRequest, map[string]interface{}{
+			"status": "error",
+			"errors": validationErrors,
+		})
+		return
+	}
+
+	role, err := h.roleService.CreateRole(body.Name, body.Description, body.Permissions)
+	if err != nil {
+		utils.WriteError(ctx, fasthttp.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(ctx, fasthttp.StatusCreated, role.ToResponse())
+}
+
+// UpdateRole godoc
+// @Summary      Update a role
+// @Description  Update the description and/or replace the permission set of a role. Role names cannot be changed. Requires 'roles:write' permission.
+// @Tags         Roles
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        roleId path      int                true  "Role ID"
+// @Param        role   body      UpdateRoleRequest  true  "Update Data"
+// @Success      200    {object}  model.RoleResponse
+// @Failure      400    {object}  utils.Response
+// @Failure      403    {object}  utils.Response
+// @Router       /roles/{roleId} [patch]
+func (h *RoleHandler) UpdateRole(c *routing.Context) error {
+	id, err := strconv.Atoi(c.Param("roleId"))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid role ID")
+		return nil
+	}
+
+	var body UpdateRoleRequest
+	if err := json.Unmarshal(c.PostBody(), &body); err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid request body")
+		return nil
+	}
+
+	var permissions []string
+	if body.Permissions != nil {
+		permissions = *body.Permissions
+		if permissions == nil {
+			permissions = []string{}
+		}
+	}
+
+	role, err := h.roleService.UpdateRole(uint(id), body.Description, permissions)
+	if err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, err.Error())
+		return nil
+	}
+
+	utils.WriteSuccess(c.RequestCtx, fasthttp.StatusOK, role.ToResponse())
+	return nil
+}
+
+// DeleteRole godoc
+// @Summary      Delete a role
+// @Description  Delete a custom role that is no longer assigned to any user. Requires 'roles:write' permission.
+// @Tags         Roles
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        roleId path  int  true  "Role ID"
+// @Success      204
+// @Failure      400    {object}  utils.Response
+// @Failure      403    {object}  utils.Response
+// @Router       /roles/{roleId} [delete]
+func (h *RoleHandler) DeleteRole(c *routing.Context) error {
+	id, err := strconv.Atoi(c.Param("roleId"))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid role ID")
+		return nil
+	}
+
+	if err := h.roleService.DeleteRole(uint(id)); err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, err.Error())
+		return nil
+	}
+
+	c.SetStatusCode(fasthttp.StatusNoContent)
+	return nil
+}
+
+// --- Request Structs for Swagger & Validation ---
+
+type CreateRoleRequest struct {
+	Name        string   `json:"name" validate:"required,max=50"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type UpdateRoleRequest struct {
+	Description *string   `json:"description"`
+	Permissions *[]string `json:"permissions"`
+}
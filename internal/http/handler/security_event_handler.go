@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/repository"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/service"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/pkg/utils"
+	routing "github.com/qiangxue/fasthttp-routing"
+	"github.com/valyala/fasthttp"
+)
+
+type SecurityEventHandler struct {
+	securityEventService *service.SecurityEventService
+}
+
+func NewSecurityEventHandler(securityEventService *service.SecurityEventService) *SecurityEventHandler {
+	return &SecurityEventHandler{securityEventService: securityEventService}
+}
+
+// GetMyEvents godoc
+// @Summary      Get my security events
+// @Description  Get a paginated login history and security event log for the authenticated user.
+// @Tags         Security Events
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        page   query    int     false  "Page number" default(1)
+// @Param        limit  query    int     false  "Page limit"  default(10)
+// @Param        type   query    string  false  "Event type (loginSuccess, loginFailure, refresh, logout, passwordChange, tokenReuse)"
+// @Param        from   query    string  false  "Only events at or after this time (RFC3339 or YYYY-MM-DD)"
+// @Param        to     query    string  false  "Only events at or before this time (RFC3339 or YYYY-MM-DD)"
+// @Success      200    {object} map[string]interface{}
+// @Failure      400    {object} utils.Response
+// @Failure      401    {object} utils.Response
+// @Router       /users/me/security-events [get]
+func (h *SecurityEventHandler) GetMyEvents(c *routing.Context) error {
+	userID, _ := c.Get("userID").(uint)
+
+	filter, ok := parseSecurityEventFilter(c.RequestCtx)
+	if !ok {
+		return nil
+	}
+	filter.UserID = userID
+
+	h.writeEvents(c.RequestCtx, filter)
+	return nil
+}
+
+// GetEvents godoc
+// @Summary      Get security events (Admin)
+// @Description  Get a paginated security event log across all members of the current organization. Requires 'admin' role.
+// @Tags         Security Events
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        page    query    int     false  "Page number" default(1)
+// @Param        limit   query    int     false  "Page limit"  default(10)
+// @Param        userId  query    int     false  "Filter by user ID"
+// @Param        type    query    string  false  "Event type (loginSuccess, loginFailure, refresh, logout, passwordChange, tokenReuse)"
+// @Param        ip      query    string  false  "Filter by client IP"
+// @Param        from    query    string  false  "Only events at or after this time (RFC3339 or YYYY-MM-DD)"
+// @Param        to      query    string  false  "Only events at or before this time (RFC3339 or YYYY-MM-DD)"
+// @Success      200     {object} map[string]interface{}
+// @Failure      400     {object} utils.Response
+// @Failure      403     {object} utils.Response
+// @Router       /security-events [get]
+func (h *SecurityEventHandler) GetEvents(c *routing.Context) error {
+	ctx := c.RequestCtx
+	filter, ok := parseSecurityEventFilter(ctx)
+	if !ok {
+		return nil
+	}
+
+	if raw := string(ctx.QueryArgs().Peek("userId")); raw != "" {
+		userID, err := strconv.Atoi(raw)
+		if err != nil || userID < 1 {
+			utils.WriteError(ctx, fasthttp.StatusBadRequest, "Invalid user ID")
+			return nil
+		}
+		filter.UserID = uint(userID)
+	}
+	filter.OrganizationID = currentUser(c).TenantID
+
+	h.writeEvents(ctx, filter)
+	return nil
+}
+
+func (h *SecurityEventHandler) writeEvents(ctx *fasthttp.RequestCtx, filter repository.SecurityEventFilter) {
+	events, total, err := h.securityEventService.GetEvents(filter)
+	if err != nil {
+		utils.WriteError(ctx, fasthttp.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(ctx, fasthttp.StatusOK, map[string]interface{}{
+		"results": events,
+		"page":    filter.Page,
+		"limit":   filter.Limit,
+		"total":   total,
+	})
+}
+
+// parseSecurityEventFilter reads the shared query parameters, writing a 400 response on failure
+func parseSecurityEventFilter(ctx *fasthttp.RequestCtx) (repository.SecurityEventFilter, bool) {
+	page, _ := strconv.Atoi(string(ctx.QueryArgs().Peek("page")))
+	limit, _ := strconv.Atoi(string(ctx.QueryArgs().Peek("limit")))
+
+	// Defaults
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+
+	from, ok := parseTimeQuery(ctx, "from")
+	if !ok {
+		utils.WriteError(ctx, fasthttp.StatusBadRequest, "Invalid 'from' timestamp")
+		return repository.SecurityEventFilter{}, false
+	}
+	to, ok := parseTimeQuery(ctx, "to")
+	if !ok {
+		utils.WriteError(ctx, fasthttp.StatusBadRequest, "Invalid 'to' timestamp")
+		return repository.SecurityEventFilter{}, false
+	}
+
+	return repository.SecurityEventFilter{
+		Page:  page,
+		Limit: limit,
+		Type:  string(ctx.QueryArgs().Peek("type")),
+		IP:    string(ctx.QueryArgs().Peek("ip")),
+		From:  from,
+		To:    to,
+	}, true
+}
@@ -1,11 +1,19 @@
 package handler
 
 import (
+	"bufio"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"strconv"
+	"time"
 
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/http/middleware"
 	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/model"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/repository"
 	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/service"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/pkg/tabular"
 	"github.com/mnabielap/starter-kit-restapi-gofasthttp/pkg/utils"
 	routing "github.com/qiangxue/fasthttp-routing"
 	"github.com/valyala/fasthttp"
@@ -21,7 +29,7 @@ func NewUserHandler(userService *service.UserService) *UserHandler {
 
 // CreateUser godoc
 // @Summary      Create a new user (Admin)
-// @Description  Create a new user manually. Requires 'admin' role.
+// @Description  Create a new user manually. The user joins the caller's current organization as a member. Requires 'admin' role.
 // @Tags         Users
 // @Accept       json
 // @Produce      json
@@ -31,11 +39,12 @@ func NewUserHandler(userService *service.UserService) *UserHandler {
 // @Failure      400  {object}  utils.Response
 // @Failure      403  {object}  utils.Response
 // @Router       /users [post]
-func (h *UserHandler) CreateUser(ctx *fasthttp.RequestCtx) {
+func (h *UserHandler) CreateUser(c *routing.Context) error {
+	ctx := c.RequestCtx
 	var user model.User
 	if err := json.Unmarshal(ctx.PostBody(), &user); err != nil {
 		utils.WriteError(ctx, fasthttp.StatusBadRequest, "Invalid request body")
-		return
+		return nil
 	}
 
 	if validationErrors := utils.ValidateStruct(&user); validationErrors != nil {
@@ -43,42 +52,55 @@ func (h *UserHandler) CreateUser(ctx *fasthttp.RequestCtx) {
 			"status": "error",
 			"errors": validationErrors,
 		})
-		return
+		return nil
 	}
 
-	createdUser, err := h.userService.CreateUser(&user)
+	createdUser, err := h.userService.CreateMember(currentUser(c), &user)
 	if err != nil {
-		utils.WriteError(ctx, fasthttp.StatusBadRequest, err.Error())
-		return
+		utils.WriteError(ctx, errorStatus(err, fasthttp.StatusBadRequest), err.Error())
+		return nil
 	}
 
+	setETag(ctx, createdUser.Version)
 	utils.WriteSuccess(ctx, fasthttp.StatusCreated, createdUser.ToResponse())
+	return nil
 }
 
 // GetUsers godoc
 // @Summary      Get all users
-// @Description  Get a paginated list of users with search, filter, and sort options. Requires 'admin' role.
+// @Description  Get a paginated list of users with search, filter, and sort options. Requires the 'users:read' permission. Users and fields hidden by the access policy are left out. Offset mode pages by page/limit. Cursor mode returns nextCursor/prevCursor instead of page, which stays fast on large tables and never skips or repeats rows while data changes. Send the ETag of a previous response as If-None-Match to get 304 Not Modified while the page is unchanged.
 // @Tags         Users
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
 // @Param        page    query    int     false  "Page number" default(1)
 // @Param        limit   query    int     false  "Page limit"  default(10)
-// @Param        search  query    string  false  "Search term"
+// @Param        search  query    string  false  "Full-text search term; results are ranked by relevance without sortBy and carry highlights"
 // @Param        scope   query    string  false  "Search scope (all, name, email, id)"
 // @Param        role    query    string  false  "Filter by role"
-// @Param        sortBy  query    string  false  "Sort format field:order (e.g. name:asc)"
+// @Param        group   query    int     false  "Filter by group ID"
+// @Param        status  query    string  false  "Filter by account status (pending, active, suspended, banned)"
+// @Param        filter      query    string  false  "Filter expression: comma-separated field:op:value or field>=value clauses (e.g. createdAt>=2024-01-01,isEmailVerified:eq:true,role:in:admin|support)"
+// @Param        sortBy      query    string  false  "Comma-separated field:order keys (e.g. role:asc,name:desc)"
+// @Param        pagination  query    string  false  "Pagination mode (offset, cursor); cursor mode is implied by after/before" default(offset)
+// @Param        after       query    string  false  "Cursor: return the users after it (nextCursor of the previous page)"
+// @Param        before      query    string  false  "Cursor: return the users before it (prevCursor of the previous page)"
+// @Param        total       query    bool    false  "Include the total count (defaults to true in offset mode, false in cursor mode)"
+// @Param        fields      query    string  false  "Comma-separated fields to return (e.g. id,name,email)"
+// @Param        include     query    string  false  "Comma-separated related resources to embed (groups, sessions)"
+// @Param        If-None-Match  header  string  false  "ETag of a cached copy; answers 304 if it is still current"
 // @Success      200     {object} map[string]interface{}
+// @Header       200     {string} ETag  "Weak tag of the page content"
+// @Success      304
+// @Failure      400     {object} utils.Response
 // @Failure      403     {object} utils.Response
 // @Router       /users [get]
-func (h *UserHandler) GetUsers(ctx *fasthttp.RequestCtx) {
+func (h *UserHandler) GetUsers(c *routing.Context) error {
+	ctx := c.RequestCtx
+
 	// Parsing Query Parameters
 	page, _ := strconv.Atoi(string(ctx.QueryArgs().Peek("page")))
 	limit, _ := strconv.Atoi(string(ctx.QueryArgs().Peek("limit")))
-	search := string(ctx.QueryArgs().Peek("search"))
-	scope := string(ctx.QueryArgs().Peek("scope"))
-	role := string(ctx.QueryArgs().Peek("role"))
-	sortBy := string(ctx.QueryArgs().Peek("sortBy"))
 
 	// Defaults
 	if page < 1 {
@@ -88,29 +110,232 @@ func (h *UserHandler) GetUsers(ctx *fasthttp.RequestCtx) {
 		limit = 10
 	}
 
-	users, total, err := h.userService.GetUsers(page, limit, search, scope, role, sortBy)
+	filter, err := h.userFilterFromQuery(c)
 	if err != nil {
-		utils.WriteError(ctx, fasthttp.StatusInternalServerError, err.Error())
-		return
+		utils.WriteError(ctx, errorStatus(err, fasthttp.StatusBadRequest), err.Error())
+		return nil
 	}
+	filter.Page = page
+	filter.Limit = limit
 
-	utils.WriteSuccess(ctx, fasthttp.StatusOK, map[string]interface{}{
+	view, err := service.ParseUserView(string(ctx.QueryArgs().Peek("fields")), string(ctx.QueryArgs().Peek("include")))
+	if err != nil {
+		utils.WriteError(ctx, fasthttp.StatusBadRequest, err.Error())
+		return nil
+	}
+
+	// Cursor mode: keyset pagination with opaque after/before cursors
+	after := string(ctx.QueryArgs().Peek("after"))
+	before := string(ctx.QueryArgs().Peek("before"))
+	cursorMode := after != "" || before != "" || string(ctx.QueryArgs().Peek("pagination")) == "cursor"
+	if after != "" && before != "" {
+		utils.WriteError(ctx, fasthttp.StatusBadRequest, "Use either after or before, not both")
+		return nil
+	}
+	if after != "" {
+		filter.After, err = h.userService.DecodeCursor(after)
+	} else if before != "" {
+		filter.Before, err = h.userService.DecodeCursor(before)
+	}
+	if err != nil {
+		utils.WriteError(ctx, fasthttp.StatusBadRequest, "Invalid cursor")
+		return nil
+	}
+
+	// The total costs a COUNT query; it is opt-in for cursor mode and opt-out for offset mode
+	filter.SkipCount = cursorMode
+	if raw := string(ctx.QueryArgs().Peek("total")); raw != "" {
+		withTotal, err := strconv.ParseBool(raw)
+		if err != nil {
+			utils.WriteError(ctx, fasthttp.StatusBadRequest, "Invalid total flag")
+			return nil
+		}
+		filter.SkipCount = !withTotal
+	}
+
+	if cursorMode {
+		result, err := h.userService.GetUsersByCursor(currentUser(c), filter, view)
+		if err != nil {
+			utils.WriteError(ctx, errorStatus(err, fasthttp.StatusInternalServerError), err.Error())
+			return nil
+		}
+
+		response := map[string]interface{}{
+			"results":    result.Results,
+			"limit":      limit,
+			"nextCursor": nil,
+			"prevCursor": nil,
+		}
+		if result.NextCursor != "" {
+			response["nextCursor"] = result.NextCursor
+		}
+		if result.PrevCursor != "" {
+			response["prevCursor"] = result.PrevCursor
+		}
+		if !filter.SkipCount {
+			response["total"] = result.Total
+		}
+		utils.WriteSuccess(ctx, fasthttp.StatusOK, response)
+		return nil
+	}
+
+	users, total, err := h.userService.GetUsers(currentUser(c), filter, view)
+	if err != nil {
+		utils.WriteError(ctx, errorStatus(err, fasthttp.StatusInternalServerError), err.Error())
+		return nil
+	}
+
+	response := map[string]interface{}{
 		"results": users,
 		"page":    page,
 		"limit":   limit,
-		"total":   total,
+	}
+	if !filter.SkipCount {
+		response["total"] = total
+	}
+	utils.WriteSuccess(ctx, fasthttp.StatusOK, response)
+	return nil
+}
+
+// userFilterFromQuery reads the search, shortcut filters, filter expression and
+// sortBy of a user list from the query string; pagination is left to the caller
+func (h *UserHandler) userFilterFromQuery(c *routing.Context) (repository.UserFilter, error) {
+	ctx := c.RequestCtx
+	filter := repository.UserFilter{
+		Search: string(ctx.QueryArgs().Peek("search")),
+		Scope:  string(ctx.QueryArgs().Peek("scope")),
+		Role:   string(ctx.QueryArgs().Peek("role")),
+		Status: string(ctx.QueryArgs().Peek("status")),
+	}
+
+	// Filter and sort expressions are checked against the user fields whitelisted for
+	// the actor; filtering or sorting by fields the policy may hide would reveal them
+	fields, err := h.userService.UserFields(currentUser(c))
+	if err != nil {
+		return filter, err
+	}
+	if _, ok := fields["email"]; !ok {
+		switch filter.Scope {
+		case "email":
+			return filter, fmt.Errorf("%w: email cannot be searched", repository.ErrInvalidFilter)
+		case "", "all":
+			filter.Scope = "name"
+		}
+	}
+	if filter.Conditions, err = fields.ParseFilter(string(ctx.QueryArgs().Peek("filter"))); err != nil {
+		return filter, err
+	}
+	if filter.Sort, err = fields.ParseSort(string(ctx.QueryArgs().Peek("sortBy"))); err != nil {
+		return filter, err
+	}
+	if raw := string(ctx.QueryArgs().Peek("group")); raw != "" {
+		groupID, err := strconv.Atoi(raw)
+		if err != nil || groupID < 1 {
+			return filter, errors.New("Invalid group ID")
+		}
+		filter.Group = uint(groupID)
+	}
+	return filter, nil
+}
+
+// ExportUsers godoc
+// @Summary      Export users
+// @Description  Download every user GET /users would list for the same search, filters and sortBy, as CSV, NDJSON or an Excel workbook. Rows are streamed from the database in batches, so large exports start right away. Users and fields hidden by the access policy are left out. Requires the 'users:read' permission.
+// @Tags         Users
+// @Produce      text/csv
+// @Produce      application/x-ndjson
+// @Produce      application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Security     BearerAuth
+// @Param        format  query    string  false  "File format (csv, ndjson, xlsx)" default(csv)
+// @Param        search  query    string  false  "Full-text search term"
+// @Param        scope   query    string  false  "Search scope (all, name, email, id)"
+// @Param        role    query    string  false  "Filter by role"
+// @Param        group   query    int     false  "Filter by group ID"
+// @Param        status  query    string  false  "Filter by account status (pending, active, suspended, banned)"
+// @Param        filter  query    string  false  "Filter expression, as for GET /users"
+// @Param        sortBy  query    string  false  "Comma-separated field:order keys (e.g. role:asc,name:desc)"
+// @Param        fields  query    string  false  "Comma-separated fields to export as columns (e.g. id,name,email)"
+// @Success      200     {file}   file
+// @Failure      400     {object} utils.Response
+// @Failure      403     {object} utils.Response
+// @Router       /users/export [get]
+func (h *UserHandler) ExportUsers(c *routing.Context) error {
+	ctx := c.RequestCtx
+
+	format := string(ctx.QueryArgs().Peek("format"))
+	if format == "" {
+		format = tabular.FormatCSV
+	}
+	contentType, err := tabular.ContentType(format)
+	if err != nil {
+		utils.WriteError(ctx, fasthttp.StatusBadRequest, err.Error())
+		return nil
+	}
+
+	filter, err := h.userFilterFromQuery(c)
+	if err != nil {
+		utils.WriteError(ctx, errorStatus(err, fasthttp.StatusBadRequest), err.Error())
+		return nil
+	}
+	view, err := service.ParseUserView(string(ctx.QueryArgs().Peek("fields")), string(ctx.QueryArgs().Peek("include")))
+	if err != nil {
+		utils.WriteError(ctx, fasthttp.StatusBadRequest, err.Error())
+		return nil
+	}
+
+	export, err := h.userService.ExportUsers(currentUser(c), filter, view)
+	if err != nil {
+		utils.WriteError(ctx, errorStatus(err, fasthttp.StatusInternalServerError), err.Error())
+		return nil
+	}
+
+	ctx.SetContentType(contentType)
+	ctx.Response.Header.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="users-%s.%s"`, time.Now().Format("20060102-150405"), format))
+
+	// The body is written after the handler returns, flushing every batch to the client.
+	// Once streaming started the status cannot change, so failures cut the file short.
+	ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+		writer, err := tabular.NewWriter(format, w, export.Columns())
+		if err == nil {
+			err = export.Each(func(batch []map[string]interface{}) error {
+				for _, row := range batch {
+					if err := writer.WriteRow(row); err != nil {
+						return err
+					}
+				}
+				if err := writer.Flush(); err != nil {
+					return err
+				}
+				return w.Flush()
+			})
+		}
+		if err == nil {
+			err = writer.Close()
+		}
+		if err != nil {
+			log.Printf("⚠️ User export failed: %v", err)
+		}
 	})
+	return nil
 }
 
 // GetUser godoc
 // @Summary      Get a user by ID
-// @Description  Get details of a specific user. Requires 'admin' role.
+// @Description  Get details of a specific user. Users may read their own record; other records require the 'users:read' permission. Embedding groups or sessions of other users requires 'groups:read' or 'security-events:read'. The ETag header carries the user's version, to send back as If-Match when updating or deleting it, or as If-None-Match to get 304 Not Modified while the user is unchanged. Responses limited by fields or by the access policy add a suffix to the version, as they are other representations of it. Responses that embed groups or sessions carry a weak ETag of their content instead.
 // @Tags         Users
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
-// @Param        userId path      int  true  "User ID"
+// @Param        userId  path      int     true   "User ID"
+// @Param        fields  query     string  false  "Comma-separated fields to return (e.g. id,name,email)"
+// @Param        include query     string  false  "Comma-separated related resources to embed (groups, sessions)"
+// @Param        If-None-Match      header  string  false  "ETag of a cached copy; answers 304 if it is still current"
+// @Param        If-Modified-Since  header  string  false  "Last-Modified of a cached copy; ignored when If-None-Match is sent"
 // @Success      200    {object}  model.UserResponse
+// @Header       200    {string}  ETag           "Version of the user, with a suffix for limited representations"
+// @Header       200    {string}  Last-Modified  "When the user was last changed"
+// @Success      304
+// @Failure      400    {object}  utils.Response
 // @Failure      404    {object}  utils.Response
 // @Failure      403    {object}  utils.Response
 // @Router       /users/{userId} [get]
@@ -121,27 +346,49 @@ func (h *UserHandler) GetUser(c *routing.Context) error {
 		return nil
 	}
 
-	user, err := h.userService.GetUserByID(uint(id))
+	view, err := service.ParseUserView(string(c.QueryArgs().Peek("fields")), string(c.QueryArgs().Peek("include")))
 	if err != nil {
-		utils.WriteError(c.RequestCtx, fasthttp.StatusNotFound, err.Error())
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, err.Error())
 		return nil
 	}
 
-	utils.WriteSuccess(c.RequestCtx, fasthttp.StatusOK, user.ToResponse())
+	response, user, etag, err := h.userService.ViewUser(currentUser(c), uint(id), view)
+	if err != nil {
+		utils.WriteError(c.RequestCtx, errorStatus(err, fasthttp.StatusInternalServerError), err.Error())
+		return nil
+	}
+
+	// Embedded groups and sessions change without the user, so such responses are
+	// left to the weak ETag of their content
+	if len(view.Include) == 0 {
+		c.Response.Header.Set(fasthttp.HeaderETag, etag)
+		setLastModified(c.RequestCtx, user.UpdatedAt)
+	}
+	utils.WriteSuccess(c.RequestCtx, fasthttp.StatusOK, response)
 	return nil
 }
 
 // UpdateUser godoc
 // @Summary      Update a user
-// @Description  Update user details. Requires 'admin' role.
+// @Description  Update user details with a JSON Merge Patch (application/merge-patch+json, or plain application/json) or a JSON Patch (application/json-patch+json). Only name, email and password can be changed; unknown and read-only fields, nulls and values of the wrong type are rejected with field-level errors, and the result must pass the same checks as a new user. The password is write-only, so a JSON Patch sets it with add. A new email is held as pendingEmail until confirmed from that address. Users may update their own record; other records require the 'users:write' permission. Changing email or password requires a recent login. If-Match must carry the ETag of the user as last read; the update is refused with 412 if the user changed since.
 // @Tags         Users
 // @Accept       json
+// @Accept       application/merge-patch+json
+// @Accept       application/json-patch+json
 // @Produce      json
 // @Security     BearerAuth
-// @Param        userId path      int  true  "User ID"
-// @Param        user   body      map[string]interface{} true "Update Data"
+// @Param        userId   path      int     true  "User ID"
+// @Param        If-Match header    string  true  "ETag of the user as last read, or * to overwrite any version"
+// @Param        user     body      UpdateUserRequest true "Merge patch, or an array of JSON Patch operations"
 // @Success      200    {object}  model.UserResponse
+// @Header       200    {string}  ETag  "New version of the user"
+// @Failure      400    {object}  utils.Response
+// @Failure      401    {object}  utils.Response
 // @Failure      403    {object}  utils.Response
+// @Failure      409    {object}  utils.Response
+// @Failure      412    {object}  utils.Response
+// @Failure      415    {object}  utils.Response
+// @Failure      428    {object}  utils.Response
 // @Router       /users/{userId} [patch]
 func (h *UserHandler) UpdateUser(c *routing.Context) error {
 	id, err := strconv.Atoi(c.Param("userId"))
@@ -150,32 +397,129 @@ func (h *UserHandler) UpdateUser(c *routing.Context) error {
 		return nil
 	}
 
-	var updateBody map[string]interface{}
-	if err := json.Unmarshal(c.PostBody(), &updateBody); err != nil {
-		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid request body")
+	format, ok := patchFormat(c.RequestCtx)
+	if !ok {
+		return nil
+	}
+	version, ok := ifMatchVersion(c.RequestCtx)
+	if !ok {
 		return nil
 	}
 
-	user, err := h.userService.UpdateUser(uint(id), updateBody)
+	user, err := h.userService.UpdateUser(currentUser(c), uint(id), version, format, c.PostBody(), clientInfo(c.RequestCtx))
+	if errors.Is(err, service.ErrReauthenticationRequired) {
+		utils.WriteErrorCode(c.RequestCtx, fasthttp.StatusUnauthorized, middleware.ErrCodeReauthenticationRequired,
+			"This operation requires a recent login. Please confirm your password.")
+		return nil
+	}
+	var patchError *service.PatchError
+	if errors.As(err, &patchError) {
+		utils.WriteJSON(c.RequestCtx, fasthttp.StatusBadRequest, map[string]interface{}{
+			"status": "error",
+			"errors": patchError.Fields,
+		})
+		return nil
+	}
 	if err != nil {
-		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, err.Error())
+		utils.WriteError(c.RequestCtx, errorStatus(err, fasthttp.StatusBadRequest), err.Error())
 		return nil
 	}
 
+	setETag(c.RequestCtx, user.Version)
 	utils.WriteSuccess(c.RequestCtx, fasthttp.StatusOK, user.ToResponse())
 	return nil
 }
 
+// BulkUsers godoc
+// @Summary      Bulk update users
+// @Description  Run operations over many users of the current organization at once: delete, update (the fields of PATCH /users/{id}), role (set a role) and status (e.g. suspend). Every item is checked like the single-user endpoint would check it and needs the same permission. In atomic mode (default) nothing is stored unless every item passes, and then all changes are stored in one transaction; in bestEffort mode each item is stored on its own. The response reports every item. Requires a recent login.
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body   body      BulkUsersRequest true "Operations"
+// @Success      200    {object}  service.BulkResult
+// @Failure      400    {object}  utils.Response
+// @Failure      403    {object}  utils.Response
+// @Router       /users/bulk [post]
+func (h *UserHandler) BulkUsers(c *routing.Context) error {
+	var req BulkUsersRequest
+	if err := json.Unmarshal(c.PostBody(), &req); err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid request body")
+		return nil
+	}
+
+	if validationErrors := utils.ValidateStruct(&req); validationErrors != nil {
+		utils.WriteJSON(c.RequestCtx, fasthttp.StatusBadRequest, map[string]interface{}{
+			"status": "error",
+			"errors": validationErrors,
+		})
+		return nil
+	}
+
+	result, err := h.userService.ApplyBulk(currentUser(c), req.Mode, req.Operations, clientInfo(c.RequestCtx))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, errorStatus(err, fasthttp.StatusInternalServerError), err.Error())
+		return nil
+	}
+
+	utils.WriteSuccess(c.RequestCtx, fasthttp.StatusOK, result)
+	return nil
+}
+
+// ConfirmEmailChange godoc
+// @Summary      Confirm an email change
+// @Description  Confirm the new email address using the token sent to it. The account email is replaced and marked as verified.
+// @Tags         Users
+// @Produce      json
+// @Param        token query string true "Email Change Token"
+// @Success      200  {object}  model.UserResponse
+// @Failure      400  {object}  utils.Response
+// @Router       /auth/confirm-email-change [post]
+func (h *UserHandler) ConfirmEmailChange(ctx *fasthttp.RequestCtx) {
+	token := string(ctx.QueryArgs().Peek("token"))
+
+	user, err := h.userService.ConfirmEmailChange(token, clientInfo(ctx))
+	if err != nil {
+		utils.WriteError(ctx, fasthttp.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(ctx, fasthttp.StatusOK, user.ToResponse())
+}
+
+// CancelEmailChange godoc
+// @Summary      Cancel an email change
+// @Description  Cancel a pending email change using the token sent to the current address.
+// @Tags         Users
+// @Produce      json
+// @Param        token query string true "Email Change Cancel Token"
+// @Success      204
+// @Failure      400  {object}  utils.Response
+// @Router       /auth/cancel-email-change [post]
+func (h *UserHandler) CancelEmailChange(ctx *fasthttp.RequestCtx) {
+	token := string(ctx.QueryArgs().Peek("token"))
+
+	if err := h.userService.CancelEmailChange(token, clientInfo(ctx)); err != nil {
+		utils.WriteError(ctx, fasthttp.StatusBadRequest, err.Error())
+		return
+	}
+	ctx.SetStatusCode(fasthttp.StatusNoContent)
+}
+
 // DeleteUser godoc
 // @Summary      Delete a user
-// @Description  Soft delete a user by ID. Requires 'admin' role.
+// @Description  Soft delete a user by ID. Deleted users can be restored or purged via /users/deleted. Requires 'users:delete' permission. If-Match must carry the ETag of the user as last read; the delete is refused with 412 if the user changed since.
 // @Tags         Users
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
-// @Param        userId path      int  true  "User ID"
+// @Param        userId   path      int     true  "User ID"
+// @Param        If-Match header    string  true  "ETag of the user as last read, or * to delete any version"
 // @Success      204
 // @Failure      403    {object}  utils.Response
+// @Failure      412    {object}  utils.Response
+// @Failure      428    {object}  utils.Response
 // @Router       /users/{userId} [delete]
 func (h *UserHandler) DeleteUser(c *routing.Context) error {
 	id, err := strconv.Atoi(c.Param("userId"))
@@ -184,11 +528,229 @@ func (h *UserHandler) DeleteUser(c *routing.Context) error {
 		return nil
 	}
 
-	if err := h.userService.DeleteUser(uint(id)); err != nil {
-		utils.WriteError(c.RequestCtx, fasthttp.StatusNotFound, err.Error())
+	version, ok := ifMatchVersion(c.RequestCtx)
+	if !ok {
+		return nil
+	}
+
+	if err := h.userService.DeleteUser(currentUser(c), uint(id), version); err != nil {
+		utils.WriteError(c.RequestCtx, errorStatus(err, fasthttp.StatusInternalServerError), err.Error())
+		return nil
+	}
+
+	c.SetStatusCode(fasthttp.StatusNoContent)
+	return nil
+}
+
+// GetDeletedUsers godoc
+// @Summary      List deleted users
+// @Description  Get a paginated list of soft-deleted users, most recently deleted first. They can be restored or purged until the retention period ends. Requires 'users:delete' permission.
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        page    query    int  false  "Page number" default(1)
+// @Param        limit   query    int  false  "Page limit"  default(10)
+// @Success      200     {object} map[string]interface{}
+// @Failure      403     {object} utils.Response
+// @Router       /users/deleted [get]
+func (h *UserHandler) GetDeletedUsers(c *routing.Context) error {
+	ctx := c.RequestCtx
+	page, _ := strconv.Atoi(string(ctx.QueryArgs().Peek("page")))
+	limit, _ := strconv.Atoi(string(ctx.QueryArgs().Peek("limit")))
+
+	// Defaults
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+
+	users, total, err := h.userService.GetDeletedUsers(currentUser(c), page, limit)
+	if err != nil {
+		utils.WriteError(ctx, fasthttp.StatusInternalServerError, err.Error())
+		return nil
+	}
+
+	utils.WriteSuccess(ctx, fasthttp.StatusOK, map[string]interface{}{
+		"results": users,
+		"page":    page,
+		"limit":   limit,
+		"total":   total,
+	})
+	return nil
+}
+
+// RestoreUser godoc
+// @Summary      Restore a deleted user
+// @Description  Undo the soft delete of a user. Sessions from before the deletion stay revoked. Requires 'users:delete' permission.
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        userId path      int  true  "User ID"
+// @Success      200    {object}  model.UserResponse
+// @Failure      403    {object}  utils.Response
+// @Failure      404    {object}  utils.Response
+// @Router       /users/deleted/{userId}/restore [post]
+func (h *UserHandler) RestoreUser(c *routing.Context) error {
+	id, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid user ID")
+		return nil
+	}
+
+	user, err := h.userService.RestoreUser(currentUser(c), uint(id))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, errorStatus(err, fasthttp.StatusInternalServerError), err.Error())
+		return nil
+	}
+
+	utils.WriteSuccess(c.RequestCtx, fasthttp.StatusOK, user.ToResponse())
+	return nil
+}
+
+// PurgeUser godoc
+// @Summary      Permanently delete a user
+// @Description  Hard delete a soft-deleted user together with their tokens and memberships. The email can be registered again afterwards. This cannot be undone. Requires 'users:delete' permission and a recent login.
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        userId path  int  true  "User ID"
+// @Success      204
+// @Failure      403    {object}  utils.Response
+// @Failure      404    {object}  utils.Response
+// @Router       /users/deleted/{userId} [delete]
+func (h *UserHandler) PurgeUser(c *routing.Context) error {
+	id, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid user ID")
+		return nil
+	}
+
+	if err := h.userService.PurgeDeletedUser(currentUser(c), uint(id)); err != nil {
+		utils.WriteError(c.RequestCtx, errorStatus(err, fasthttp.StatusInternalServerError), err.Error())
 		return nil
 	}
 
 	c.SetStatusCode(fasthttp.StatusNoContent)
 	return nil
-}
\ No newline at end of file
+}
+
+// ChangeRole godoc
+// @Summary      Change a user's role
+// @Description  Assign a role to a user. The last remaining admin cannot be demoted. The user's existing sessions are revoked so the new role takes effect immediately. Requires the 'roles:assign' permission and a recent login.
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        userId path      int  true  "User ID"
+// @Param        body   body      ChangeRoleRequest true "New Role"
+// @Success      200    {object}  model.UserResponse
+// @Failure      400    {object}  utils.Response
+// @Failure      403    {object}  utils.Response
+// @Failure      404    {object}  utils.Response
+// @Failure      409    {object}  utils.Response
+// @Router       /users/{userId}/role [put]
+func (h *UserHandler) ChangeRole(c *routing.Context) error {
+	id, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid user ID")
+		return nil
+	}
+
+	var req ChangeRoleRequest
+	if err := json.Unmarshal(c.PostBody(), &req); err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid request body")
+		return nil
+	}
+
+	if validationErrors := utils.ValidateStruct(&req); validationErrors != nil {
+		utils.WriteJSON(c.RequestCtx, fasthttp.StatusBadRequest, map[string]interface{}{
+			"status": "error",
+			"errors": validationErrors,
+		})
+		return nil
+	}
+
+	user, err := h.userService.ChangeRole(currentUser(c), uint(id), req.Role, clientInfo(c.RequestCtx))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, errorStatus(err, fasthttp.StatusBadRequest), err.Error())
+		return nil
+	}
+
+	setETag(c.RequestCtx, user.Version)
+	utils.WriteSuccess(c.RequestCtx, fasthttp.StatusOK, user.ToResponse())
+	return nil
+}
+
+// ChangeStatus godoc
+// @Summary      Change a user's account status
+// @Description  Activate, suspend or ban a user. Allowed transitions: pending -> active, active <-> suspended, and any status -> banned. Banned is final. A suspension may expire automatically via 'suspendedUntil'. Suspending or banning signs the user out everywhere, and inactive users are rejected on every request. Requires the 'users:write' permission and a recent login.
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        userId path      int  true  "User ID"
+// @Param        body   body      ChangeStatusRequest true "New Status"
+// @Success      200    {object}  model.UserResponse
+// @Failure      400    {object}  utils.Response
+// @Failure      403    {object}  utils.Response
+// @Failure      404    {object}  utils.Response
+// @Router       /users/{userId}/status [put]
+func (h *UserHandler) ChangeStatus(c *routing.Context) error {
+	id, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid user ID")
+		return nil
+	}
+
+	var req ChangeStatusRequest
+	if err := json.Unmarshal(c.PostBody(), &req); err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid request body")
+		return nil
+	}
+
+	if validationErrors := utils.ValidateStruct(&req); validationErrors != nil {
+		utils.WriteJSON(c.RequestCtx, fasthttp.StatusBadRequest, map[string]interface{}{
+			"status": "error",
+			"errors": validationErrors,
+		})
+		return nil
+	}
+
+	user, err := h.userService.ChangeStatus(currentUser(c), uint(id), req.Status, req.Reason, req.SuspendedUntil, clientInfo(c.RequestCtx))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, errorStatus(err, fasthttp.StatusBadRequest), err.Error())
+		return nil
+	}
+
+	setETag(c.RequestCtx, user.Version)
+	utils.WriteSuccess(c.RequestCtx, fasthttp.StatusOK, user.ToResponse())
+	return nil
+}
+
+// --- Request Structs for Swagger & Validation ---
+
+type UpdateUserRequest struct {
+	Name     string `json:"name,omitempty" example:"Jane Doe"`
+	Email    string `json:"email,omitempty" example:"jane@example.com"`
+	Password string `json:"password,omitempty" example:"password123"`
+}
+
+type ChangeRoleRequest struct {
+	Role string `json:"role" validate:"required"`
+}
+
+type ChangeStatusRequest struct {
+	Status         string     `json:"status" validate:"required,oneof=active suspended banned" example:"suspended"`
+	Reason         string     `json:"reason" validate:"max=500"`
+	SuspendedUntil *time.Time `json:"suspendedUntil" example:"2030-01-01T00:00:00Z"`
+}
+
+type BulkUsersRequest struct {
+	Mode       string                  `json:"mode" validate:"omitempty,oneof=atomic bestEffort" example:"atomic"`
+	Operations []service.BulkOperation `json:"operations" validate:"required,min=1,dive"`
+}
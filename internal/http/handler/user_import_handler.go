@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/model"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/service"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/pkg/utils"
+	routing "github.com/qiangxue/fasthttp-routing"
+	"github.com/valyala/fasthttp"
+)
+
+type UserImportHandler struct {
+	userImportService *service.UserImportService
+}
+
+func NewUserImportHandler(userImportService *service.UserImportService) *UserImportHandler {
+	return &UserImportHandler{userImportService: userImportService}
+}
+
+// ImportUsers godoc
+// @Summary      Import users
+// @Description  Create many users of the current organization from a CSV file (header row with name, email and optionally password and role) or an NDJSON file (one JSON object with the same fields per line). Send the file as the raw body or as the "file" field of a multipart form. Every row is validated and checked for taken or duplicate emails; rows with a password are created, rows without one are invited. Setting a role requires the 'roles:assign' permission. With dryRun=true nothing is stored and the report shows which rows would fail. Small files are imported right away (201); larger ones run in the background (202) and are followed via /users/import/{jobId}. Requires 'users:write' permission.
+// @Tags         Users
+// @Accept       text/csv
+// @Accept       application/x-ndjson
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     BearerAuth
+// @Param        format  query     string  false  "File format (csv, ndjson); detected from the Content-Type or file name when omitted"
+// @Param        dryRun  query     bool    false  "Only validate the rows"
+// @Param        file    formData  file    false  "Import file (multipart uploads)"
+// @Success      200     {object}  model.ImportJobResponse
+// @Success      201     {object}  model.ImportJobResponse
+// @Success      202     {object}  model.ImportJobResponse
+// @Failure      400     {object}  utils.Response
+// @Failure      403     {object}  utils.Response
+// @Router       /users/import [post]
+func (h *UserImportHandler) ImportUsers(c *routing.Context) error {
+	ctx := c.RequestCtx
+
+	data := ctx.PostBody()
+	name := ""
+	if bytes.HasPrefix(ctx.Request.Header.ContentType(), []byte("multipart/form-data")) {
+		header, err := ctx.FormFile("file")
+		if err != nil {
+			utils.WriteError(ctx, fasthttp.StatusBadRequest, "Missing import file")
+			return nil
+		}
+		file, err := header.Open()
+		if err != nil {
+			utils.WriteError(ctx, fasthttp.StatusBadRequest, "Invalid import file")
+			return nil
+		}
+		defer file.Close()
+		if data, err = io.ReadAll(file); err != nil {
+			utils.WriteError(ctx, fasthttp.StatusBadRequest, "Invalid import file")
+			return nil
+		}
+		name = header.Filename
+	}
+
+	format := importFormat(string(ctx.QueryArgs().Peek("format")), string(ctx.Request.Header.ContentType()), name)
+	dryRun := ctx.QueryArgs().GetBool("dryRun")
+
+	job, err := h.userImportService.Import(currentUser(c), format, data, dryRun)
+	if err != nil {
+		utils.WriteError(ctx, errorStatus(err, fasthttp.StatusInternalServerError), err.Error())
+		return nil
+	}
+
+	status := fasthttp.StatusCreated
+	switch {
+	case dryRun:
+		status = fasthttp.StatusOK
+	case job.Status != model.ImportJobStatusCompleted:
+		status = fasthttp.StatusAccepted
+	}
+	utils.WriteSuccess(ctx, status, job.ToResponse())
+	return nil
+}
+
+// GetImportJob godoc
+// @Summary      Get an import job
+// @Description  Get the progress and per-row report of a user import of the current organization. Requires 'users:write' permission.
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        jobId   path      int  true  "Import Job ID"
+// @Success      200     {object}  model.ImportJobResponse
+// @Failure      403     {object}  utils.Response
+// @Failure      404     {object}  utils.Response
+// @Router       /users/import/{jobId} [get]
+func (h *UserImportHandler) GetImportJob(c *routing.Context) error {
+	id, err := strconv.Atoi(c.Param("jobId"))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, fasthttp.StatusBadRequest, "Invalid import job ID")
+		return nil
+	}
+
+	job, err := h.userImportService.GetJob(currentUser(c), uint(id))
+	if err != nil {
+		utils.WriteError(c.RequestCtx, errorStatus(err, fasthttp.StatusInternalServerError), err.Error())
+		return nil
+	}
+
+	utils.WriteSuccess(c.RequestCtx, fasthttp.StatusOK, job.ToResponse())
+	return nil
+}
+
+// importFormat picks the file format from the format parameter, then the
+// Content-Type and finally the extension of an uploaded file
+func importFormat(format, contentType, filename string) string {
+	if format != "" {
+		return strings.ToLower(format)
+	}
+	switch {
+	case strings.HasPrefix(contentType, "text/csv"):
+		return model.ImportFormatCSV
+	case strings.HasPrefix(contentType, "application/x-ndjson"), strings.HasPrefix(contentType, "application/jsonl"):
+		return model.ImportFormatNDJSON
+	}
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return model.ImportFormatCSV
+	case ".ndjson", ".jsonl":
+		return model.ImportFormatNDJSON
+	}
+	return ""
+}
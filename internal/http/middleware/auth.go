@@ -1,7 +1,10 @@
 package middleware
 
 import (
+	"encoding/json"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/model"
 	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/service"
@@ -10,8 +13,9 @@ import (
 	"github.com/valyala/fasthttp"
 )
 
-// AuthMiddleware handles JWT verification and Role-Based Access Control (RBAC)
-func AuthMiddleware(tokenService *service.TokenService, requiredRoles ...string) routing.Handler {
+// AuthMiddleware handles JWT verification and Role-Based Access Control (RBAC).
+// The user is loaded on every request so revoked tokens and role changes apply immediately.
+func AuthMiddleware(tokenService *service.TokenService, userService *service.UserService, requiredRoles ...string) routing.Handler {
 	return func(c *routing.Context) error {
 		// 1. Check Authorization Header
 		authHeader := string(c.Request.Header.Peek("Authorization"))
@@ -32,11 +36,38 @@ func AuthMiddleware(tokenService *service.TokenService, requiredRoles ...string)
 			return nil
 		}
 
-		// 3. RBAC Check (If roles are specified)
+		// 3. Load the user and reject tokens issued before their sessions were revoked
+		user, err := userService.GetUserByID(claims.UserID)
+		if err != nil || claims.Version != user.TokenVersion {
+			utils.WriteError(c.RequestCtx, fasthttp.StatusUnauthorized, "Invalid or expired token")
+			c.Abort()
+			return nil
+		}
+
+		// 4. Reject suspended, banned and pending accounts
+		if err := userService.CheckStatus(user); err != nil {
+			utils.WriteErrorCode(c.RequestCtx, fasthttp.StatusForbidden, ErrCodeAccountInactive, err.Error())
+			c.Abort()
+			return nil
+		}
+
+		// 5. Resolve the organization the token acts in; removed members lose access immediately
+		if claims.OrgID != 0 {
+			membership, err := userService.GetMembership(claims.OrgID, user.ID)
+			if err != nil || membership == nil {
+				utils.WriteError(c.RequestCtx, fasthttp.StatusUnauthorized, "Invalid or expired token")
+				c.Abort()
+				return nil
+			}
+			user.TenantID = membership.OrganizationID
+			user.TenantRole = membership.Role
+		}
+
+		// 6. RBAC Check (If roles are specified)
 		if len(requiredRoles) > 0 {
 			hasRole := false
 			for _, role := range requiredRoles {
-				if claims.Role == role {
+				if user.Role == role {
 					hasRole = true
 					break
 				}
@@ -48,10 +79,191 @@ func AuthMiddleware(tokenService *service.TokenService, requiredRoles ...string)
 			}
 		}
 
-		// 4. Store user info in context
-		c.Set("userID", claims.UserID)
-		c.Set("userRole", claims.Role)
+		user.Scopes = strings.Fields(claims.Scope)
+		if claims.AuthTime != nil {
+			user.AuthTime = &claims.AuthTime.Time
+		}
+
+		// 7. Store user info in context
+		c.Set("userID", user.ID)
+		c.Set("userRole", user.Role)
+		c.Set("claims", claims)
+		c.Set("user", user)
 
 		return c.Next()
 	}
-}
\ No newline at end of file
+}
+
+// ErrCodeAccountInactive tells clients the account is suspended, banned or not yet activated
+const ErrCodeAccountInactive = "account_inactive"
+
+// ErrCodeInsufficientScope tells clients the access token was not issued for this operation
+const ErrCodeInsufficientScope = "insufficient_scope"
+
+// RequireScopes rejects requests whose access token lacks any of the given scopes.
+// It must run after AuthMiddleware.
+func RequireScopes(scopes ...string) routing.Handler {
+	return func(c *routing.Context) error {
+		claims, _ := c.Get("claims").(*service.Claims)
+		if claims == nil || !claims.HasScopes(scopes...) {
+			utils.WriteErrorCode(c.RequestCtx, fasthttp.StatusForbidden, ErrCodeInsufficientScope,
+				"Forbidden: Token scope does not allow this operation")
+			c.Abort()
+			return nil
+		}
+		return c.Next()
+	}
+}
+
+// RequirePermission rejects requests whose role and groups do not grant all of the given permissions.
+// Permissions double as scopes, so the access token must carry them as well.
+// It must run after AuthMiddleware.
+func RequirePermission(roleService *service.RoleService, permissions ...string) routing.Handler {
+	return func(c *routing.Context) error {
+		claims, _ := c.Get("claims").(*service.Claims)
+		if claims == nil || !claims.HasScopes(permissions...) {
+			utils.WriteErrorCode(c.RequestCtx, fasthttp.StatusForbidden, ErrCodeInsufficientScope,
+				"Forbidden: Token scope does not allow this operation")
+			c.Abort()
+			return nil
+		}
+
+		user, _ := c.Get("user").(*model.User)
+
+		allowed, err := roleService.UserHasPermissions(user, permissions...)
+		if err != nil {
+			utils.WriteError(c.RequestCtx, fasthttp.StatusInternalServerError, err.Error())
+			c.Abort()
+			return nil
+		}
+		if !allowed {
+			utils.WriteError(c.RequestCtx, fasthttp.StatusForbidden, "Forbidden: Insufficient permissions")
+			c.Abort()
+			return nil
+		}
+
+		return c.Next()
+	}
+}
+
+// Policy decides whether the authenticated caller may access the requested resource
+type Policy func(c *routing.Context, claims *service.Claims) bool
+
+// Authorize rejects requests the policy does not allow. It must run after AuthMiddleware.
+func Authorize(policy Policy) routing.Handler {
+	return func(c *routing.Context) error {
+		claims, _ := c.Get("claims").(*service.Claims)
+		if claims == nil || !policy(c, claims) {
+			utils.WriteError(c.RequestCtx, fasthttp.StatusForbidden, "Forbidden: Insufficient permissions")
+			c.Abort()
+			return nil
+		}
+		return c.Next()
+	}
+}
+
+// IsSelf reports whether the "userId" path parameter refers to the caller
+func IsSelf(c *routing.Context) bool {
+	userID, _ := c.Get("userID").(uint)
+	id, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	return err == nil && userID != 0 && uint(id) == userID
+}
+
+// SelfOrRole allows callers acting on their own user record with the profile scope,
+// or holding one of the roles
+func SelfOrRole(roles ...string) Policy {
+	return func(c *routing.Context, claims *service.Claims) bool {
+		if IsSelf(c) && claims.HasScopes(model.ScopeProfile) {
+			return true
+		}
+		role, _ := c.Get("userRole").(string)
+		for _, r := range roles {
+			if role == r {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// SelfOrPermission allows callers acting on their own user record with the profile scope,
+// or whose role or groups grant the permissions and whose token carries them as scopes
+func SelfOrPermission(roleService *service.RoleService, permissions ...string) Policy {
+	return func(c *routing.Context, claims *service.Claims) bool {
+		if IsSelf(c) && claims.HasScopes(model.ScopeProfile) {
+			return true
+		}
+		if !claims.HasScopes(permissions...) {
+			return false
+		}
+		user, _ := c.Get("user").(*model.User)
+		allowed, err := roleService.UserHasPermissions(user, permissions...)
+		return err == nil && allowed
+	}
+}
+
+// When applies handler only if condition matches the request and skips it otherwise
+func When(condition func(c *routing.Context) bool, handler routing.Handler) routing.Handler {
+	return func(c *routing.Context) error {
+		if !condition(c) {
+			return c.Next()
+		}
+		return handler(c)
+	}
+}
+
+// ErrCodeReauthenticationRequired tells clients to call POST /auth/reauthenticate and retry
+const ErrCodeReauthenticationRequired = "reauthentication_required"
+
+// RequireRecentAuth rejects requests whose access token was not obtained with a password
+// within maxAge. It must run after AuthMiddleware. When conditions are given, the check
+// only applies if at least one of them matches the request.
+func RequireRecentAuth(maxAge time.Duration, conditions ...func(c *routing.Context) bool) routing.Handler {
+	return func(c *routing.Context) error {
+		if len(conditions) > 0 {
+			applies := false
+			for _, condition := range conditions {
+				if condition(c) {
+					applies = true
+					break
+				}
+			}
+			if !applies {
+				return c.Next()
+			}
+		}
+
+		claims, _ := c.Get("claims").(*service.Claims)
+		if claims == nil || claims.AuthTime == nil || time.Since(claims.AuthTime.Time) > maxAge {
+			utils.WriteErrorCode(c.RequestCtx, fasthttp.StatusUnauthorized, ErrCodeReauthenticationRequired,
+				"This operation requires a recent login. Please confirm your password.")
+			c.Abort()
+			return nil
+		}
+
+		return c.Next()
+	}
+}
+
+// BodyHasOperation reports whether a bulk request body has an operation of one of
+// the given kinds, e.g. {"operations": [{"op": "delete", ...}]}
+func BodyHasOperation(ops ...string) func(c *routing.Context) bool {
+	return func(c *routing.Context) bool {
+		var body struct {
+			Operations []struct {
+				Op string `json:"op"`
+			} `json:"operations"`
+		}
+		if err := json.Unmarshal(c.PostBody(), &body); err != nil {
+			return false
+		}
+		for _, operation := range body.Operations {
+			for _, op := range ops {
+				if operation.Op == op {
+					return true
+				}
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+
+	routing "github.com/qiangxue/fasthttp-routing"
+	"github.com/valyala/fasthttp"
+)
+
+// ConditionalGET adds validators and caching headers to successful GET responses and
+// answers 304 Not Modified when the client's copy is still current.
+// Handlers may set ETag and Last-Modified themselves; responses without an ETag get a
+// weak one derived from the body, so any read endpoint can be revalidated.
+// cacheControl is sent as the Cache-Control header, e.g. "private, no-cache".
+func ConditionalGET(cacheControl string) routing.Handler {
+	return func(c *routing.Context) error {
+		if !c.IsGet() {
+			return c.Next()
+		}
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		response := &c.Response
+		if response.StatusCode() != fasthttp.StatusOK || response.IsBodyStream() {
+			return nil
+		}
+		if len(response.Header.Peek(fasthttp.HeaderETag)) == 0 {
+			sum := sha256.Sum256(response.Body())
+			response.Header.Set(fasthttp.HeaderETag, `W/"`+hex.EncodeToString(sum[:16])+`"`)
+		}
+		response.Header.Set(fasthttp.HeaderCacheControl, cacheControl)
+		// Responses depend on who asks, so caches must not share them between tokens
+		response.Header.Add(fasthttp.HeaderVary, fasthttp.HeaderAuthorization)
+
+		if notModified(&c.Request.Header, &response.Header) {
+			response.SetStatusCode(fasthttp.StatusNotModified)
+			response.ResetBody()
+		}
+		return nil
+	}
+}
+
+// notModified evaluates If-None-Match, or If-Modified-Since when there is none,
+// against the validators of the response
+func notModified(request *fasthttp.RequestHeader, response *fasthttp.ResponseHeader) bool {
+	if ifNoneMatch := request.Peek(fasthttp.HeaderIfNoneMatch); len(ifNoneMatch) > 0 {
+		etag := opaqueTag(response.Peek(fasthttp.HeaderETag))
+		for _, tag := range bytes.Split(ifNoneMatch, []byte(",")) {
+			tag = bytes.TrimSpace(tag)
+			if string(tag) == "*" || bytes.Equal(opaqueTag(tag), etag) {
+				return true
+			}
+		}
+		return false
+	}
+
+	ifModifiedSince := request.Peek(fasthttp.HeaderIfModifiedSince)
+	lastModified := response.Peek(fasthttp.HeaderLastModified)
+	if len(ifModifiedSince) == 0 || len(lastModified) == 0 {
+		return false
+	}
+	since, err := fasthttp.ParseHTTPDate(ifModifiedSince)
+	if err != nil {
+		return false
+	}
+	modified, err := fasthttp.ParseHTTPDate(lastModified)
+	if err != nil {
+		return false
+	}
+	return !modified.After(since)
+}
+
+// opaqueTag strips the weakness indicator, as If-None-Match compares tags weakly
+func opaqueTag(tag []byte) []byte {
+	return bytes.TrimPrefix(tag, []byte("W/"))
+}
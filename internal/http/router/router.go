@@ -1,8 +1,10 @@
 package router
 
 import (
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/config"
 	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/http/handler"
 	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/http/middleware"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/model"
 	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/service"
 	routing "github.com/qiangxue/fasthttp-routing"
 	fasthttpSwagger "github.com/swaggo/fasthttp-swagger"
@@ -20,10 +22,29 @@ func adaptHandler(h fasthttp.RequestHandler) routing.Handler {
 func SetupRouter(
 	authHandler *handler.AuthHandler,
 	userHandler *handler.UserHandler,
+	securityEventHandler *handler.SecurityEventHandler,
+	invitationHandler *handler.InvitationHandler,
+	roleHandler *handler.RoleHandler,
+	policyHandler *handler.PolicyHandler,
+	organizationHandler *handler.OrganizationHandler,
+	groupHandler *handler.GroupHandler,
+	dataRequestHandler *handler.DataRequestHandler,
+	userImportHandler *handler.UserImportHandler,
 	tokenService *service.TokenService,
+	userService *service.UserService,
+	roleService *service.RoleService,
+	cfg *config.Config,
 ) *routing.Router {
 	router := routing.New()
 
+	// authenticated verifies the access token and loads the caller
+	authenticated := middleware.AuthMiddleware(tokenService, userService)
+
+	// can guards a route with permissions resolved from the caller's role
+	can := func(permissions ...string) routing.Handler {
+		return middleware.RequirePermission(roleService, permissions...)
+	}
+
 	router.Use(middleware.Logger)
 
 	// Swagger Docs
@@ -37,16 +58,120 @@ func SetupRouter(
 	auth.Post("/login", adaptHandler(authHandler.Login))
 	auth.Post("/logout", adaptHandler(authHandler.Logout))
 	auth.Post("/refresh-tokens", adaptHandler(authHandler.RefreshTokens))
+	auth.Post("/forgot-password", adaptHandler(authHandler.ForgotPassword))
+	auth.Post("/reset-password", adaptHandler(authHandler.ResetPassword))
+	auth.Get("/revoke-sessions", adaptHandler(authHandler.ConfirmRevokeSessions))
+	auth.Post("/revoke-sessions", adaptHandler(authHandler.RevokeSessions))
+	auth.Post("/accept-invite", adaptHandler(authHandler.AcceptInvite))
+	auth.Post("/confirm-email-change", adaptHandler(userHandler.ConfirmEmailChange))
+	auth.Post("/cancel-email-change", adaptHandler(userHandler.CancelEmailChange))
+	auth.Post("/reauthenticate", authenticated, middleware.RequireScopes(model.ScopeProfile), authHandler.Reauthenticate)
+	auth.Post("/scoped-token", authenticated, authHandler.CreateScopedToken)
 
-	// --- User Routes (Protected: Admin Only) ---
+	// --- Current User Routes (Protected: Any Authenticated User) ---
+	me := v1.Group("/users/me")
+	me.Use(authenticated, middleware.RequireScopes(model.ScopeProfile))
+
+	me.Get("/security-events", securityEventHandler.GetMyEvents)
+	me.Post("/export", dataRequestHandler.RequestExport)
+	me.Post("/erasure", middleware.RequireRecentAuth(cfg.ReauthMaxAge), dataRequestHandler.RequestErasure)
+	me.Get("/data-requests", dataRequestHandler.GetMyDataRequests)
+	me.Get("/data-requests/<requestId>/download", dataRequestHandler.DownloadExport)
+	me.Delete("/data-requests/<requestId>", dataRequestHandler.CancelDataRequest)
+
+	// --- User Routes (Protected: Permission Based) ---
 	users := v1.Group("/users")
-	users.Use(middleware.AuthMiddleware(tokenService, "admin"))
-	
-	users.Post("", adaptHandler(userHandler.CreateUser))
-	users.Get("", adaptHandler(userHandler.GetUsers))
-	users.Get("/<userId>", userHandler.GetUser)
-	users.Patch("/<userId>", userHandler.UpdateUser)
-	users.Delete("/<userId>", userHandler.DeleteUser)
+	users.Use(authenticated)
+
+	// Static paths are registered before "/<userId>" so they take precedence
+	// Polled reads may be kept by the client but are revalidated every time
+	revalidated := middleware.ConditionalGET("private, no-cache")
+
+	users.Post("/invitations", can(model.PermissionUsersWrite), invitationHandler.CreateInvitation)
+	users.Get("/invitations", can(model.PermissionUsersRead), invitationHandler.GetInvitations)
+	users.Post("/invitations/<invitationId>/resend", can(model.PermissionUsersWrite), invitationHandler.ResendInvitation)
+	users.Delete("/invitations/<invitationId>", can(model.PermissionUsersWrite), invitationHandler.RevokeInvitation)
+
+	users.Get("/export", can(model.PermissionUsersRead), userHandler.ExportUsers)
+	// Each kind of bulk operation needs the permission of its single-user route
+	users.Post("/bulk",
+		middleware.When(middleware.BodyHasOperation(service.BulkOpUpdate, service.BulkOpStatus), can(model.PermissionUsersWrite)),
+		middleware.When(middleware.BodyHasOperation(service.BulkOpDelete), can(model.PermissionUsersDelete)),
+		middleware.When(middleware.BodyHasOperation(service.BulkOpRole), can(model.PermissionRolesAssign)),
+		middleware.RequireRecentAuth(cfg.ReauthMaxAge),
+		userHandler.BulkUsers)
+	users.Post("/import", can(model.PermissionUsersWrite), userImportHandler.ImportUsers)
+	users.Get("/import/<jobId>", can(model.PermissionUsersWrite), userImportHandler.GetImportJob)
+
+	users.Get("/deleted", can(model.PermissionUsersDelete), userHandler.GetDeletedUsers)
+	users.Post("/deleted/<userId>/restore", can(model.PermissionUsersDelete), userHandler.RestoreUser)
+	users.Delete("/deleted/<userId>", can(model.PermissionUsersDelete), middleware.RequireRecentAuth(cfg.ReauthMaxAge), userHandler.PurgeUser)
+
+	users.Post("", can(model.PermissionUsersWrite), userHandler.CreateUser)
+	users.Get("", can(model.PermissionUsersRead), revalidated, userHandler.GetUsers)
+	// Users may read and update their own record without holding the permission
+	users.Get("/<userId>", middleware.Authorize(middleware.SelfOrPermission(roleService, model.PermissionUsersRead)), revalidated, userHandler.GetUser)
+	// Sensitive operations additionally require a recent password confirmation
+	users.Patch("/<userId>", middleware.Authorize(middleware.SelfOrPermission(roleService, model.PermissionUsersWrite)), userHandler.UpdateUser)
+	users.Delete("/<userId>", can(model.PermissionUsersDelete), middleware.RequireRecentAuth(cfg.ReauthMaxAge), userHandler.DeleteUser)
+	users.Put("/<userId>/role", can(model.PermissionRolesAssign), middleware.RequireRecentAuth(cfg.ReauthMaxAge), userHandler.ChangeRole)
+	users.Put("/<userId>/status", can(model.PermissionUsersWrite), middleware.RequireRecentAuth(cfg.ReauthMaxAge), userHandler.ChangeStatus)
+
+	// --- Security Event Routes (Protected: Permission Based) ---
+	securityEvents := v1.Group("/security-events")
+	securityEvents.Use(authenticated, can(model.PermissionSecurityEventsRead))
+
+	securityEvents.Get("", securityEventHandler.GetEvents)
+
+	// --- Organization Routes (Protected: Organization Role Based) ---
+	organizations := v1.Group("/organizations")
+	organizations.Use(authenticated, middleware.RequireScopes(model.ScopeProfile))
+
+	organizations.Get("", organizationHandler.GetOrganizations)
+	organizations.Post("", organizationHandler.CreateOrganization)
+	organizations.Get("/<orgId>", organizationHandler.GetOrganization)
+	organizations.Patch("/<orgId>", organizationHandler.UpdateOrganization)
+	organizations.Delete("/<orgId>", middleware.RequireRecentAuth(cfg.ReauthMaxAge), organizationHandler.DeleteOrganization)
+	organizations.Post("/<orgId>/switch", organizationHandler.SwitchOrganization)
+	organizations.Get("/<orgId>/members", organizationHandler.GetMembers)
+	organizations.Post("/<orgId>/members", organizationHandler.InviteMember)
+	organizations.Patch("/<orgId>/members/<userId>", organizationHandler.UpdateMember)
+	organizations.Delete("/<orgId>/members/<userId>", organizationHandler.RemoveMember)
+
+	// --- Group Routes (Protected: Permission Based) ---
+	groups := v1.Group("/groups")
+	groups.Use(authenticated)
+
+	groups.Get("", can(model.PermissionGroupsRead), groupHandler.GetGroups)
+	groups.Post("", can(model.PermissionGroupsWrite), groupHandler.CreateGroup)
+	groups.Get("/<groupId>", can(model.PermissionGroupsRead), groupHandler.GetGroup)
+	groups.Patch("/<groupId>", can(model.PermissionGroupsWrite), groupHandler.UpdateGroup)
+	groups.Delete("/<groupId>", can(model.PermissionGroupsWrite), groupHandler.DeleteGroup)
+	groups.Get("/<groupId>/members", can(model.PermissionGroupsRead), groupHandler.GetGroupMembers)
+	groups.Put("/<groupId>/members/<userId>", can(model.PermissionGroupsWrite), groupHandler.AddGroupMember)
+	groups.Delete("/<groupId>/members/<userId>", can(model.PermissionGroupsWrite), groupHandler.RemoveGroupMember)
+
+	// --- Data Request Routes (Protected: Permission Based) ---
+	dataRequests := v1.Group("/data-requests")
+	dataRequests.Use(authenticated, can(model.PermissionDataRequestsManage))
+
+	dataRequests.Get("", dataRequestHandler.GetDataRequests)
+	dataRequests.Get("/<requestId>", dataRequestHandler.GetDataRequest)
+	dataRequests.Post("/<requestId>/approve", middleware.RequireRecentAuth(cfg.ReauthMaxAge), dataRequestHandler.ApproveDataRequest)
+	dataRequests.Post("/<requestId>/reject", dataRequestHandler.RejectDataRequest)
+
+	// --- Role & Permission Routes (Protected: Permission Based) ---
+	roles := v1.Group("/roles")
+	roles.Use(authenticated)
+
+	roles.Get("", can(model.PermissionRolesRead), adaptHandler(roleHandler.GetRoles))
+	roles.Post("", can(model.PermissionRolesWrite), adaptHandler(roleHandler.CreateRole))
+	roles.Get("/<roleId>", can(model.PermissionRolesRead), roleHandler.GetRole)
+	roles.Patch("/<roleId>", can(model.PermissionRolesWrite), roleHandler.UpdateRole)
+	roles.Delete("/<roleId>", can(model.PermissionRolesWrite), roleHandler.DeleteRole)
+
+	v1.Get("/permissions", authenticated, can(model.PermissionRolesRead), adaptHandler(roleHandler.GetPermissions))
+	v1.Post("/policy/decide", authenticated, can(model.PermissionRolesRead), policyHandler.Decide)
 
 	return router
 }
\ No newline at end of file
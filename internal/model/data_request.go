@@ -0,0 +1,78 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Kinds of data subject requests
+const (
+	DataRequestTypeExport  = "export"
+	DataRequestTypeErasure = "erasure"
+)
+
+// Data request lifecycle. Exports go from pending through processing to completed
+// or failed on their own; erasures wait in pending until an admin approves or rejects them.
+const (
+	DataRequestStatusPending    = "pending"
+	DataRequestStatusProcessing = "processing"
+	DataRequestStatusCompleted  = "completed"
+	DataRequestStatusFailed     = "failed"
+	DataRequestStatusRejected   = "rejected"
+	DataRequestStatusCancelled  = "cancelled"
+)
+
+// DataRequest is a user's request to receive a copy of their data (export)
+// or to have it erased. It doubles as the audit record of how it was handled.
+type DataRequest struct {
+	gorm.Model
+	UserID uint   `gorm:"index;not null"`
+	Type   string `gorm:"index;not null"`
+	Status string `gorm:"index;not null"`
+	// OrganizationID is the organization the user acted in; its admins review the request
+	OrganizationID uint `gorm:"index"`
+	// Reason is the user's note on an erasure, Resolution the reviewer's answer or the export error
+	Reason        string
+	Resolution    string
+	ProcessedByID *uint
+	CompletedAt   *time.Time
+	// FilePath locates the export bundle on disk until it expires
+	FilePath  string
+	ExpiresAt *time.Time
+}
+
+// IsActive reports whether the request is still waiting to be handled
+func (r *DataRequest) IsActive() bool {
+	return r.Status == DataRequestStatusPending || r.Status == DataRequestStatusProcessing
+}
+
+// DataRequestResponse is a DTO for sending data request details to the client
+type DataRequestResponse struct {
+	ID            uint       `json:"id"`
+	UserID        uint       `json:"userId"`
+	Type          string     `json:"type"`
+	Status        string     `json:"status"`
+	Reason        string     `json:"reason,omitempty"`
+	Resolution    string     `json:"resolution,omitempty"`
+	ProcessedByID *uint      `json:"processedById,omitempty"`
+	CompletedAt   *time.Time `json:"completedAt,omitempty"`
+	ExpiresAt     *time.Time `json:"expiresAt,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+}
+
+// ToResponse converts a DataRequest model to DataRequestResponse DTO
+func (r *DataRequest) ToResponse() DataRequestResponse {
+	return DataRequestResponse{
+		ID:            r.ID,
+		UserID:        r.UserID,
+		Type:          r.Type,
+		Status:        r.Status,
+		Reason:        r.Reason,
+		Resolution:    r.Resolution,
+		ProcessedByID: r.ProcessedByID,
+		CompletedAt:   r.CompletedAt,
+		ExpiresAt:     r.ExpiresAt,
+		CreatedAt:     r.CreatedAt,
+	}
+}
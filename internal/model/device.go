@@ -0,0 +1,17 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UserDevice is a client (network + user agent) a user has successfully logged in from
+type UserDevice struct {
+	gorm.Model
+	UserID      uint      `json:"userId" gorm:"uniqueIndex:idx_user_device;not null"`
+	Fingerprint string    `json:"-" gorm:"uniqueIndex:idx_user_device;not null"`
+	IP          string    `json:"ip"`
+	UserAgent   string    `json:"userAgent"`
+	LastSeenAt  time.Time `json:"lastSeenAt"`
+}
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Group is a team of users within an organization. Permissions granted to a
+// group apply to all of its members in addition to those of their role.
+type Group struct {
+	gorm.Model
+	OrganizationID uint         `json:"organizationId" gorm:"uniqueIndex:idx_org_group_name;not null"`
+	Name           string       `json:"name" gorm:"uniqueIndex:idx_org_group_name;not null"`
+	Description    string       `json:"description"`
+	Permissions    []Permission `json:"permissions" gorm:"many2many:group_permissions;"`
+	Members        []User       `json:"-" gorm:"many2many:user_groups;"`
+}
+
+// GroupResponse is a DTO for sending group data to the client
+type GroupResponse struct {
+	ID          uint      `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Permissions []string  `json:"permissions"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// PermissionNames returns the names of the permissions granted to the group
+func (g *Group) PermissionNames() []string {
+	names := make([]string, 0, len(g.Permissions))
+	for _, p := range g.Permissions {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// ToResponse converts a Group model to GroupResponse DTO
+func (g *Group) ToResponse() GroupResponse {
+	return GroupResponse{
+		ID:          g.ID,
+		Name:        g.Name,
+		Description: g.Description,
+		Permissions: g.PermissionNames(),
+		CreatedAt:   g.CreatedAt,
+	}
+}
@@ -0,0 +1,94 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// File formats accepted by the user import
+const (
+	ImportFormatCSV    = "csv"
+	ImportFormatNDJSON = "ndjson"
+)
+
+// Import job lifecycle: pending until a worker picks it up, then processing
+// until every row has been handled or the job failed as a whole
+const (
+	ImportJobStatusPending    = "pending"
+	ImportJobStatusProcessing = "processing"
+	ImportJobStatusCompleted  = "completed"
+	ImportJobStatusFailed     = "failed"
+)
+
+// Outcomes of a single import row. Rows are only "valid" in a dry run.
+const (
+	ImportRowValid   = "valid"
+	ImportRowCreated = "created"
+	ImportRowInvited = "invited"
+	ImportRowFailed  = "failed"
+)
+
+// ImportJob is a bulk import of users into an organization
+type ImportJob struct {
+	gorm.Model
+	OrganizationID uint   `gorm:"index;not null"`
+	CreatedByID    uint   `gorm:"not null"`
+	Status         string `gorm:"index;not null"`
+	DryRun         bool   `gorm:"-"`
+	Total          int
+	Created        int
+	Invited        int
+	Failed         int
+	// Report holds the per-row results as JSON, see ImportRowResult
+	Report      string `gorm:"type:text"`
+	Error       string
+	CompletedAt *time.Time
+}
+
+// ImportRowResult is the outcome of one row of an import file. Row counts from 1,
+// not including a CSV header.
+type ImportRowResult struct {
+	Row    int      `json:"row"`
+	Email  string   `json:"email,omitempty"`
+	Status string   `json:"status"`
+	UserID uint     `json:"userId,omitempty"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+// ImportJobResponse is a DTO for sending import progress and results to the client
+type ImportJobResponse struct {
+	ID          uint              `json:"id,omitempty"`
+	Status      string            `json:"status"`
+	DryRun      bool              `json:"dryRun"`
+	Total       int               `json:"total"`
+	Created     int               `json:"created"`
+	Invited     int               `json:"invited"`
+	Failed      int               `json:"failed"`
+	Error       string            `json:"error,omitempty"`
+	Rows        []ImportRowResult `json:"rows"`
+	CreatedAt   time.Time         `json:"createdAt"`
+	CompletedAt *time.Time        `json:"completedAt,omitempty"`
+}
+
+// ToResponse converts an ImportJob model to ImportJobResponse DTO
+func (j *ImportJob) ToResponse() ImportJobResponse {
+	rows := []ImportRowResult{}
+	if j.Report != "" {
+		_ = json.Unmarshal([]byte(j.Report), &rows)
+	}
+	return ImportJobResponse{
+		ID:          j.ID,
+		Status:      j.Status,
+		DryRun:      j.DryRun,
+		Total:       j.Total,
+		Created:     j.Created,
+		Invited:     j.Invited,
+		Failed:      j.Failed,
+		Error:       j.Error,
+		Rows:        rows,
+		CreatedAt:   j.CreatedAt,
+		CompletedAt: j.CompletedAt,
+	}
+}
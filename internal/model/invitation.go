@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Invitation tracks an admin-created account waiting for its owner to set a password.
+// The invite token itself lives in the tokens table like other one-time tokens.
+type Invitation struct {
+	gorm.Model
+	UserID         uint       `json:"userId" gorm:"index;not null"`
+	InvitedByID    uint       `json:"invitedById" gorm:"not null"`
+	OrganizationID uint       `json:"organizationId" gorm:"index"`
+	Expires        time.Time  `json:"expires" gorm:"not null"`
+	LastSentAt     time.Time  `json:"lastSentAt"`
+	AcceptedAt     *time.Time `json:"acceptedAt"`
+
+	User      User `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	InvitedBy User `gorm:"foreignKey:InvitedByID"`
+}
+
+// InvitationResponse is a DTO for sending invitation data to the client
+type InvitationResponse struct {
+	ID          uint         `json:"id"`
+	User        UserResponse `json:"user"`
+	InvitedByID uint         `json:"invitedById"`
+	Expires     time.Time    `json:"expires"`
+	LastSentAt  time.Time    `json:"lastSentAt"`
+	CreatedAt   time.Time    `json:"createdAt"`
+}
+
+// ToResponse converts an Invitation model to InvitationResponse DTO
+func (i *Invitation) ToResponse() InvitationResponse {
+	return InvitationResponse{
+		ID:          i.ID,
+		User:        i.User.ToResponse(),
+		InvitedByID: i.InvitedByID,
+		Expires:     i.Expires,
+		LastSentAt:  i.LastSentAt,
+		CreatedAt:   i.CreatedAt,
+	}
+}
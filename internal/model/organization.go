@@ -0,0 +1,74 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Organization roles, held per membership
+const (
+	OrgRoleOwner  = "owner"
+	OrgRoleAdmin  = "admin"
+	OrgRoleMember = "member"
+)
+
+// Organization is a tenant. Users only see other users of the organization
+// they are currently acting in.
+type Organization struct {
+	gorm.Model
+	Name string `json:"name" gorm:"not null"`
+}
+
+// Membership links a user to an organization with an organization role
+type Membership struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	OrganizationID uint      `json:"organizationId" gorm:"uniqueIndex:idx_org_user;not null"`
+	UserID         uint      `json:"userId" gorm:"uniqueIndex:idx_org_user;index;not null"`
+	Role           string    `json:"role" gorm:"not null"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+
+	Organization Organization `json:"-" gorm:"constraint:OnDelete:CASCADE;"`
+	User         User         `json:"-" gorm:"constraint:OnDelete:CASCADE;"`
+}
+
+// OrganizationResponse is a DTO describing an organization from a member's point of view
+type OrganizationResponse struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	Current   bool      `json:"current"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// MemberResponse is a DTO for listing the members of an organization
+type MemberResponse struct {
+	UserID   uint      `json:"userId"`
+	Name     string    `json:"name"`
+	Email    string    `json:"email"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joinedAt"`
+}
+
+// ToResponse converts a Membership with its User preloaded to MemberResponse DTO
+func (m *Membership) ToResponse() MemberResponse {
+	return MemberResponse{
+		UserID:   m.UserID,
+		Name:     m.User.Name,
+		Email:    m.User.Email,
+		Role:     m.Role,
+		JoinedAt: m.CreatedAt,
+	}
+}
+
+// ToOrganizationResponse converts a Membership with its Organization preloaded to OrganizationResponse DTO
+func (m *Membership) ToOrganizationResponse(currentOrgID uint) OrganizationResponse {
+	return OrganizationResponse{
+		ID:        m.Organization.ID,
+		Name:      m.Organization.Name,
+		Role:      m.Role,
+		Current:   m.OrganizationID == currentOrgID,
+		CreatedAt: m.Organization.CreatedAt,
+	}
+}
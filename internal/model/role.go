@@ -0,0 +1,74 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Built-in roles. They are seeded on startup and cannot be deleted.
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
+// Permissions known to the application, in "resource:action" form
+const (
+	PermissionUsersRead          = "users:read"
+	PermissionUsersWrite         = "users:write"
+	PermissionUsersDelete        = "users:delete"
+	PermissionRolesRead          = "roles:read"
+	PermissionRolesWrite         = "roles:write"
+	PermissionRolesAssign        = "roles:assign"
+	PermissionSecurityEventsRead = "security-events:read"
+	PermissionGroupsRead         = "groups:read"
+	PermissionGroupsWrite        = "groups:write"
+	PermissionDataRequestsManage = "data-requests:manage"
+)
+
+// Permission is a single capability that can be granted to roles and groups
+type Permission struct {
+	ID          uint   `json:"id" gorm:"primarykey"`
+	Name        string `json:"name" gorm:"uniqueIndex;not null"`
+	Description string `json:"description"`
+}
+
+// Role groups permissions. Users reference roles by name through User.Role.
+type Role struct {
+	gorm.Model
+	Name        string       `json:"name" gorm:"uniqueIndex;not null"`
+	Description string       `json:"description"`
+	IsSystem    bool         `json:"isSystem" gorm:"default:false"`
+	Permissions []Permission `json:"permissions" gorm:"many2many:role_permissions;"`
+}
+
+// RoleResponse is a DTO for sending role data to the client
+type RoleResponse struct {
+	ID          uint      `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	IsSystem    bool      `json:"isSystem"`
+	Permissions []string  `json:"permissions"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// PermissionNames returns the names of the permissions granted to the role
+func (r *Role) PermissionNames() []string {
+	names := make([]string, 0, len(r.Permissions))
+	for _, p := range r.Permissions {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// ToResponse converts a Role model to RoleResponse DTO
+func (r *Role) ToResponse() RoleResponse {
+	return RoleResponse{
+		ID:          r.ID,
+		Name:        r.Name,
+		Description: r.Description,
+		IsSystem:    r.IsSystem,
+		Permissions: r.PermissionNames(),
+		CreatedAt:   r.CreatedAt,
+	}
+}
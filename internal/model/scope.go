@@ -0,0 +1,5 @@
+package model
+
+// ScopeProfile lets a token access its own user's account, e.g. /users/me.
+// Every other scope is named after the permission it unlocks, such as "users:read".
+const ScopeProfile = "profile"
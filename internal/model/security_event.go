@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+const (
+	SecurityEventLoginSuccess      = "loginSuccess"
+	SecurityEventLoginFailure      = "loginFailure"
+	SecurityEventRefresh           = "refresh"
+	SecurityEventLogout            = "logout"
+	SecurityEventPasswordChange    = "passwordChange"
+	SecurityEventTokenReuse        = "tokenReuse"
+	SecurityEventNewDevice         = "newDevice"
+	SecurityEventSessionsRevoked   = "sessionsRevoked"
+	SecurityEventPasswordReset     = "passwordReset"
+	SecurityEventReauthenticate    = "reauthenticate"
+	SecurityEventEmailChange       = "emailChange"
+	SecurityEventEmailChangeCancel = "emailChangeCancel"
+	SecurityEventRoleChange        = "roleChange"
+	SecurityEventStatusChange      = "statusChange"
+)
+
+// SecurityEvent is an append-only audit record of an authentication-related action
+type SecurityEvent struct {
+	ID        uint   `json:"id" gorm:"primarykey"`
+	UserID    uint   `json:"userId" gorm:"index"`
+	Type      string `json:"type" gorm:"index;not null"`
+	Email     string `json:"email"`
+	IP        string `json:"ip"`
+	UserAgent string `json:"userAgent"`
+
+	// ActorID is the user who performed the action when it was not the account owner
+	ActorID uint   `json:"actorId,omitempty" gorm:"index"`
+	Details string `json:"details,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt" gorm:"index"`
+}
@@ -7,10 +7,15 @@ import (
 )
 
 const (
-	TokenTypeAccess        = "access"
-	TokenTypeRefresh       = "refresh"
-	TokenTypeResetPassword = "resetPassword"
-	TokenTypeVerifyEmail   = "verifyEmail"
+	TokenTypeAccess         = "access"
+	TokenTypeRefresh        = "refresh"
+	TokenTypeResetPassword  = "resetPassword"
+	TokenTypeVerifyEmail    = "verifyEmail"
+	TokenTypeRevokeSessions = "revokeSessions"
+	TokenTypeInvite         = "invite"
+	// Sent to the new address to confirm it, and to the old address to cancel the change
+	TokenTypeEmailChange       = "emailChange"
+	TokenTypeEmailChangeCancel = "emailChangeCancel"
 )
 
 // Token represents authentication tokens in the database
@@ -24,4 +29,20 @@ type Token struct {
 	
 	// Foreign Key Relation
 	User User `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
-}
\ No newline at end of file
+}
+
+// SessionResponse describes a login session, i.e. an active refresh token, without the token itself
+type SessionResponse struct {
+	ID        uint      `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// ToSessionResponse converts a refresh token to a SessionResponse DTO
+func (t *Token) ToSessionResponse() SessionResponse {
+	return SessionResponse{
+		ID:        t.ID,
+		CreatedAt: t.CreatedAt,
+		ExpiresAt: t.Expires,
+	}
+}
@@ -9,31 +9,84 @@ import (
 // User represents the user entity in the database
 type User struct {
 	gorm.Model
-	Name            string `json:"name" gorm:"not null"`
-	Email           string `json:"email" gorm:"uniqueIndex;not null"`
-	Password        string `json:"password" gorm:"not null"` 
+	Name            string `json:"name" gorm:"not null" validate:"required,max=100"`
+	Email           string `json:"email" gorm:"uniqueIndex;not null" validate:"required,email"`
+	Password        string `json:"password" gorm:"not null" validate:"required,min=8"`
 	Role            string `json:"role" gorm:"default:'user'"`
 	IsEmailVerified bool   `json:"isEmailVerified" gorm:"default:false"`
+
+	// Status controls whether the account may be used, see CanTransitionStatus.
+	// It is only changed through the status endpoint, never from request bodies.
+	Status       string `json:"-" gorm:"default:'active';index"`
+	StatusReason string `json:"-"`
+	// SuspendedUntil ends a suspension automatically; nil suspends indefinitely
+	SuspendedUntil *time.Time `json:"-"`
+
+	// PasswordResetRequired blocks login until the password is reset,
+	// e.g. after the owner reports a login they did not make.
+	PasswordResetRequired bool `json:"-" gorm:"default:false"`
+
+	// PendingEmail holds a requested new address until its owner confirms it
+	PendingEmail string `json:"-"`
+
+	// TokenVersion is embedded in access tokens. Bumping it invalidates every
+	// access token issued before, e.g. after a role change or when all sessions are revoked.
+	TokenVersion uint `json:"-" gorm:"default:0"`
+
+	// Version counts the changes to the row. Updates only apply to the version they
+	// were read at, so concurrent edits cannot silently overwrite each other.
+	Version uint `json:"-" gorm:"not null;default:1"`
+
+	// LastOrganizationID is the organization selected most recently; new logins start in it
+	LastOrganizationID *uint `json:"-"`
+
+	// Groups the user belongs to, across all of their organizations
+	Groups []Group `json:"-" gorm:"many2many:user_groups;"`
+
+	// TenantID and TenantRole describe the organization the user acts in for the
+	// current request, Scopes what its token may be used for and AuthTime when the
+	// user last entered their password. They come from the access token and are
+	// never stored; Scopes is nil outside of requests.
+	TenantID   uint       `json:"-" gorm:"-"`
+	TenantRole string     `json:"-" gorm:"-"`
+	Scopes     []string   `json:"-" gorm:"-"`
+	AuthTime   *time.Time `json:"-" gorm:"-"`
 }
 
 // UserResponse is a DTO for sending user data to the client safely
 type UserResponse struct {
-	ID              uint      `json:"id"`
-	Name            string    `json:"name"`
-	Email           string    `json:"email"`
-	Role            string    `json:"role"`
-	IsEmailVerified bool      `json:"isEmailVerified"`
-	CreatedAt       time.Time `json:"createdAt"`
+	ID              uint       `json:"id"`
+	Name            string     `json:"name"`
+	Email           string     `json:"email"`
+	Role            string     `json:"role"`
+	IsEmailVerified bool       `json:"isEmailVerified"`
+	PendingEmail    string     `json:"pendingEmail,omitempty"`
+	Status          string     `json:"status"`
+	StatusReason    string     `json:"statusReason,omitempty"`
+	SuspendedUntil  *time.Time `json:"suspendedUntil,omitempty"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	DeletedAt       *time.Time `json:"deletedAt,omitempty"`
+	// Version is also sent as the ETag; pass it in If-Match to update or delete the user
+	Version uint `json:"version"`
 }
 
 // ToResponse converts a User model to UserResponse DTO
 func (u *User) ToResponse() UserResponse {
-	return UserResponse{
+	response := UserResponse{
 		ID:              u.ID,
 		Name:            u.Name,
 		Email:           u.Email,
 		Role:            u.Role,
 		IsEmailVerified: u.IsEmailVerified,
+		PendingEmail:    u.PendingEmail,
+		Status:          u.Status,
+		StatusReason:    u.StatusReason,
+		SuspendedUntil:  u.SuspendedUntil,
 		CreatedAt:       u.CreatedAt,
+		Version:         u.Version,
+	}
+	if u.DeletedAt.Valid {
+		response.DeletedAt = &u.DeletedAt.Time
 	}
+	return response
 }
\ No newline at end of file
@@ -0,0 +1,28 @@
+package model
+
+// Account statuses. Only active users can sign in or use their tokens.
+const (
+	UserStatusPending   = "pending"
+	UserStatusActive    = "active"
+	UserStatusSuspended = "suspended"
+	UserStatusBanned    = "banned"
+)
+
+// userStatusTransitions lists the statuses each status may change to. Banned is final.
+// Suspended may be "changed" to suspended again to update the reason or expiry.
+var userStatusTransitions = map[string][]string{
+	UserStatusPending:   {UserStatusActive, UserStatusBanned},
+	UserStatusActive:    {UserStatusSuspended, UserStatusBanned},
+	UserStatusSuspended: {UserStatusActive, UserStatusSuspended, UserStatusBanned},
+	UserStatusBanned:    {},
+}
+
+// CanTransitionStatus reports whether a user may move from one status to another
+func CanTransitionStatus(from, to string) bool {
+	for _, status := range userStatusTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
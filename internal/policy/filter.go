@@ -0,0 +1,192 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// RowFilter describes which resources a subject may access for an action, so
+// lists can be filtered in the database instead of deciding row by row.
+type RowFilter struct {
+	// Unrestricted is set when an allow rule matches regardless of the resource
+	Unrestricted bool
+	// Allow holds the resource conditions of the remaining allow rules; a row must satisfy one of them
+	Allow [][]Condition
+	// Deny holds the resource conditions of deny rules; a row must satisfy none of them
+	Deny [][]Condition
+}
+
+// Filter resolves the rules for a subject and action into resource conditions.
+// Conditions on the subject are evaluated immediately; references in resource
+// conditions are replaced by the subject's values.
+func (e *Engine) Filter(subject Attributes, action string) RowFilter {
+	var filter RowFilter
+	req := Request{Subject: subject, Action: action}
+
+	for i := range e.rules {
+		rule := &e.rules[i]
+		if rule.Effect == EffectRedact || !rule.covers(action) {
+			continue
+		}
+
+		applies := true
+		var resourceConditions []Condition
+		for _, cond := range rule.conditions {
+			expected := resolve(cond.Value, req)
+			if strings.HasPrefix(cond.Attr, "resource.") {
+				resourceConditions = append(resourceConditions, Condition{
+					Attr:  strings.TrimPrefix(cond.Attr, "resource."),
+					Op:    cond.Op,
+					Value: expected,
+				})
+				continue
+			}
+			if !compare(lookup(cond.Attr, req), cond.Op, expected) {
+				applies = false
+				break
+			}
+		}
+		if !applies {
+			continue
+		}
+
+		if rule.Effect == EffectAllow {
+			if len(resourceConditions) == 0 {
+				filter.Unrestricted = true
+			} else {
+				filter.Allow = append(filter.Allow, resourceConditions)
+			}
+		} else {
+			filter.Deny = append(filter.Deny, resourceConditions)
+		}
+	}
+	return filter
+}
+
+// RedactableFields lists the fields redact rules may remove from resources the subject
+// reads for an action. Resource conditions are assumed to hold, so a field is listed
+// when it is hidden from the subject on any resource.
+func (e *Engine) RedactableFields(subject Attributes, action string) []string {
+	req := Request{Subject: subject, Action: action}
+	fields := map[string]bool{}
+
+	for i := range e.rules {
+		rule := &e.rules[i]
+		if rule.Effect != EffectRedact || !rule.covers(action) {
+			continue
+		}
+		applies := true
+		for _, cond := range rule.conditions {
+			if strings.HasPrefix(cond.Attr, "resource.") {
+				continue
+			}
+			if !compare(lookup(cond.Attr, req), cond.Op, resolve(cond.Value, req)) {
+				applies = false
+				break
+			}
+		}
+		if applies {
+			for _, field := range rule.Fields {
+				fields[field] = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(fields))
+	for field := range fields {
+		names = append(names, field)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Scope translates the filter into a GORM scope. columns maps resource attributes
+// to database columns; conditions on unmapped attributes cannot be translated.
+func (f RowFilter) Scope(columns map[string]string) (func(db *gorm.DB) *gorm.DB, error) {
+	var clauses []string
+	var args []interface{}
+
+	if !f.Unrestricted {
+		if len(f.Allow) == 0 {
+			clauses = append(clauses, "1 = 0")
+		} else {
+			var alternatives []string
+			for _, conditions := range f.Allow {
+				sql, condArgs, err := conjunction(conditions, columns)
+				if err != nil {
+					return nil, err
+				}
+				alternatives = append(alternatives, sql)
+				args = append(args, condArgs...)
+			}
+			clauses = append(clauses, "("+strings.Join(alternatives, " OR ")+")")
+		}
+	}
+
+	for _, conditions := range f.Deny {
+		if len(conditions) == 0 {
+			clauses = append(clauses, "1 = 0")
+			continue
+		}
+		sql, condArgs, err := conjunction(conditions, columns)
+		if err != nil {
+			return nil, err
+		}
+		clauses = append(clauses, "NOT "+sql)
+		args = append(args, condArgs...)
+	}
+
+	return func(db *gorm.DB) *gorm.DB {
+		if len(clauses) == 0 {
+			return db
+		}
+		return db.Where(strings.Join(clauses, " AND "), args...)
+	}, nil
+}
+
+func conjunction(conditions []Condition, columns map[string]string) (string, []interface{}, error) {
+	parts := make([]string, 0, len(conditions))
+	args := make([]interface{}, 0, len(conditions))
+
+	for _, cond := range conditions {
+		column, ok := columns[cond.Attr]
+		if !ok {
+			return "", nil, fmt.Errorf("policy: resource attribute %q cannot be filtered", cond.Attr)
+		}
+
+		switch cond.Op {
+		case OpEq:
+			parts = append(parts, column+" = ?")
+		case OpNe:
+			parts = append(parts, column+" <> ?")
+		case OpIn:
+			parts = append(parts, column+" IN ?")
+		case OpNotIn:
+			parts = append(parts, column+" NOT IN ?")
+		default:
+			return "", nil, fmt.Errorf("policy: operator %q cannot be filtered", cond.Op)
+		}
+		args = append(args, cond.Value)
+	}
+	return "(" + strings.Join(parts, " AND ") + ")", args, nil
+}
+
+// Redact converts v to its JSON object form and removes the given fields
+func Redact(v interface{}, fields []string) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var object map[string]interface{}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, err
+	}
+	for _, field := range fields {
+		delete(object, field)
+	}
+	return object, nil
+}
@@ -0,0 +1,393 @@
+// Package policy implements attribute-based access control (ABAC).
+//
+// Rules are declared in YAML and evaluated against the attributes of a subject
+// (the caller), the requested action and a resource. The default rules are
+// embedded in the binary; a custom file can be loaded instead via POLICY_FILE.
+package policy
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/pkg/logger"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed default_policy.yaml
+var defaultPolicy []byte
+
+// Rule effects
+const (
+	EffectAllow  = "allow"
+	EffectDeny   = "deny"
+	EffectRedact = "redact"
+)
+
+// Condition operators
+const (
+	OpEq       = "eq"
+	OpNe       = "ne"
+	OpIn       = "in"
+	OpNotIn    = "not_in"
+	OpContains = "contains"
+)
+
+// Attributes describe a subject or resource, e.g. {"id": 1, "role": "admin"}
+type Attributes map[string]interface{}
+
+// Request is a single access question: may Subject perform Action on Resource?
+type Request struct {
+	Subject  Attributes
+	Action   string
+	Resource Attributes
+}
+
+// Condition compares an attribute with a value. Values starting with "$" refer
+// to another attribute, e.g. "$subject.id" or "$action".
+type Condition struct {
+	Attr  string      `json:"attr"`
+	Op    string      `json:"op"`
+	Value interface{} `json:"value"`
+}
+
+// Rule is a single policy statement. All conditions must hold for it to match.
+type Rule struct {
+	Name        string                            `yaml:"name"`
+	Description string                            `yaml:"description"`
+	Effect      string                            `yaml:"effect"`
+	Actions     []string                          `yaml:"actions"`
+	When        map[string]map[string]interface{} `yaml:"when"`
+	Fields      []string                          `yaml:"fields"`
+
+	conditions []Condition
+}
+
+// Evaluation records why a rule did or did not match, for explain output
+type Evaluation struct {
+	Rule    string `json:"rule"`
+	Effect  string `json:"effect"`
+	Matched bool   `json:"matched"`
+	Reason  string `json:"reason"`
+}
+
+// Decision is the outcome of a request. Access is denied unless an allow rule
+// matches and no deny rule does.
+type Decision struct {
+	Action         string       `json:"action"`
+	Allowed        bool         `json:"allowed"`
+	Rule           string       `json:"rule,omitempty"`
+	RedactedFields []string     `json:"redactedFields,omitempty"`
+	Trace          []Evaluation `json:"trace"`
+}
+
+// Engine evaluates requests against an ordered set of rules
+type Engine struct {
+	rules []Rule
+
+	// Debug logs the explanation of every decision
+	Debug bool
+}
+
+// Load reads the policy from path, or the embedded default policy when path is empty
+func Load(path string) (*Engine, error) {
+	data := defaultPolicy
+	if path != "" {
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	return Parse(data)
+}
+
+// Parse builds an engine from a YAML policy document
+func Parse(data []byte) (*Engine, error) {
+	var doc struct {
+		Rules []Rule `yaml:"rules"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid policy: %w", err)
+	}
+
+	names := make(map[string]bool, len(doc.Rules))
+	for i := range doc.Rules {
+		rule := &doc.Rules[i]
+		if err := rule.compile(); err != nil {
+			return nil, fmt.Errorf("invalid policy rule %q: %w", rule.Name, err)
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("invalid policy: duplicate rule %q", rule.Name)
+		}
+		names[rule.Name] = true
+	}
+	return &Engine{rules: doc.Rules}, nil
+}
+
+// compile validates the rule and flattens its conditions in a stable order
+func (r *Rule) compile() error {
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	switch r.Effect {
+	case EffectAllow, EffectDeny:
+	case EffectRedact:
+		if len(r.Fields) == 0 {
+			return errors.New("redact rules must list fields")
+		}
+	default:
+		return fmt.Errorf("unknown effect %q", r.Effect)
+	}
+	if len(r.Actions) == 0 {
+		return errors.New("at least one action is required")
+	}
+
+	attrs := make([]string, 0, len(r.When))
+	for attr := range r.When {
+		attrs = append(attrs, attr)
+	}
+	sort.Strings(attrs)
+
+	for _, attr := range attrs {
+		if !validPath(attr) {
+			return fmt.Errorf("unknown attribute %q", attr)
+		}
+		ops := make([]string, 0, len(r.When[attr]))
+		for op := range r.When[attr] {
+			ops = append(ops, op)
+		}
+		sort.Strings(ops)
+
+		for _, op := range ops {
+			value := r.When[attr][op]
+			switch op {
+			case OpEq, OpNe, OpContains:
+			case OpIn, OpNotIn:
+				if _, ok := toList(value); !ok {
+					return fmt.Errorf("%s %s expects a list", attr, op)
+				}
+			default:
+				return fmt.Errorf("unknown operator %q", op)
+			}
+			if err := validateRefs(value); err != nil {
+				return err
+			}
+			r.conditions = append(r.conditions, Condition{Attr: attr, Op: op, Value: value})
+		}
+	}
+	return nil
+}
+
+func validPath(path string) bool {
+	return path == "action" || strings.HasPrefix(path, "subject.") || strings.HasPrefix(path, "resource.")
+}
+
+// validateRefs only allows references to the subject or action, so resource
+// conditions can always be turned into database filters
+func validateRefs(value interface{}) error {
+	if list, ok := toList(value); ok {
+		for _, v := range list {
+			if err := validateRefs(v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if ref, ok := reference(value); ok && ref != "action" && !strings.HasPrefix(ref, "subject.") {
+		return fmt.Errorf("invalid reference $%s: only $subject.* and $action are supported", ref)
+	}
+	return nil
+}
+
+func (r *Rule) covers(action string) bool {
+	for _, a := range r.Actions {
+		if a == "*" || a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// Decide evaluates every rule against the request
+func (e *Engine) Decide(req Request) Decision {
+	decision := Decision{Action: req.Action}
+	var allowedBy, deniedBy string
+	redacted := make(map[string]bool)
+
+	for i := range e.rules {
+		rule := &e.rules[i]
+		evaluation := rule.evaluate(req)
+		decision.Trace = append(decision.Trace, evaluation)
+		if !evaluation.Matched {
+			continue
+		}
+
+		switch rule.Effect {
+		case EffectAllow:
+			if allowedBy == "" {
+				allowedBy = rule.Name
+			}
+		case EffectDeny:
+			if deniedBy == "" {
+				deniedBy = rule.Name
+			}
+		case EffectRedact:
+			for _, field := range rule.Fields {
+				redacted[field] = true
+			}
+		}
+	}
+
+	switch {
+	case deniedBy != "":
+		decision.Rule = deniedBy
+	case allowedBy != "":
+		decision.Allowed = true
+		decision.Rule = allowedBy
+		for field := range redacted {
+			decision.RedactedFields = append(decision.RedactedFields, field)
+		}
+		sort.Strings(decision.RedactedFields)
+	}
+
+	if e.Debug {
+		logger.Info("%s", decision.Explain())
+	}
+	return decision
+}
+
+func (r *Rule) evaluate(req Request) Evaluation {
+	evaluation := Evaluation{Rule: r.Name, Effect: r.Effect}
+	if !r.covers(req.Action) {
+		evaluation.Reason = "action not covered"
+		return evaluation
+	}
+
+	for _, cond := range r.conditions {
+		actual := lookup(cond.Attr, req)
+		expected := resolve(cond.Value, req)
+		if !compare(actual, cond.Op, expected) {
+			evaluation.Reason = fmt.Sprintf("%s %s %v failed (got %v)", cond.Attr, cond.Op, expected, actual)
+			return evaluation
+		}
+	}
+
+	evaluation.Matched = true
+	evaluation.Reason = "all conditions met"
+	return evaluation
+}
+
+// Explain renders the decision and the outcome of every rule as readable text
+func (d Decision) Explain() string {
+	var b strings.Builder
+
+	outcome := "deny"
+	if d.Allowed {
+		outcome = "allow"
+	}
+	rule := d.Rule
+	if rule == "" {
+		rule = "no rule matched"
+	}
+	fmt.Fprintf(&b, "policy: %s -> %s (%s)", d.Action, outcome, rule)
+	if len(d.RedactedFields) > 0 {
+		fmt.Fprintf(&b, ", redacted: %s", strings.Join(d.RedactedFields, ", "))
+	}
+
+	for _, evaluation := range d.Trace {
+		mark := " "
+		if evaluation.Matched {
+			mark = "x"
+		}
+		fmt.Fprintf(&b, "\n  [%s] %s (%s): %s", mark, evaluation.Rule, evaluation.Effect, evaluation.Reason)
+	}
+	return b.String()
+}
+
+func lookup(path string, req Request) interface{} {
+	switch {
+	case path == "action":
+		return req.Action
+	case strings.HasPrefix(path, "subject."):
+		return req.Subject[strings.TrimPrefix(path, "subject.")]
+	case strings.HasPrefix(path, "resource."):
+		return req.Resource[strings.TrimPrefix(path, "resource.")]
+	}
+	return nil
+}
+
+func reference(value interface{}) (string, bool) {
+	s, ok := value.(string)
+	if !ok || !strings.HasPrefix(s, "$") {
+		return "", false
+	}
+	return strings.TrimPrefix(s, "$"), true
+}
+
+// resolve replaces attribute references with their values
+func resolve(value interface{}, req Request) interface{} {
+	if list, ok := toList(value); ok {
+		resolved := make([]interface{}, len(list))
+		for i, v := range list {
+			resolved[i] = resolve(v, req)
+		}
+		return resolved
+	}
+	if ref, ok := reference(value); ok {
+		return lookup(ref, req)
+	}
+	return value
+}
+
+func compare(actual interface{}, op string, expected interface{}) bool {
+	switch op {
+	case OpEq:
+		return equal(actual, expected)
+	case OpNe:
+		return !equal(actual, expected)
+	case OpIn, OpNotIn:
+		list, _ := toList(expected)
+		found := containsValue(list, actual)
+		return found == (op == OpIn)
+	case OpContains:
+		list, ok := toList(actual)
+		return ok && containsValue(list, expected)
+	}
+	return false
+}
+
+// equal compares scalars by their string form, so YAML integers match uint IDs
+func equal(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+func containsValue(list []interface{}, value interface{}) bool {
+	for _, v := range list {
+		if equal(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func toList(value interface{}) ([]interface{}, bool) {
+	if value == nil {
+		return nil, false
+	}
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice {
+		return nil, false
+	}
+	list := make([]interface{}, v.Len())
+	for i := range list {
+		list[i] = v.Index(i).Interface()
+	}
+	return list, true
+}
@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidCursor is returned for cursors that are malformed, were not signed by
+// the server or were issued for another sort order
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor marks a row of a keyset-paginated list by the values of its sort columns and
+// the row ID, which breaks ties. Clients receive it base64-encoded and signed with a
+// server key, and must treat it as opaque.
+type Cursor struct {
+	Sort   string            `json:"s"`
+	Values []json.RawMessage `json:"v,omitempty"`
+	ID     uint              `json:"i"`
+}
+
+// NewCursor builds the cursor of a row in a list sorted by terms, given the row's
+// value for each term
+func NewCursor(terms []SortTerm, values []interface{}, id uint) *Cursor {
+	cursor := &Cursor{Sort: SortString(terms), ID: id}
+	for _, value := range values {
+		raw, _ := json.Marshal(value)
+		cursor.Values = append(cursor.Values, raw)
+	}
+	return cursor
+}
+
+// Encode returns the opaque form of the cursor: its base64 payload and a signature
+// made with key, so clients can neither forge cursors nor alter their values
+func (c *Cursor) Encode(key []byte) string {
+	data, _ := json.Marshal(c)
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(cursorSignature(key, payload))
+}
+
+// DecodeCursor parses a cursor received from a client, checking it was signed with key
+func DecodeCursor(key []byte, raw string) (*Cursor, error) {
+	payload, signature, ok := strings.Cut(raw, ".")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, cursorSignature(key, payload)) {
+		return nil, ErrInvalidCursor
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// cursorSignature is the HMAC-SHA256 of an encoded cursor payload
+func cursorSignature(key []byte, payload string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// KeysetScope orders a query by terms, then by ID, and restricts it to the rows after
+// the cursor, or before it when backwards is set, in which case the order is reversed.
+// A nil cursor only orders the query.
+func (f Fields) KeysetScope(terms []SortTerm, cursor *Cursor, backwards bool) (func(db *gorm.DB) *gorm.DB, error) {
+	// Ties are broken by the ID, in the direction of the last sort key
+	keys := append([]SortTerm{}, terms...)
+	if len(keys) == 0 || keys[len(keys)-1].Column != "id" {
+		idDesc := len(keys) > 0 && keys[len(keys)-1].Desc
+		keys = append(keys, SortTerm{Field: "id", Column: "id", Desc: idDesc})
+	}
+
+	var where string
+	var args []interface{}
+	if cursor != nil {
+		if cursor.Sort != SortString(terms) || len(cursor.Values) != len(terms) {
+			return nil, ErrInvalidCursor
+		}
+		values := make([]interface{}, 0, len(keys))
+		for i, term := range terms {
+			value, err := f.cursorValue(term.Field, cursor.Values[i])
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		values = append(values, cursor.ID)
+
+		// (a > ?) OR (a = ? AND b > ?) OR (a = ? AND b = ? AND id > ?), with > turned
+		// into < for descending keys
+		var alternatives []string
+		for i, key := range keys {
+			var parts []string
+			for j := 0; j < i; j++ {
+				parts = append(parts, keys[j].Column+" = ?")
+				args = append(args, values[j])
+			}
+			op := ">"
+			if key.Desc != backwards {
+				op = "<"
+			}
+			parts = append(parts, fmt.Sprintf("%s %s ?", key.Column, op))
+			args = append(args, values[i])
+			alternatives = append(alternatives, "("+strings.Join(parts, " AND ")+")")
+		}
+		where = strings.Join(alternatives, " OR ")
+	}
+
+	return func(db *gorm.DB) *gorm.DB {
+		if where != "" {
+			db = db.Where(where, args...)
+		}
+		for _, key := range keys {
+			db = db.Order(key.orderClause(backwards))
+		}
+		return db
+	}, nil
+}
+
+// cursorValue decodes the value of a sort key stored in a cursor
+func (f Fields) cursorValue(name string, raw json.RawMessage) (interface{}, error) {
+	var value interface{}
+	var err error
+	switch f[name].Type {
+	case FieldNumber:
+		var n int64
+		err = json.Unmarshal(raw, &n)
+		value = n
+	case FieldBool:
+		var b bool
+		err = json.Unmarshal(raw, &b)
+		value = b
+	case FieldTime:
+		var t time.Time
+		err = json.Unmarshal(raw, &t)
+		value = t
+	default:
+		var s string
+		err = json.Unmarshal(raw, &s)
+		value = s
+	}
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return value, nil
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/model"
+	"gorm.io/gorm"
+)
+
+type dataRequestRepo struct {
+	db *gorm.DB
+}
+
+// NewDataRequestRepository creates a new instance of DataRequestRepository
+func NewDataRequestRepository(db *gorm.DB) DataRequestRepository {
+	return &dataRequestRepo{db: db}
+}
+
+func (r *dataRequestRepo) Create(request *model.DataRequest) error {
+	return r.db.Create(request).Error
+}
+
+func (r *dataRequestRepo) FindByID(id uint) (*model.DataRequest, error) {
+	var request model.DataRequest
+	if err := r.db.First(&request, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &request, nil
+}
+
+// FindActive returns the user's pending or processing request of the type, if any
+func (r *dataRequestRepo) FindActive(userID uint, requestType string) (*model.DataRequest, error) {
+	var request model.DataRequest
+	err := r.db.Where("user_id = ? AND type = ? AND status IN ?", userID, requestType,
+		[]string{model.DataRequestStatusPending, model.DataRequestStatusProcessing}).
+		First(&request).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &request, nil
+}
+
+func (r *dataRequestRepo) FindAll(filter DataRequestFilter) ([]model.DataRequest, int64, error) {
+	var requests []model.DataRequest
+	var total int64
+
+	query := r.db.Model(&model.DataRequest{})
+
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.OrganizationID != 0 {
+		query = query.Where("organization_id = ?", filter.OrganizationID)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (filter.Page - 1) * filter.Limit
+	if err := query.Order("id DESC").Offset(offset).Limit(filter.Limit).Find(&requests).Error; err != nil {
+		return nil, 0, err
+	}
+	return requests, total, nil
+}
+
+// FindExportsWithFiles lists exports whose bundle is still on disk, optionally
+// only those of one user or those expired before the cutoff
+func (r *dataRequestRepo) FindExportsWithFiles(userID uint, expiredBefore *time.Time) ([]model.DataRequest, error) {
+	var requests []model.DataRequest
+	query := r.db.Where("type = ? AND file_path <> ''", model.DataRequestTypeExport)
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	if expiredBefore != nil {
+		query = query.Where("expires_at < ?", *expiredBefore)
+	}
+	err := query.Find(&requests).Error
+	return requests, err
+}
+
+// FailUnfinishedExports marks exports that are still pending or processing as failed
+func (r *dataRequestRepo) FailUnfinishedExports(reason string) (int64, error) {
+	result := r.db.Model(&model.DataRequest{}).
+		Where("type = ? AND status IN ?", model.DataRequestTypeExport,
+			[]string{model.DataRequestStatusPending, model.DataRequestStatusProcessing}).
+		Updates(map[string]interface{}{
+			"status":       model.DataRequestStatusFailed,
+			"resolution":   reason,
+			"completed_at": time.Now(),
+		})
+	return result.RowsAffected, result.Error
+}
+
+// Erase stores the anonymized user in one transaction with everything else that
+// identifies them: export file paths, security events, devices and tokens. The user
+// is soft-deleted unless already deleted; the export files are left to the caller.
+func (r *dataRequestRepo) Erase(user *model.User, email string) error {
+	version := user.Version
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.DataRequest{}).
+			Where("user_id = ? AND type = ? AND file_path <> ''", user.ID, model.DataRequestTypeExport).
+			Update("file_path", "").Error; err != nil {
+			return err
+		}
+		// Failed logins are recorded under the email without a user
+		if err := tx.Model(&model.SecurityEvent{}).
+			Where("user_id = ? OR email = ?", user.ID, email).
+			Updates(map[string]interface{}{"email": "", "ip": "", "user_agent": ""}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&model.UserDevice{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&model.Token{}).Error; err != nil {
+			return err
+		}
+		if err := updateVersioned(tx, user); err != nil {
+			return err
+		}
+		if !user.DeletedAt.Valid {
+			return deleteVersioned(tx, user)
+		}
+		return nil
+	})
+	if err != nil {
+		// The rollback also undid the version bump
+		user.Version = version
+	}
+	return err
+}
+
+func (r *dataRequestRepo) Update(request *model.DataRequest) error {
+	return r.db.Save(request).Error
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/model"
+	"gorm.io/gorm"
+)
+
+type deviceRepo struct {
+	db *gorm.DB
+}
+
+// NewDeviceRepository creates a new instance of DeviceRepository
+func NewDeviceRepository(db *gorm.DB) DeviceRepository {
+	return &deviceRepo{db: db}
+}
+
+func (r *deviceRepo) Create(device *model.UserDevice) error {
+	return r.db.Create(device).Error
+}
+
+func (r *deviceRepo) FindByFingerprint(userID uint, fingerprint string) (*model.UserDevice, error) {
+	var device model.UserDevice
+	err := r.db.Where("user_id = ? AND fingerprint = ?", userID, fingerprint).First(&device).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &device, nil
+}
+
+func (r *deviceRepo) CountByUserID(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.UserDevice{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+func (r *deviceRepo) Update(device *model.UserDevice) error {
+	return r.db.Save(device).Error
+}
+
+func (r *deviceRepo) FindByUserID(userID uint) ([]model.UserDevice, error) {
+	var devices []model.UserDevice
+	err := r.db.Where("user_id = ?", userID).Order("id ASC").Find(&devices).Error
+	return devices, err
+}
@@ -0,0 +1,310 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidFilter wraps every syntax error in a filter or sort expression
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// FieldType tells how filter values of a field are parsed
+type FieldType int
+
+const (
+	FieldString FieldType = iota
+	FieldNumber
+	FieldBool
+	FieldTime
+)
+
+// Field is a column clients may filter and sort by under a public name
+type Field struct {
+	Column string
+	Type   FieldType
+}
+
+// Fields whitelists the fields of a resource by their public name. Only listed
+// fields reach SQL, and only through their column name, so expressions from
+// clients can never inject SQL.
+type Fields map[string]Field
+
+// Without returns the fields except the named ones, along with other names for
+// their columns, e.g. to keep clients from filtering by values hidden from them
+func (f Fields) Without(names ...string) Fields {
+	hidden := map[string]bool{}
+	for _, name := range names {
+		if field, ok := f[name]; ok {
+			hidden[field.Column] = true
+		}
+	}
+	fields := make(Fields, len(f))
+	for name, field := range f {
+		if !hidden[field.Column] {
+			fields[name] = field
+		}
+	}
+	return fields
+}
+
+// Condition is one parsed clause of a filter expression
+type Condition struct {
+	Field  string
+	Column string
+	Op     string
+	Values []interface{}
+}
+
+// SortTerm is one parsed key of a sort expression
+type SortTerm struct {
+	Field  string
+	Column string
+	Desc   bool
+}
+
+// filterOperators maps the operators of the "field:op:value" form to SQL
+var filterOperators = map[string]string{
+	"eq":   "=",
+	"ne":   "<>",
+	"gt":   ">",
+	"gte":  ">=",
+	"lt":   "<",
+	"lte":  "<=",
+	"in":   "IN",
+	"nin":  "NOT IN",
+	"like": "LIKE",
+	"null": "IS NULL",
+}
+
+// filterSymbols maps the shorthand "field>=value" operators to their names, longest first
+var filterSymbols = []struct{ symbol, op string }{
+	{">=", "gte"}, {"<=", "lte"}, {"!=", "ne"}, {">", "gt"}, {"<", "lt"}, {"=", "eq"},
+}
+
+// ParseFilter parses a comma-separated list of clauses that must all match. A clause is
+// either "field:op:value" (ops: eq, ne, gt, gte, lt, lte, in, nin, like, null) or the
+// shorthand "field>=value" (>=, <=, !=, >, <, =). Values of in/nin are separated by "|".
+func (f Fields) ParseFilter(raw string) ([]Condition, error) {
+	var conditions []Condition
+	for _, clause := range strings.Split(raw, ",") {
+		clause = strings.TrimSpace(clause)
+		if clause == "" {
+			continue
+		}
+		condition, err := f.parseClause(clause)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, condition)
+	}
+	return conditions, nil
+}
+
+func (f Fields) parseClause(clause string) (Condition, error) {
+	// The field name runs up to the first character that cannot be part of it
+	end := strings.IndexFunc(clause, func(r rune) bool {
+		return !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	})
+	if end <= 0 {
+		return Condition{}, fmt.Errorf("%w: %q is not of the form field:op:value or field>=value", ErrInvalidFilter, clause)
+	}
+	name, rest := clause[:end], clause[end:]
+
+	var op, value string
+	if strings.HasPrefix(rest, ":") {
+		parts := strings.SplitN(rest[1:], ":", 2)
+		if len(parts) != 2 {
+			return Condition{}, fmt.Errorf("%w: %q is missing a value", ErrInvalidFilter, clause)
+		}
+		op, value = parts[0], parts[1]
+		if _, ok := filterOperators[op]; !ok {
+			return Condition{}, fmt.Errorf("%w: unknown operator %q in %q (allowed: %s)", ErrInvalidFilter, op, clause, strings.Join(operatorNames(), ", "))
+		}
+	} else {
+		for _, s := range filterSymbols {
+			if strings.HasPrefix(rest, s.symbol) {
+				op, value = s.op, rest[len(s.symbol):]
+				break
+			}
+		}
+		if op == "" {
+			return Condition{}, fmt.Errorf("%w: %q is not of the form field:op:value or field>=value", ErrInvalidFilter, clause)
+		}
+	}
+
+	field, err := f.lookup(name)
+	if err != nil {
+		return Condition{}, err
+	}
+	condition := Condition{Field: name, Column: field.Column, Op: op}
+
+	switch op {
+	case "null":
+		isNull, err := strconv.ParseBool(value)
+		if err != nil {
+			return Condition{}, fmt.Errorf("%w: %s:null expects true or false", ErrInvalidFilter, name)
+		}
+		condition.Values = []interface{}{isNull}
+		return condition, nil
+	case "like":
+		if field.Type != FieldString {
+			return Condition{}, fmt.Errorf("%w: like only works on text fields, not %s", ErrInvalidFilter, name)
+		}
+	case "gt", "gte", "lt", "lte":
+		if field.Type == FieldBool {
+			return Condition{}, fmt.Errorf("%w: %s cannot be compared with %s", ErrInvalidFilter, name, op)
+		}
+	}
+
+	values := []string{value}
+	if op == "in" || op == "nin" {
+		values = strings.Split(value, "|")
+	}
+	for _, v := range values {
+		parsed, err := parseFieldValue(name, field.Type, v)
+		if err != nil {
+			return Condition{}, err
+		}
+		condition.Values = append(condition.Values, parsed)
+	}
+	return condition, nil
+}
+
+// ParseSort parses a comma-separated list of "field:order" keys, e.g. "role:asc,name:desc".
+// The order defaults to asc.
+func (f Fields) ParseSort(raw string) ([]SortTerm, error) {
+	var terms []SortTerm
+	for _, key := range strings.Split(raw, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		parts := strings.SplitN(key, ":", 2)
+		field, err := f.lookup(parts[0])
+		if err != nil {
+			return nil, err
+		}
+		term := SortTerm{Field: parts[0], Column: field.Column}
+		if len(parts) == 2 {
+			switch strings.ToLower(parts[1]) {
+			case "asc":
+			case "desc":
+				term.Desc = true
+			default:
+				return nil, fmt.Errorf("%w: sort order of %s must be asc or desc", ErrInvalidFilter, parts[0])
+			}
+		}
+		terms = append(terms, term)
+	}
+	return terms, nil
+}
+
+func (f Fields) lookup(name string) (Field, error) {
+	field, ok := f[name]
+	if !ok {
+		names := make([]string, 0, len(f))
+		for n := range f {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return Field{}, fmt.Errorf("%w: unknown field %q (allowed: %s)", ErrInvalidFilter, name, strings.Join(names, ", "))
+	}
+	return field, nil
+}
+
+func parseFieldValue(name string, fieldType FieldType, raw string) (interface{}, error) {
+	switch fieldType {
+	case FieldNumber:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s expects a number, got %q", ErrInvalidFilter, name, raw)
+		}
+		return n, nil
+	case FieldBool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s expects true or false, got %q", ErrInvalidFilter, name, raw)
+		}
+		return b, nil
+	case FieldTime:
+		if t, err := time.Parse(time.RFC3339, raw); err == nil {
+			return t, nil
+		}
+		if t, err := time.Parse("2006-01-02", raw); err == nil {
+			return t, nil
+		}
+		return nil, fmt.Errorf("%w: %s expects an RFC3339 timestamp or YYYY-MM-DD date, got %q", ErrInvalidFilter, name, raw)
+	}
+	return raw, nil
+}
+
+func operatorNames() []string {
+	names := make([]string, 0, len(filterOperators))
+	for name := range filterOperators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// likeEscaper makes % and _ in a like value match themselves instead of acting as wildcards
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// FilterScope applies parsed conditions to a query
+func FilterScope(conditions []Condition) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		for _, c := range conditions {
+			switch c.Op {
+			case "null":
+				if c.Values[0].(bool) {
+					db = db.Where(c.Column + " IS NULL")
+				} else {
+					db = db.Where(c.Column + " IS NOT NULL")
+				}
+			case "in", "nin":
+				db = db.Where(fmt.Sprintf("%s %s ?", c.Column, filterOperators[c.Op]), c.Values)
+			case "like":
+				db = db.Where(c.Column+" LIKE ? ESCAPE '\\'", "%"+likeEscaper.Replace(c.Values[0].(string))+"%")
+			default:
+				db = db.Where(fmt.Sprintf("%s %s ?", c.Column, filterOperators[c.Op]), c.Values[0])
+			}
+		}
+		return db
+	}
+}
+
+// SortScope orders a query by parsed sort terms
+func SortScope(terms []SortTerm) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		for _, t := range terms {
+			db = db.Order(t.orderClause(false))
+		}
+		return db
+	}
+}
+
+// SortString renders sort terms in their canonical "field:order" form
+func SortString(terms []SortTerm) string {
+	keys := make([]string, 0, len(terms))
+	for _, t := range terms {
+		order := "asc"
+		if t.Desc {
+			order = "desc"
+		}
+		keys = append(keys, t.Field+":"+order)
+	}
+	return strings.Join(keys, ",")
+}
+
+func (t SortTerm) orderClause(reverse bool) string {
+	if t.Desc != reverse {
+		return t.Column + " DESC"
+	}
+	return t.Column + " ASC"
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/model"
+	"gorm.io/gorm"
+)
+
+func TestFilterLikeMatchesWildcardsLiterally(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	if err := db.AutoMigrate(&model.User{}); err != nil {
+		t.Fatalf("migrating: %v", err)
+	}
+
+	for _, name := range []string{"snake_case", "snakeXcase", "100% sure", "1000 sure", `back\slash`} {
+		user := &model.User{Name: name, Email: name + "@example.com", Role: "user"}
+		if err := db.Create(user).Error; err != nil {
+			t.Fatalf("creating %q: %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		filter string
+		want   []string
+	}{
+		{"name:like:e_c", []string{"snake_case"}},
+		{"name:like:0%", []string{"100% sure"}},
+		{`name:like:k\s`, []string{`back\slash`}},
+		{"name:like:sure", []string{"100% sure", "1000 sure"}},
+	}
+	for _, tt := range tests {
+		conditions, err := UserFields.ParseFilter(tt.filter)
+		if err != nil {
+			t.Fatalf("%s: parsing: %v", tt.filter, err)
+		}
+
+		var users []model.User
+		if err := db.Scopes(FilterScope(conditions)).Order("id").Find(&users).Error; err != nil {
+			t.Fatalf("%s: querying: %v", tt.filter, err)
+		}
+
+		got := make([]string, 0, len(users))
+		for _, user := range users {
+			got = append(got, user.Name)
+		}
+		if len(got) != len(tt.want) {
+			t.Fatalf("%s: got %q, want %q", tt.filter, got, tt.want)
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Fatalf("%s: got %q, want %q", tt.filter, got, tt.want)
+			}
+		}
+	}
+}
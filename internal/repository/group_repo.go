@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/model"
+	"gorm.io/gorm"
+)
+
+type groupRepo struct {
+	db *gorm.DB
+}
+
+// NewGroupRepository creates a new instance of GroupRepository
+func NewGroupRepository(db *gorm.DB) GroupRepository {
+	return &groupRepo{db: db}
+}
+
+func (r *groupRepo) Create(group *model.Group) error {
+	return r.db.Omit("Members").Create(group).Error
+}
+
+func (r *groupRepo) FindAll(organizationID uint) ([]model.Group, error) {
+	var groups []model.Group
+	err := r.db.Preload("Permissions").
+		Where("organization_id = ?", organizationID).
+		Order("id ASC").
+		Find(&groups).Error
+	return groups, err
+}
+
+func (r *groupRepo) FindByID(organizationID, id uint) (*model.Group, error) {
+	var group model.Group
+	err := r.db.Preload("Permissions").
+		Where("organization_id = ?", organizationID).
+		First(&group, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &group, nil
+}
+
+func (r *groupRepo) FindByName(organizationID uint, name string) (*model.Group, error) {
+	var group model.Group
+	err := r.db.Where("organization_id = ? AND name = ?", organizationID, name).First(&group).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &group, nil
+}
+
+// Update saves the group and replaces its permission set
+func (r *groupRepo) Update(group *model.Group) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Permissions", "Members").Save(group).Error; err != nil {
+			return err
+		}
+		return tx.Model(group).Association("Permissions").Replace(group.Permissions)
+	})
+}
+
+func (r *groupRepo) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		group := model.Group{Model: gorm.Model{ID: id}}
+		if err := tx.Model(&group).Association("Permissions").Clear(); err != nil {
+			return err
+		}
+		if err := tx.Model(&group).Association("Members").Clear(); err != nil {
+			return err
+		}
+		// Hard delete so the name can be reused
+		return tx.Unscoped().Delete(&group).Error
+	})
+}
+
+func (r *groupRepo) FindMembers(groupID uint, page, limit int) ([]model.User, int64, error) {
+	var users []model.User
+	var total int64
+
+	query := r.db.Model(&model.User{}).
+		Joins("JOIN user_groups ON user_groups.user_id = users.id").
+		Where("user_groups.group_id = ?", groupID)
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Order("users.id ASC").Offset(offset).Limit(limit).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+func (r *groupRepo) AddMember(groupID, userID uint) error {
+	group := model.Group{Model: gorm.Model{ID: groupID}}
+	return r.db.Model(&group).Association("Members").Append(&model.User{Model: gorm.Model{ID: userID}})
+}
+
+func (r *groupRepo) RemoveMember(groupID, userID uint) error {
+	group := model.Group{Model: gorm.Model{ID: groupID}}
+	return r.db.Model(&group).Association("Members").Delete(&model.User{Model: gorm.Model{ID: userID}})
+}
+
+func (r *groupRepo) IsMember(groupID, userID uint) (bool, error) {
+	var count int64
+	err := r.db.Table("user_groups").
+		Where("group_id = ? AND user_id = ?", groupID, userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *groupRepo) PermissionsOf(organizationID, userID uint) ([]string, error) {
+	var names []string
+	err := r.db.Model(&model.Permission{}).
+		Distinct("permissions.name").
+		Joins("JOIN group_permissions ON group_permissions.permission_id = permissions.id").
+		Joins("JOIN user_groups ON user_groups.group_id = group_permissions.group_id").
+		Joins("JOIN groups ON groups.id = user_groups.group_id").
+		Where("groups.organization_id = ? AND user_groups.user_id = ? AND groups.deleted_at IS NULL", organizationID, userID).
+		Order("permissions.name ASC").
+		Pluck("permissions.name", &names).Error
+	return names, err
+}
+
+func (r *groupRepo) FindByUserID(userID uint) ([]model.Group, error) {
+	var groups []model.Group
+	err := r.db.Preload("Permissions").
+		Where("id IN (?)", r.db.Table("user_groups").Select("group_id").Where("user_id = ?", userID)).
+		Order("id ASC").
+		Find(&groups).Error
+	return groups, err
+}
+
+func (r *groupRepo) FindByMembers(organizationID uint, userIDs []uint) (map[uint][]model.Group, error) {
+	var links []struct {
+		UserID  uint
+		GroupID uint
+	}
+	if err := r.db.Table("user_groups").Select("user_id, group_id").Where("user_id IN ?", userIDs).Find(&links).Error; err != nil {
+		return nil, err
+	}
+
+	groupIDs := make([]uint, 0, len(links))
+	for _, link := range links {
+		groupIDs = append(groupIDs, link.GroupID)
+	}
+	var groups []model.Group
+	if err := r.db.Preload("Permissions").
+		Where("organization_id = ? AND id IN ?", organizationID, groupIDs).
+		Order("id ASC").
+		Find(&groups).Error; err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uint]model.Group, len(groups))
+	for _, group := range groups {
+		byID[group.ID] = group
+	}
+	byUser := make(map[uint][]model.Group, len(userIDs))
+	for _, link := range links {
+		if group, ok := byID[link.GroupID]; ok {
+			byUser[link.UserID] = append(byUser[link.UserID], group)
+		}
+	}
+	return byUser, nil
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/model"
+	"gorm.io/gorm"
+)
+
+type importJobRepo struct {
+	db *gorm.DB
+}
+
+// NewImportJobRepository creates a new instance of ImportJobRepository
+func NewImportJobRepository(db *gorm.DB) ImportJobRepository {
+	return &importJobRepo{db: db}
+}
+
+func (r *importJobRepo) Create(job *model.ImportJob) error {
+	return r.db.Create(job).Error
+}
+
+func (r *importJobRepo) FindByID(organizationID, id uint) (*model.ImportJob, error) {
+	var job model.ImportJob
+	if err := r.db.Where("organization_id = ?", organizationID).First(&job, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *importJobRepo) Update(job *model.ImportJob) error {
+	return r.db.Save(job).Error
+}
+
+// FailUnfinished marks jobs that were still pending or processing as failed
+func (r *importJobRepo) FailUnfinished(reason string) (int64, error) {
+	result := r.db.Model(&model.ImportJob{}).
+		Where("status IN ?", []string{model.ImportJobStatusPending, model.ImportJobStatusProcessing}).
+		Updates(map[string]interface{}{"status": model.ImportJobStatusFailed, "error": reason})
+	return result.RowsAffected, result.Error
+}
@@ -1,7 +1,10 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/model"
+	"gorm.io/gorm"
 )
 
 // UserFilter contains all possible filters for querying users
@@ -11,7 +14,19 @@ type UserFilter struct {
 	Search string
 	Scope  string
 	Role   string
-	SortBy string
+	Status string
+	// Group restricts the results to members of the group with this ID
+	Group uint
+	// Conditions and Sort come from filter and sort expressions, see Fields.ParseFilter and Fields.ParseSort
+	Conditions []Condition
+	Sort       []SortTerm
+	// After and Before continue a keyset-paginated list from a cursor, see FindByCursor
+	After  *Cursor
+	Before *Cursor
+	// SkipCount leaves out the total, sparing a COUNT query
+	SkipCount bool
+	// Scopes restrict the query further, e.g. to the rows an access policy allows
+	Scopes []func(*gorm.DB) *gorm.DB
 }
 
 // UserRepository defines the methods for user database operations
@@ -20,9 +35,34 @@ type UserRepository interface {
 	FindByEmail(email string) (*model.User, error)
 	FindByID(id uint) (*model.User, error)
 	FindAll(filter UserFilter) ([]model.User, int64, error)
+	FindByCursor(filter UserFilter) (users []model.User, hasMore bool, err error)
+	Count(filter UserFilter) (int64, error)
+	// FindTakenEmails returns those of the emails that belong to a user, deleted ones included
+	FindTakenEmails(emails []string) ([]string, error)
+	// CreateMembers creates users as members of the organization in one transaction
+	CreateMembers(users []*model.User, organizationID uint, orgRole string) error
+	// SaveAll stores changes to users and soft-deletes others in one transaction.
+	// Like Update and Delete it fails with ErrVersionConflict for users changed since they were read.
+	SaveAll(users []*model.User, deleted []*model.User) error
+	// SearchHighlights marks the words of a search in the names and emails of the given users
+	SearchHighlights(search, scope string, ids []uint) (map[uint]map[string]string, error)
+	// Update and Delete only apply to the version the user was read at, see ErrVersionConflict
 	Update(user *model.User) error
-	Delete(id uint) error
+	Delete(user *model.User) error
+	// IsEmailTaken reports whether a user other than excludeID has the email, deleted ones included
 	IsEmailTaken(email string, excludeID uint) (bool, error)
+	Purge(id uint) error
+	// FindDeleted, FindDeletedByID and FindDeletedBefore only see soft-deleted users
+	FindDeleted(filter UserFilter) ([]model.User, int64, error)
+	FindDeletedByID(id uint) (*model.User, error)
+	FindDeletedBefore(cutoff time.Time) ([]model.User, error)
+	Restore(user *model.User) error
+	CountByRole(role string) (int64, error)
+	// WithTenant returns a repository whose lookups only see members of the organization
+	WithTenant(organizationID uint) UserRepository
+	// Global returns a repository whose lookups see the users of every organization,
+	// for work that is not done on behalf of a tenant, like logins and retention
+	Global() UserRepository
 }
 
 // TokenRepository defines the methods for token database operations
@@ -31,4 +71,133 @@ type TokenRepository interface {
 	FindOne(token string, tokenType string, blacklisted bool) (*model.Token, error)
 	Delete(token string, tokenType string) error
 	DeleteByUserID(userID uint, tokenType string) error
-}
\ No newline at end of file
+	PurgeByUserID(userID uint) error
+	FindByUserID(userID uint) ([]model.Token, error)
+	// FindActive lists the unexpired, non-blacklisted tokens of a type belonging to any of the users
+	FindActive(userIDs []uint, tokenType string) ([]model.Token, error)
+}
+// SecurityEventFilter contains all possible filters for querying security events
+type SecurityEventFilter struct {
+	Page           int
+	Limit          int
+	UserID         uint
+	OrganizationID uint // only events of the organization's members
+	Type           string
+	IP             string
+	From           *time.Time
+	To             *time.Time
+}
+
+// SecurityEventRepository defines the methods for security event database operations
+type SecurityEventRepository interface {
+	Create(event *model.SecurityEvent) error
+	FindAll(filter SecurityEventFilter) ([]model.SecurityEvent, int64, error)
+	FindByUserID(userID uint) ([]model.SecurityEvent, error)
+}
+
+// DeviceRepository defines the methods for user device database operations
+type DeviceRepository interface {
+	Create(device *model.UserDevice) error
+	FindByFingerprint(userID uint, fingerprint string) (*model.UserDevice, error)
+	CountByUserID(userID uint) (int64, error)
+	FindByUserID(userID uint) ([]model.UserDevice, error)
+	Update(device *model.UserDevice) error
+}
+
+// InvitationRepository defines the methods for invitation database operations
+type InvitationRepository interface {
+	Create(invitation *model.Invitation, orgRole string) error
+	FindPendingByID(id uint) (*model.Invitation, error)
+	FindPendingByUserID(userID uint) (*model.Invitation, error)
+	FindAllPending(organizationID uint, page, limit int) ([]model.Invitation, int64, error)
+	Update(invitation *model.Invitation) error
+	Delete(id uint) error
+}
+
+// RoleRepository defines the methods for role database operations
+type RoleRepository interface {
+	Create(role *model.Role) error
+	FindAll() ([]model.Role, error)
+	FindByID(id uint) (*model.Role, error)
+	FindByName(name string) (*model.Role, error)
+	Update(role *model.Role) error
+	Delete(id uint) error
+}
+
+// PermissionRepository defines the methods for permission database operations
+type PermissionRepository interface {
+	FindAll() ([]model.Permission, error)
+	FindByNames(names []string) ([]model.Permission, error)
+}
+
+// OrganizationRepository defines the methods for organization database operations
+type OrganizationRepository interface {
+	// Create stores the organization together with the owner's membership
+	Create(organization *model.Organization, ownerID uint) error
+	FindByID(id uint) (*model.Organization, error)
+	Update(organization *model.Organization) error
+	Delete(id uint) error
+}
+
+// MembershipRepository defines the methods for organization membership database operations
+type MembershipRepository interface {
+	Create(membership *model.Membership) error
+	Find(organizationID, userID uint) (*model.Membership, error)
+	FindByUserID(userID uint) ([]model.Membership, error)
+	FindByOrganizationID(organizationID uint, page, limit int) ([]model.Membership, int64, error)
+	Update(membership *model.Membership) error
+	Delete(organizationID, userID uint) error
+	DeleteByUserID(userID uint) error
+	CountByRole(organizationID uint, role string) (int64, error)
+}
+
+// GroupRepository defines the methods for group database operations
+type GroupRepository interface {
+	Create(group *model.Group) error
+	FindAll(organizationID uint) ([]model.Group, error)
+	FindByID(organizationID, id uint) (*model.Group, error)
+	FindByName(organizationID uint, name string) (*model.Group, error)
+	Update(group *model.Group) error
+	Delete(id uint) error
+	FindMembers(groupID uint, page, limit int) ([]model.User, int64, error)
+	AddMember(groupID, userID uint) error
+	RemoveMember(groupID, userID uint) error
+	IsMember(groupID, userID uint) (bool, error)
+	// PermissionsOf lists the permissions the user holds through groups of the organization
+	PermissionsOf(organizationID, userID uint) ([]string, error)
+	// FindByUserID lists the groups the user belongs to, across all organizations
+	FindByUserID(userID uint) ([]model.Group, error)
+	// FindByMembers lists the groups of the organization each of the users belongs to, keyed by user ID
+	FindByMembers(organizationID uint, userIDs []uint) (map[uint][]model.Group, error)
+}
+
+// DataRequestFilter contains all possible filters for querying data requests
+type DataRequestFilter struct {
+	Page           int
+	Limit          int
+	UserID         uint
+	OrganizationID uint
+	Type           string
+	Status         string
+}
+
+// DataRequestRepository defines the methods for data export and erasure request database operations
+type DataRequestRepository interface {
+	Create(request *model.DataRequest) error
+	FindByID(id uint) (*model.DataRequest, error)
+	FindActive(userID uint, requestType string) (*model.DataRequest, error)
+	FindAll(filter DataRequestFilter) ([]model.DataRequest, int64, error)
+	FindExportsWithFiles(userID uint, expiredBefore *time.Time) ([]model.DataRequest, error)
+	FailUnfinishedExports(reason string) (int64, error)
+	// Erase anonymizes and soft-deletes the user and their related records in one transaction
+	Erase(user *model.User, email string) error
+	Update(request *model.DataRequest) error
+}
+
+// ImportJobRepository defines the methods for user import job database operations
+type ImportJobRepository interface {
+	Create(job *model.ImportJob) error
+	FindByID(organizationID, id uint) (*model.ImportJob, error)
+	Update(job *model.ImportJob) error
+	FailUnfinished(reason string) (int64, error)
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/model"
+	"gorm.io/gorm"
+)
+
+type invitationRepo struct {
+	db *gorm.DB
+}
+
+// NewInvitationRepository creates a new instance of InvitationRepository
+func NewInvitationRepository(db *gorm.DB) InvitationRepository {
+	return &invitationRepo{db: db}
+}
+
+// Create stores the invited user held in invitation.User, their membership of the
+// invitation's organization with orgRole, and the invitation, all or none of them
+func (r *invitationRepo) Create(invitation *model.Invitation, orgRole string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&invitation.User).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&model.Membership{
+			OrganizationID: invitation.OrganizationID,
+			UserID:         invitation.User.ID,
+			Role:           orgRole,
+		}).Error; err != nil {
+			return err
+		}
+		invitation.UserID = invitation.User.ID
+		return tx.Omit("User", "InvitedBy").Create(invitation).Error
+	})
+}
+
+func (r *invitationRepo) pending() *gorm.DB {
+	return r.db.Preload("User").Where("accepted_at IS NULL")
+}
+
+func (r *invitationRepo) FindPendingByID(id uint) (*model.Invitation, error) {
+	var invitation model.Invitation
+	if err := r.pending().First(&invitation, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (r *invitationRepo) FindPendingByUserID(userID uint) (*model.Invitation, error) {
+	var invitation model.Invitation
+	if err := r.pending().Where("user_id = ?", userID).First(&invitation).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (r *invitationRepo) FindAllPending(organizationID uint, page, limit int) ([]model.Invitation, int64, error) {
+	var invitations []model.Invitation
+	var total int64
+
+	err := r.db.Model(&model.Invitation{}).
+		Where("accepted_at IS NULL AND organization_id = ?", organizationID).
+		Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err = r.pending().Where("organization_id = ?", organizationID).
+		Order("id DESC").Offset(offset).Limit(limit).Find(&invitations).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return invitations, total, nil
+}
+
+func (r *invitationRepo) Update(invitation *model.Invitation) error {
+	return r.db.Omit("User", "InvitedBy").Save(invitation).Error
+}
+
+func (r *invitationRepo) Delete(id uint) error {
+	return r.db.Unscoped().Delete(&model.Invitation{}, id).Error
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/model"
+	"gorm.io/gorm"
+)
+
+type membershipRepo struct {
+	db *gorm.DB
+}
+
+// NewMembershipRepository creates a new instance of MembershipRepository
+func NewMembershipRepository(db *gorm.DB) MembershipRepository {
+	return &membershipRepo{db: db}
+}
+
+func (r *membershipRepo) Create(membership *model.Membership) error {
+	return r.db.Omit("Organization", "User").Create(membership).Error
+}
+
+func (r *membershipRepo) Find(organizationID, userID uint) (*model.Membership, error) {
+	var membership model.Membership
+	err := r.db.Preload("Organization").
+		Where("organization_id = ? AND user_id = ?", organizationID, userID).
+		First(&membership).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &membership, nil
+}
+
+// FindByUserID lists the user's memberships in organizations that still exist, oldest first
+func (r *membershipRepo) FindByUserID(userID uint) ([]model.Membership, error) {
+	var memberships []model.Membership
+	err := r.db.Joins("Organization").
+		Where("memberships.user_id = ?", userID).
+		Order("memberships.id ASC").
+		Find(&memberships).Error
+	return memberships, err
+}
+
+func (r *membershipRepo) FindByOrganizationID(organizationID uint, page, limit int) ([]model.Membership, int64, error) {
+	var memberships []model.Membership
+	var total int64
+
+	query := r.db.Model(&model.Membership{}).
+		Joins("User").
+		Where("memberships.organization_id = ?", organizationID)
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Order("memberships.id ASC").Offset(offset).Limit(limit).Find(&memberships).Error; err != nil {
+		return nil, 0, err
+	}
+	return memberships, total, nil
+}
+
+func (r *membershipRepo) Update(membership *model.Membership) error {
+	return r.db.Omit("Organization", "User").Save(membership).Error
+}
+
+// Delete removes the user from the organization and from its groups
+func (r *membershipRepo) Delete(organizationID, userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		groups := tx.Model(&model.Group{}).Select("id").Where("organization_id = ?", organizationID)
+		if err := tx.Table("user_groups").
+			Where("user_id = ? AND group_id IN (?)", userID, groups).
+			Delete(nil).Error; err != nil {
+			return err
+		}
+		return tx.Where("organization_id = ? AND user_id = ?", organizationID, userID).
+			Delete(&model.Membership{}).Error
+	})
+}
+
+// DeleteByUserID removes the user from every organization and group
+func (r *membershipRepo) DeleteByUserID(userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table("user_groups").Where("user_id = ?", userID).Delete(nil).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&model.Membership{}).Error
+	})
+}
+
+func (r *membershipRepo) CountByRole(organizationID uint, role string) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.Membership{}).
+		Where("organization_id = ? AND role = ?", organizationID, role).
+		Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/model"
+	"gorm.io/gorm"
+)
+
+type organizationRepo struct {
+	db *gorm.DB
+}
+
+// NewOrganizationRepository creates a new instance of OrganizationRepository
+func NewOrganizationRepository(db *gorm.DB) OrganizationRepository {
+	return &organizationRepo{db: db}
+}
+
+func (r *organizationRepo) Create(organization *model.Organization, ownerID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(organization).Error; err != nil {
+			return err
+		}
+		return tx.Create(&model.Membership{
+			OrganizationID: organization.ID,
+			UserID:         ownerID,
+			Role:           model.OrgRoleOwner,
+		}).Error
+	})
+}
+
+func (r *organizationRepo) FindByID(id uint) (*model.Organization, error) {
+	var organization model.Organization
+	if err := r.db.First(&organization, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &organization, nil
+}
+
+func (r *organizationRepo) Update(organization *model.Organization) error {
+	return r.db.Save(organization).Error
+}
+
+// Delete removes the organization with all of its memberships and groups
+func (r *organizationRepo) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		groups := tx.Model(&model.Group{}).Select("id").Where("organization_id = ?", id)
+		for _, table := range []string{"user_groups", "group_permissions"} {
+			if err := tx.Table(table).Where("group_id IN (?)", groups).Delete(nil).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Unscoped().Where("organization_id = ?", id).Delete(&model.Group{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("organization_id = ?", id).Delete(&model.Membership{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.Organization{}, id).Error
+	})
+}
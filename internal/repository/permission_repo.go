@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/model"
+	"gorm.io/gorm"
+)
+
+type permissionRepo struct {
+	db *gorm.DB
+}
+
+// NewPermissionRepository creates a new instance of PermissionRepository
+func NewPermissionRepository(db *gorm.DB) PermissionRepository {
+	return &permissionRepo{db: db}
+}
+
+func (r *permissionRepo) FindAll() ([]model.Permission, error) {
+	var permissions []model.Permission
+	err := r.db.Order("name ASC").Find(&permissions).Error
+	return permissions, err
+}
+
+func (r *permissionRepo) FindByNames(names []string) ([]model.Permission, error) {
+	var permissions []model.Permission
+	if len(names) == 0 {
+		return permissions, nil
+	}
+	err := r.db.Where("name IN ?", names).Find(&permissions).Error
+	return permissions, err
+}
@@ -0,0 +1,350 @@
+package repository
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TextSearch is a full-text index over text columns of a table. The database keeps
+// it in sync on every insert, update and delete, so writers need not know about it.
+type TextSearch interface {
+	// Migrate creates the index if needed and fills it with the rows already in the table
+	Migrate() error
+	// Match is a condition for rows whose columns contain every word of term as a
+	// word prefix. Without columns, all indexed columns are searched.
+	Match(term string, columns ...string) clause.Expression
+	// Rank orders a query restricted by Match by relevance, best matches first, then
+	// by ID. It must be the only order, as GORM drops orders merged with an expression.
+	Rank(term string, columns ...string) func(db *gorm.DB) *gorm.DB
+	// Highlight returns the columns of the given rows, HTML-escaped and with matches
+	// wrapped in <mark> tags, keyed by row ID and column
+	Highlight(term string, ids []uint, columns ...string) (map[uint]map[string]string, error)
+}
+
+// NewTextSearch returns the full-text index of table over columns for the database
+// behind db: FTS5 for SQLite and a tsvector column for Postgres. Other databases
+// fall back to case-insensitive LIKE without ranking or highlighting.
+func NewTextSearch(db *gorm.DB, table string, columns ...string) TextSearch {
+	switch db.Dialector.Name() {
+	case "sqlite":
+		return &sqliteSearch{db: db, table: table, columns: columns}
+	case "postgres":
+		return &postgresSearch{db: db, table: table, columns: columns}
+	}
+	return &likeSearch{columns: columns}
+}
+
+// Highlighted matches are marked with private-use characters by the database, so
+// the text can be escaped before the markers are turned into tags
+const (
+	markStart = "\ue000"
+	markEnd   = "\ue001"
+)
+
+func markHighlight(text string) string {
+	text = html.EscapeString(text)
+	return strings.NewReplacer(markStart, "<mark>", markEnd, "</mark>").Replace(text)
+}
+
+// searchWords splits a search term into the words the indexes tokenize text into.
+// Everything else is dropped, so the words are safe inside query syntax.
+func searchWords(term string) []string {
+	return strings.FieldsFunc(strings.ToLower(term), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+var matchNothing = clause.Expr{SQL: "1 = 0"}
+
+// --- SQLite: FTS5 external content table ---
+
+type sqliteSearch struct {
+	db      *gorm.DB
+	table   string
+	columns []string
+}
+
+func (s *sqliteSearch) index() string {
+	return s.table + "_fts"
+}
+
+func (s *sqliteSearch) Migrate() error {
+	var existing int64
+	if err := s.db.Raw("SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = ?", s.index()).Scan(&existing).Error; err != nil {
+		return err
+	}
+
+	cols := strings.Join(s.columns, ", ")
+	newCols := "new." + strings.Join(s.columns, ", new.")
+	oldCols := "old." + strings.Join(s.columns, ", old.")
+	statements := []string{
+		fmt.Sprintf("CREATE VIRTUAL TABLE IF NOT EXISTS %s USING fts5(%s, content='%s', content_rowid='id')", s.index(), cols, s.table),
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS %[1]s_insert AFTER INSERT ON %[2]s BEGIN
+			INSERT INTO %[1]s(rowid, %[3]s) VALUES (new.id, %[4]s);
+		END`, s.index(), s.table, cols, newCols),
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS %[1]s_delete AFTER DELETE ON %[2]s BEGIN
+			INSERT INTO %[1]s(%[1]s, rowid, %[3]s) VALUES ('delete', old.id, %[4]s);
+		END`, s.index(), s.table, cols, oldCols),
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS %[1]s_update AFTER UPDATE OF %[3]s ON %[2]s BEGIN
+			INSERT INTO %[1]s(%[1]s, rowid, %[3]s) VALUES ('delete', old.id, %[4]s);
+			INSERT INTO %[1]s(rowid, %[3]s) VALUES (new.id, %[5]s);
+		END`, s.index(), s.table, cols, oldCols, newCols),
+	}
+	// Rows written before the index existed are indexed once
+	if existing == 0 {
+		statements = append(statements, fmt.Sprintf("INSERT INTO %[1]s(%[1]s) VALUES ('rebuild')", s.index()))
+	}
+
+	for _, statement := range statements {
+		if err := s.db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// query builds an FTS5 query matching every word as a prefix in the given columns
+func (s *sqliteSearch) query(term string, columns []string) string {
+	if len(columns) == 0 {
+		columns = s.columns
+	}
+	words := searchWords(term)
+	if len(words) == 0 {
+		return ""
+	}
+	filter := "{" + strings.Join(columns, " ") + "} : "
+	phrases := make([]string, 0, len(words))
+	for _, word := range words {
+		phrases = append(phrases, filter+`"`+word+`"*`)
+	}
+	return strings.Join(phrases, " AND ")
+}
+
+func (s *sqliteSearch) Match(term string, columns ...string) clause.Expression {
+	query := s.query(term, columns)
+	if query == "" {
+		return matchNothing
+	}
+	return clause.Expr{
+		SQL:  fmt.Sprintf("%[1]s.id IN (SELECT rowid FROM %[2]s WHERE %[2]s MATCH ?)", s.table, s.index()),
+		Vars: []interface{}{query},
+	}
+}
+
+func (s *sqliteSearch) Rank(term string, columns ...string) func(db *gorm.DB) *gorm.DB {
+	query := s.query(term, columns)
+	return func(db *gorm.DB) *gorm.DB {
+		if query == "" {
+			return db.Order(s.table + ".id")
+		}
+		// bm25 scores are lower for better matches
+		return db.Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL:                fmt.Sprintf("(SELECT bm25(%[1]s) FROM %[1]s WHERE %[1]s MATCH ? AND %[1]s.rowid = %[2]s.id), %[2]s.id", s.index(), s.table),
+			Vars:               []interface{}{query},
+			WithoutParentheses: true,
+		}})
+	}
+}
+
+func (s *sqliteSearch) Highlight(term string, ids []uint, columns ...string) (map[uint]map[string]string, error) {
+	if len(columns) == 0 {
+		columns = s.columns
+	}
+	query := s.query(term, columns)
+	if query == "" || len(ids) == 0 {
+		return map[uint]map[string]string{}, nil
+	}
+
+	selects := []string{"rowid AS id"}
+	var args []interface{}
+	for i, column := range s.columns {
+		if contains(columns, column) {
+			selects = append(selects, fmt.Sprintf("highlight(%s, %d, ?, ?) AS %s", s.index(), i, column))
+			args = append(args, markStart, markEnd)
+		}
+	}
+	args = append(args, query, ids)
+
+	var rows []map[string]interface{}
+	sql := fmt.Sprintf("SELECT %s FROM %s WHERE %s MATCH ? AND rowid IN ?", strings.Join(selects, ", "), s.index(), s.index())
+	if err := s.db.Raw(sql, args...).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	return highlightRows(rows, columns), nil
+}
+
+// --- Postgres: generated tsvector column with a GIN index ---
+
+type postgresSearch struct {
+	db      *gorm.DB
+	table   string
+	columns []string
+}
+
+// postgresWeights label the indexed columns so queries can be restricted to some of them
+var postgresWeights = []string{"A", "B", "C", "D"}
+
+func (s *postgresSearch) Migrate() error {
+	if len(s.columns) > len(postgresWeights) {
+		return fmt.Errorf("postgres search supports at most %d columns", len(postgresWeights))
+	}
+
+	// Punctuation is replaced by spaces so e-mail addresses are indexed word by word
+	vectors := make([]string, 0, len(s.columns))
+	for i, column := range s.columns {
+		vectors = append(vectors, fmt.Sprintf(
+			"setweight(to_tsvector('simple'::regconfig, regexp_replace(coalesce(%s, ''), '[^[:alnum:]]+', ' ', 'g')), '%s')",
+			column, postgresWeights[i]))
+	}
+	statements := []string{
+		fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (%s) STORED",
+			s.table, strings.Join(vectors, " || ")),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_%[1]s_search_vector ON %[1]s USING GIN (search_vector)", s.table),
+	}
+	for _, statement := range statements {
+		if err := s.db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// query builds a tsquery matching every word as a prefix in the given columns
+func (s *postgresSearch) query(term string, columns []string) string {
+	var weights string
+	for i, column := range s.columns {
+		if len(columns) == 0 || contains(columns, column) {
+			weights += postgresWeights[i]
+		}
+	}
+	return prefixTSQuery(term, weights)
+}
+
+// prefixTSQuery builds a tsquery matching every word of term as a prefix,
+// restricted to lexemes of the given weights if any
+func prefixTSQuery(term, weights string) string {
+	words := searchWords(term)
+	lexemes := make([]string, 0, len(words))
+	for _, word := range words {
+		lexemes = append(lexemes, word+":*"+weights)
+	}
+	return strings.Join(lexemes, " & ")
+}
+
+func (s *postgresSearch) Match(term string, columns ...string) clause.Expression {
+	query := s.query(term, columns)
+	if query == "" {
+		return matchNothing
+	}
+	return clause.Expr{
+		SQL:  s.table + ".search_vector @@ to_tsquery('simple', ?)",
+		Vars: []interface{}{query},
+	}
+}
+
+func (s *postgresSearch) Rank(term string, columns ...string) func(db *gorm.DB) *gorm.DB {
+	query := s.query(term, columns)
+	return func(db *gorm.DB) *gorm.DB {
+		if query == "" {
+			return db.Order(s.table + ".id")
+		}
+		return db.Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "ts_rank(" + s.table + ".search_vector, to_tsquery('simple', ?)) DESC, " + s.table + ".id",
+			Vars:               []interface{}{query},
+			WithoutParentheses: true,
+		}})
+	}
+}
+
+func (s *postgresSearch) Highlight(term string, ids []uint, columns ...string) (map[uint]map[string]string, error) {
+	if len(columns) == 0 {
+		columns = s.columns
+	}
+	// Headlines are computed on the raw text, which has no weights
+	query := prefixTSQuery(term, "")
+	if query == "" || len(ids) == 0 {
+		return map[uint]map[string]string{}, nil
+	}
+
+	options := fmt.Sprintf(`StartSel="%s", StopSel="%s", HighlightAll=true`, markStart, markEnd)
+	selects := []string{"id"}
+	var args []interface{}
+	for _, column := range columns {
+		selects = append(selects, fmt.Sprintf("ts_headline('simple', coalesce(%[1]s, ''), to_tsquery('simple', ?), ?) AS %[1]s", column))
+		args = append(args, query, options)
+	}
+	args = append(args, ids)
+
+	var rows []map[string]interface{}
+	sql := fmt.Sprintf("SELECT %s FROM %s WHERE id IN ?", strings.Join(selects, ", "), s.table)
+	if err := s.db.Raw(sql, args...).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	return highlightRows(rows, columns), nil
+}
+
+// --- Fallback: LIKE ---
+
+type likeSearch struct {
+	columns []string
+}
+
+func (s *likeSearch) Migrate() error {
+	return nil
+}
+
+func (s *likeSearch) Match(term string, columns ...string) clause.Expression {
+	if len(columns) == 0 {
+		columns = s.columns
+	}
+	conditions := make([]string, 0, len(columns))
+	args := make([]interface{}, 0, len(columns))
+	for _, column := range columns {
+		conditions = append(conditions, "LOWER("+column+") LIKE ?")
+		args = append(args, "%"+strings.ToLower(term)+"%")
+	}
+	return clause.Expr{SQL: "(" + strings.Join(conditions, " OR ") + ")", Vars: args}
+}
+
+func (s *likeSearch) Rank(term string, columns ...string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}
+}
+
+func (s *likeSearch) Highlight(term string, ids []uint, columns ...string) (map[uint]map[string]string, error) {
+	return map[uint]map[string]string{}, nil
+}
+
+// highlightRows converts raw highlight rows into marked-up text keyed by row ID
+func highlightRows(rows []map[string]interface{}, columns []string) map[uint]map[string]string {
+	highlights := make(map[uint]map[string]string, len(rows))
+	for _, row := range rows {
+		id, ok := row["id"].(int64)
+		if !ok {
+			continue
+		}
+		fields := map[string]string{}
+		for _, column := range columns {
+			if text, ok := row[column].(string); ok && strings.Contains(text, markStart) {
+				fields[column] = markHighlight(text)
+			}
+		}
+		highlights[uint(id)] = fields
+	}
+	return highlights
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/model"
+	"gorm.io/gorm"
+)
+
+type securityEventRepo struct {
+	db *gorm.DB
+}
+
+// NewSecurityEventRepository creates a new instance of SecurityEventRepository
+func NewSecurityEventRepository(db *gorm.DB) SecurityEventRepository {
+	return &securityEventRepo{db: db}
+}
+
+func (r *securityEventRepo) Create(event *model.SecurityEvent) error {
+	return r.db.Create(event).Error
+}
+
+func (r *securityEventRepo) FindAll(filter SecurityEventFilter) ([]model.SecurityEvent, int64, error) {
+	var events []model.SecurityEvent
+	var total int64
+
+	query := r.db.Model(&model.SecurityEvent{})
+
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.OrganizationID != 0 {
+		query = query.Scopes(membersScope(filter.OrganizationID, "user_id"))
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.IP != "" {
+		query = query.Where("ip = ?", filter.IP)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at <= ?", *filter.To)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Newest first, so "last login" is always the first row
+	offset := (filter.Page - 1) * filter.Limit
+	err := query.Order("created_at DESC").Order("id DESC").
+		Offset(offset).Limit(filter.Limit).Find(&events).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return events, total, nil
+}
+
+func (r *securityEventRepo) FindByUserID(userID uint) ([]model.SecurityEvent, error) {
+	var events []model.SecurityEvent
+	err := r.db.Where("user_id = ?", userID).Order("id ASC").Find(&events).Error
+	return events, err
+}
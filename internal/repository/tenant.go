@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/model"
+	"gorm.io/gorm"
+)
+
+// ErrTenantRequired is returned by repositories asked to look up users before they were
+// restricted to an organization or explicitly opened to all of them
+var ErrTenantRequired = errors.New("user lookup without an organization; use WithTenant or Global")
+
+// TenantScope restricts a users query to the members of an organization
+func TenantScope(organizationID uint) func(db *gorm.DB) *gorm.DB {
+	return membersScope(organizationID, "users.id")
+}
+
+// membersScope restricts rows whose column holds a user ID to the members of an organization
+func membersScope(organizationID uint, column string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		members := db.Session(&gorm.Session{NewDB: true}).
+			Model(&model.Membership{}).
+			Select("user_id").
+			Where("organization_id = ?", organizationID)
+		return db.Where(column+" IN (?)", members)
+	}
+}
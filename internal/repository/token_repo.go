@@ -2,6 +2,7 @@ package repository
 
 import (
 	"errors"
+	"time"
 
 	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/model"
 	"gorm.io/gorm"
@@ -38,4 +39,23 @@ func (r *tokenRepo) Delete(tokenString string, tokenType string) error {
 
 func (r *tokenRepo) DeleteByUserID(userID uint, tokenType string) error {
 	return r.db.Where("user_id = ? AND type = ?", userID, tokenType).Delete(&model.Token{}).Error
-}
\ No newline at end of file
+}
+
+// PurgeByUserID permanently removes every token of the user, including deleted ones
+func (r *tokenRepo) PurgeByUserID(userID uint) error {
+	return r.db.Unscoped().Where("user_id = ?", userID).Delete(&model.Token{}).Error
+}
+
+func (r *tokenRepo) FindByUserID(userID uint) ([]model.Token, error) {
+	var tokens []model.Token
+	err := r.db.Where("user_id = ?", userID).Order("id ASC").Find(&tokens).Error
+	return tokens, err
+}
+
+func (r *tokenRepo) FindActive(userIDs []uint, tokenType string) ([]model.Token, error) {
+	var tokens []model.Token
+	err := r.db.Where("user_id IN ? AND type = ? AND blacklisted = ? AND expires > ?", userIDs, tokenType, false, time.Now()).
+		Order("id ASC").
+		Find(&tokens).Error
+	return tokens, err
+}
@@ -2,21 +2,37 @@ package repository
 
 import (
 	"errors"
-	"fmt"
 	"strconv"
-	"strings"
+	"time"
 
 	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrVersionConflict is returned when a user changed after it was read, so
+// storing the copy at hand would overwrite someone else's change
+var ErrVersionConflict = errors.New("the user was changed since it was read; reload it and try again")
+
 type userRepo struct {
-	db *gorm.DB
+	db     *gorm.DB
+	search TextSearch
+	// tenantID restricts lookups to members of an organization when scoped is set.
+	// Lookups fail with ErrTenantRequired unless the repository is scoped or global.
+	tenantID uint
+	scoped   bool
+	global   bool
 }
 
-// NewUserRepository creates a new instance of UserRepository
+// NewUserRepository creates a new instance of UserRepository. Its lookups need
+// WithTenant or Global to decide which users they may see.
 func NewUserRepository(db *gorm.DB) UserRepository {
-	return &userRepo{db: db}
+	return &userRepo{db: db, search: UserSearch(db)}
+}
+
+// UserSearch is the full-text index over user names and emails
+func UserSearch(db *gorm.DB) TextSearch {
+	return NewTextSearch(db, "users", "name", "email")
 }
 
 func (r *userRepo) Create(user *model.User) error {
@@ -25,7 +41,7 @@ func (r *userRepo) Create(user *model.User) error {
 
 func (r *userRepo) FindByEmail(email string) (*model.User, error) {
 	var user model.User
-	if err := r.db.Where("email = ?", email).First(&user).Error; err != nil {
+	if err := r.query().Where("email = ?", email).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil // Return nil if not found, let service handle 404
 		}
@@ -36,7 +52,7 @@ func (r *userRepo) FindByEmail(email string) (*model.User, error) {
 
 func (r *userRepo) FindByID(id uint) (*model.User, error) {
 	var user model.User
-	if err := r.db.First(&user, id).Error; err != nil {
+	if err := r.query().First(&user, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
@@ -49,17 +65,85 @@ func (r *userRepo) FindAll(filter UserFilter) ([]model.User, int64, error) {
 	var users []model.User
 	var total int64
 
+	query := r.filtered(filter)
+
+	// 3. Sorting, by relevance when searching unless asked otherwise. The scopes are
+	// applied right away because Count would drop orders added by pending scopes.
+	if len(filter.Sort) > 0 {
+		query = SortScope(filter.Sort)(query)
+	} else if filter.Search != "" && filter.Scope != "id" {
+		query = r.search.Rank(filter.Search, searchColumns(filter.Scope)...)(query)
+	} else {
+		// Default sort
+		query = query.Order("id ASC")
+	}
+
+	// Count total records (before pagination)
+	if !filter.SkipCount {
+		if err := query.Count(&total).Error; err != nil {
+			return nil, 0, err
+		}
+	}
+
+	// 4. Pagination
+	offset := (filter.Page - 1) * filter.Limit
+	if err := query.Offset(offset).Limit(filter.Limit).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
+// FindByCursor pages through users by keyset: rows are located by the sort columns
+// and ID of the cursor row instead of an offset, so pages stay stable while rows
+// are added or removed. hasMore reports whether rows exist beyond the page in the
+// direction of travel.
+func (r *userRepo) FindByCursor(filter UserFilter) ([]model.User, bool, error) {
+	var users []model.User
+
+	cursor, backwards := filter.After, false
+	if filter.Before != nil {
+		cursor, backwards = filter.Before, true
+	}
+	keyset, err := UserFields.KeysetScope(filter.Sort, cursor, backwards)
+	if err != nil {
+		return nil, false, err
+	}
+	query := r.filtered(filter).Scopes(keyset)
+
+	// Fetch one extra row to learn whether another page follows
+	if err := query.Limit(filter.Limit + 1).Find(&users).Error; err != nil {
+		return nil, false, err
+	}
+	hasMore := len(users) > filter.Limit
+	if hasMore {
+		users = users[:filter.Limit]
+	}
+
+	// Rows before the cursor were read in reverse; restore the requested order
+	if backwards {
+		for i, j := 0, len(users)-1; i < j; i, j = i+1, j-1 {
+			users[i], users[j] = users[j], users[i]
+		}
+	}
+	return users, hasMore, nil
+}
+
+// Count returns how many users match the filter; pagination fields are ignored
+func (r *userRepo) Count(filter UserFilter) (int64, error) {
+	var total int64
+	err := r.filtered(filter).Count(&total).Error
+	return total, err
+}
+
+// filtered starts a users query with the search and filters applied
+func (r *userRepo) filtered(filter UserFilter) *gorm.DB {
 	// Start query construction
-	query := r.db.Model(&model.User{})
+	query := r.query().Model(&model.User{}).Scopes(filter.Scopes...)
 
 	// 1. Search Logic
 	if filter.Search != "" {
-		searchLike := "%" + filter.Search + "%"
 		switch filter.Scope {
-		case "name":
-			query = query.Where("name LIKE ?", searchLike)
-		case "email":
-			query = query.Where("email LIKE ?", searchLike)
 		case "id":
 			// Exact match for ID
 			if id, err := strconv.Atoi(filter.Search); err == nil {
@@ -68,88 +152,135 @@ func (r *userRepo) FindAll(filter UserFilter) ([]model.User, int64, error) {
 				// If scope is ID but value is not a number, return empty or fail safe
 				query = query.Where("1 = 0")
 			}
-		default: // "all" or empty
-			// Search in Name, Email, or ID
-			sql := "name LIKE ? OR email LIKE ?"
-			args := []interface{}{searchLike, searchLike}
-			
-			// If search term is numeric, include ID search
-			if id, err := strconv.Atoi(filter.Search); err == nil {
-				sql += " OR id = ?"
-				args = append(args, id)
+		default: // "all", "name", "email" or empty
+			match := r.search.Match(filter.Search, searchColumns(filter.Scope)...)
+
+			// If search term is numeric and all fields are searched, include ID search
+			if id, err := strconv.Atoi(filter.Search); err == nil && filter.Scope != "name" && filter.Scope != "email" {
+				match = clause.Or(match, clause.Eq{Column: clause.Column{Table: "users", Name: "id"}, Value: id})
 			}
-			query = query.Where(sql, args...)
+			query = query.Where(match)
 		}
 	}
 
-	// 2. Filter by Role
+	// 2. Filter by Role, Status, Group and filter expressions
 	if filter.Role != "" {
 		query = query.Where("role = ?", filter.Role)
 	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Group != 0 {
+		query = query.Where("id IN (?)", r.db.Table("user_groups").Select("user_id").Where("group_id = ?", filter.Group))
+	}
+	if len(filter.Conditions) > 0 {
+		query = query.Scopes(FilterScope(filter.Conditions))
+	}
 
-	// 3. Sorting
-	if filter.SortBy != "" {
-		sortParams := strings.Split(filter.SortBy, ":")
-		if len(sortParams) == 2 {
-			field := sortParams[0]
-			order := strings.ToUpper(sortParams[1])
-			
-			// Validate Order
-			if order != "ASC" && order != "DESC" {
-				order = "ASC"
-			}
-
-			// Validate Field (Whitelisting to prevent SQL injection)
-			allowedFields := map[string]string{
-				"id":         "id",
-				"name":       "name",
-				"email":      "email",
-				"role":       "role",
-				"created_at": "created_at",
-			}
+	return query
+}
 
-			if dbField, ok := allowedFields[field]; ok {
-				query = query.Order(fmt.Sprintf("%s %s", dbField, order))
-			}
-		}
-	} else {
-		// Default sort
-		query = query.Order("id ASC")
+// searchColumns maps a search scope to the indexed columns it searches; nil searches all
+func searchColumns(scope string) []string {
+	if scope == "name" || scope == "email" {
+		return []string{scope}
 	}
+	return nil
+}
 
-	// Count total records (before pagination)
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
+// SearchHighlights returns the name and email of the given users with the words
+// matching the search marked, for the columns the scope searches
+func (r *userRepo) SearchHighlights(search, scope string, ids []uint) (map[uint]map[string]string, error) {
+	if scope == "id" {
+		return map[uint]map[string]string{}, nil
 	}
+	return r.search.Highlight(search, ids, searchColumns(scope)...)
+}
 
-	// 4. Pagination
-	offset := (filter.Page - 1) * filter.Limit
-	if err := query.Offset(offset).Limit(filter.Limit).Find(&users).Error; err != nil {
-		return nil, 0, err
-	}
+// UserFields whitelists the user fields clients may filter and sort by
+var UserFields = Fields{
+	"id":              {Column: "id", Type: FieldNumber},
+	"name":            {Column: "name", Type: FieldString},
+	"email":           {Column: "email", Type: FieldString},
+	"role":            {Column: "role", Type: FieldString},
+	"status":          {Column: "status", Type: FieldString},
+	"isEmailVerified": {Column: "is_email_verified", Type: FieldBool},
+	"createdAt":       {Column: "created_at", Type: FieldTime},
+	"updatedAt":       {Column: "updated_at", Type: FieldTime},
+	// created_at is the name sortBy used before filter expressions existed
+	"created_at": {Column: "created_at", Type: FieldTime},
+}
 
-	return users, total, nil
+// UserCursor returns the cursor of a user in a list sorted by terms,
+// for continuing with the rows after or before it
+func UserCursor(user *model.User, terms []SortTerm) *Cursor {
+	values := make([]interface{}, 0, len(terms))
+	for _, term := range terms {
+		var value interface{}
+		switch term.Column {
+		case "id":
+			value = user.ID
+		case "name":
+			value = user.Name
+		case "email":
+			value = user.Email
+		case "role":
+			value = user.Role
+		case "status":
+			value = user.Status
+		case "is_email_verified":
+			value = user.IsEmailVerified
+		case "created_at":
+			value = user.CreatedAt
+		case "updated_at":
+			value = user.UpdatedAt
+		}
+		values = append(values, value)
+	}
+	return NewCursor(terms, values, user.ID)
 }
 
 func (r *userRepo) Update(user *model.User) error {
-	return r.db.Save(user).Error
+	return updateVersioned(r.query(), user)
+}
+
+// updateVersioned saves the whole user if the row is still at the version the user
+// was read at, and moves it to the next version. Soft-deleted users can be saved too.
+func updateVersioned(db *gorm.DB, user *model.User) error {
+	version := user.Version
+	user.Version++
+	result := db.Unscoped().Model(user).Where("version = ?", version).Select("*").Updates(user)
+	if result.Error == nil && result.RowsAffected == 0 {
+		result.Error = ErrVersionConflict
+	}
+	if result.Error != nil {
+		user.Version = version
+	}
+	return result.Error
+}
+
+func (r *userRepo) Delete(user *model.User) error {
+	return deleteVersioned(r.query(), user)
 }
 
-func (r *userRepo) Delete(id uint) error {
-	result := r.db.Delete(&model.User{}, id)
+// deleteVersioned soft-deletes the user if the row is still at the version the
+// user was read at
+func deleteVersioned(db *gorm.DB, user *model.User) error {
+	result := db.Where("version = ?", user.Version).Delete(&model.User{}, user.ID)
 	if result.Error != nil {
 		return result.Error
 	}
 	if result.RowsAffected == 0 {
-		return errors.New("user not found")
+		return ErrVersionConflict
 	}
 	return nil
 }
 
 func (r *userRepo) IsEmailTaken(email string, excludeID uint) (bool, error) {
 	var count int64
-	query := r.db.Model(&model.User{}).Where("email = ?", email)
-	
+	// Deleted users keep their email until they are purged, so it is still taken
+	query := r.query().Unscoped().Model(&model.User{}).Where("email = ?", email)
+
 	if excludeID != 0 {
 		query = query.Where("id != ?", excludeID)
 	}
@@ -159,4 +290,148 @@ func (r *userRepo) IsEmailTaken(email string, excludeID uint) (bool, error) {
 		return false, err
 	}
 	return count > 0, nil
-}
\ No newline at end of file
+}
+
+func (r *userRepo) FindTakenEmails(emails []string) ([]string, error) {
+	var taken []string
+	if len(emails) == 0 {
+		return taken, nil
+	}
+	err := r.query().Unscoped().Model(&model.User{}).Where("email IN ?", emails).Pluck("email", &taken).Error
+	return taken, err
+}
+
+func (r *userRepo) CreateMembers(users []*model.User, organizationID uint, orgRole string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(users).Error; err != nil {
+			return err
+		}
+		memberships := make([]model.Membership, 0, len(users))
+		for _, user := range users {
+			memberships = append(memberships, model.Membership{
+				OrganizationID: organizationID,
+				UserID:         user.ID,
+				Role:           orgRole,
+			})
+		}
+		return tx.Create(&memberships).Error
+	})
+}
+
+func (r *userRepo) SaveAll(users []*model.User, deleted []*model.User) error {
+	versions := make([]uint, len(users))
+	for i, user := range users {
+		versions[i] = user.Version
+	}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for _, user := range users {
+			if err := updateVersioned(r.within(tx), user); err != nil {
+				return err
+			}
+		}
+		for _, user := range deleted {
+			if err := deleteVersioned(r.within(tx), user); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	// Nothing was stored after a rollback, so the users keep the versions they were read at
+	if err != nil {
+		for i, user := range users {
+			user.Version = versions[i]
+		}
+	}
+	return err
+}
+
+// Purge permanently removes a user row, freeing its email for reuse
+func (r *userRepo) Purge(id uint) error {
+	return r.query().Unscoped().Delete(&model.User{}, id).Error
+}
+
+// FindDeleted lists soft-deleted users, most recently deleted first
+func (r *userRepo) FindDeleted(filter UserFilter) ([]model.User, int64, error) {
+	var users []model.User
+	var total int64
+
+	query := r.query().Unscoped().Model(&model.User{}).
+		Scopes(filter.Scopes...).
+		Where("users.deleted_at IS NOT NULL")
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (filter.Page - 1) * filter.Limit
+	if err := query.Order("users.deleted_at DESC").Offset(offset).Limit(filter.Limit).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+func (r *userRepo) FindDeletedByID(id uint) (*model.User, error) {
+	var user model.User
+	if err := r.query().Unscoped().Where("users.deleted_at IS NOT NULL").First(&user, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// FindDeletedBefore lists users soft-deleted before the cutoff, across all organizations
+func (r *userRepo) FindDeletedBefore(cutoff time.Time) ([]model.User, error) {
+	var users []model.User
+	err := r.query().Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).Find(&users).Error
+	return users, err
+}
+
+// Restore undoes a soft delete of a user that is still at its version, and moves
+// it to the next one
+func (r *userRepo) Restore(user *model.User) error {
+	result := r.query().Unscoped().Model(&model.User{}).Where("users.id = ? AND version = ?", user.ID, user.Version).
+		Updates(map[string]interface{}{"deleted_at": nil, "version": gorm.Expr("version + 1")})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
+	user.Version++
+	return nil
+}
+
+func (r *userRepo) CountByRole(role string) (int64, error) {
+	var count int64
+	err := r.query().Model(&model.User{}).Where("role = ?", role).Count(&count).Error
+	return count, err
+}
+
+func (r *userRepo) WithTenant(organizationID uint) UserRepository {
+	return &userRepo{db: r.db, search: r.search, tenantID: organizationID, scoped: true}
+}
+
+func (r *userRepo) Global() UserRepository {
+	return &userRepo{db: r.db, search: r.search, global: true}
+}
+
+// query starts a statement restricted to the repository's tenant
+func (r *userRepo) query() *gorm.DB {
+	return r.within(r.db)
+}
+
+// within restricts a statement on db, e.g. a transaction, to the repository's tenant.
+// Statements of a repository that is neither scoped nor global fail with ErrTenantRequired.
+func (r *userRepo) within(db *gorm.DB) *gorm.DB {
+	switch {
+	case r.scoped:
+		return db.Scopes(TenantScope(r.tenantID))
+	case r.global:
+		return db
+	}
+	db = db.Session(&gorm.Session{})
+	_ = db.AddError(ErrTenantRequired)
+	return db
+}
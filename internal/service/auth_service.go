@@ -2,69 +2,202 @@ package service
 
 import (
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/model"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/pkg/logger"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type AuthService struct {
-	userService  *UserService
-	tokenService *TokenService
+	userService          *UserService
+	tokenService         *TokenService
+	securityEventService *SecurityEventService
+	deviceService        *DeviceService
+	emailService         *EmailService
+	invitationService    *InvitationService
+	organizationService  *OrganizationService
 }
 
-func NewAuthService(userService *UserService, tokenService *TokenService) *AuthService {
+func NewAuthService(
+	userService *UserService,
+	tokenService *TokenService,
+	securityEventService *SecurityEventService,
+	deviceService *DeviceService,
+	emailService *EmailService,
+	invitationService *InvitationService,
+	organizationService *OrganizationService,
+) *AuthService {
 	return &AuthService{
-		userService:  userService,
-		tokenService: tokenService,
+		userService:          userService,
+		tokenService:         tokenService,
+		securityEventService: securityEventService,
+		deviceService:        deviceService,
+		emailService:         emailService,
+		invitationService:    invitationService,
+		organizationService:  organizationService,
 	}
 }
 
-func (s *AuthService) Login(email, password string) (*model.User, *AuthTokens, error) {
+func (s *AuthService) Login(email, password string, client ClientInfo) (*model.User, *AuthTokens, error) {
 	user, err := s.userService.GetUserByEmail(email)
 	if err != nil || user == nil {
+		s.securityEventService.Record(model.SecurityEventLoginFailure, 0, email, client)
 		return nil, nil, errors.New("incorrect email or password")
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		s.securityEventService.Record(model.SecurityEventLoginFailure, user.ID, email, client)
 		return nil, nil, errors.New("incorrect email or password")
 	}
 
-	tokens, err := s.tokenService.GenerateAuthTokens(user)
+	if user.PasswordResetRequired {
+		s.securityEventService.Record(model.SecurityEventLoginFailure, user.ID, email, client)
+		return nil, nil, errors.New("password reset required")
+	}
+
+	if err := s.userService.CheckStatus(user); err != nil {
+		s.securityEventService.Record(model.SecurityEventLoginFailure, user.ID, email, client)
+		return nil, nil, err
+	}
+
+	tokens, err := s.issueTokens(user, time.Now(), 0)
 	if err != nil {
 		return nil, nil, err
 	}
 
+	s.securityEventService.Record(model.SecurityEventLoginSuccess, user.ID, user.Email, client)
+	s.notifyIfNewDevice(user, client)
+
 	return user, tokens, nil
 }
 
-func (s *AuthService) Register(user *model.User) (*model.User, *AuthTokens, error) {
+// issueTokens signs the user in with the default scopes of their role and groups. The tokens
+// act in preferredOrgID when the user is a member of it, otherwise in their current organization.
+func (s *AuthService) issueTokens(user *model.User, authTime time.Time, preferredOrgID uint) (*AuthTokens, error) {
+	orgID, err := s.organizationService.ResolveOrganizationID(user, preferredOrgID)
+	if err != nil {
+		return nil, err
+	}
+	user.TenantID = orgID
+
+	scopes, err := s.userService.DefaultScopes(user)
+	if err != nil {
+		return nil, err
+	}
+	return s.tokenService.GenerateAuthTokens(user, authTime, scopes, orgID)
+}
+
+// IssueScopedToken creates an access token limited to a subset of the caller's scopes,
+// e.g. a read-only token for a script. Only tokens of a login may mint one, and it
+// lives for ttl but never past the caller's own token.
+func (s *AuthService) IssueScopedToken(userID uint, claims *Claims, scopes []string, ttl time.Duration) (*AuthTokenResponse, error) {
+	// Scoped tokens carry no auth_time, so they cannot mint tokens of their own
+	if claims.AuthTime == nil {
+		return nil, fmt.Errorf("%w: scoped tokens cannot be used to create other tokens", ErrForbidden)
+	}
+	for _, scope := range scopes {
+		if !claims.HasScopes(scope) {
+			return nil, fmt.Errorf("scope not granted to this token: %s", scope)
+		}
+	}
+
+	maxTTL := s.tokenService.config.JWTRefreshExpirationDays
+	if ttl <= 0 {
+		ttl = s.tokenService.config.JWTAccessExpirationMinutes
+	}
+	if ttl > maxTTL {
+		return nil, fmt.Errorf("expiration cannot exceed %d minutes", int(maxTTL.Minutes()))
+	}
+
+	expires := time.Now().Add(ttl)
+	if claims.ExpiresAt != nil && claims.ExpiresAt.Time.Before(expires) {
+		expires = claims.ExpiresAt.Time
+	}
+
+	user, err := s.userService.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.tokenService.GenerateScopedToken(user, scopes, claims.OrgID, expires)
+}
+
+// notifyIfNewDevice emails the user when a login comes from a device we have not seen before.
+// Errors are only logged: a failed notification must not fail the login itself.
+func (s *AuthService) notifyIfNewDevice(user *model.User, client ClientInfo) {
+	device, isNew, err := s.deviceService.Track(user.ID, client)
+	if err != nil {
+		logger.Error("failed to track device for user %d: %v", user.ID, err)
+		return
+	}
+	if !isNew {
+		return
+	}
+
+	s.securityEventService.Record(model.SecurityEventNewDevice, user.ID, user.Email, client)
+
+	revokeToken, err := s.tokenService.GenerateRevokeSessionsToken(user)
+	if err != nil {
+		logger.Error("failed to generate revoke-sessions token for user %d: %v", user.ID, err)
+		return
+	}
+	if err := s.emailService.SendNewDeviceEmail(user.Email, device, revokeToken); err != nil {
+		logger.Error("failed to send new device email to user %d: %v", user.ID, err)
+	}
+}
+
+func (s *AuthService) Register(user *model.User, client ClientInfo) (*model.User, *AuthTokens, error) {
 	createdUser, err := s.userService.CreateUser(user)
 	if err != nil {
 		return nil, nil, err
 	}
+	if err := s.organizationService.CreatePersonalOrganization(createdUser); err != nil {
+		return nil, nil, err
+	}
 
-	tokens, err := s.tokenService.GenerateAuthTokens(createdUser)
+	tokens, err := s.issueTokens(createdUser, time.Now(), 0)
 	if err != nil {
 		return nil, nil, err
 	}
 
+	// Remember the registering device so the next login from it is not reported as new
+	if _, _, err := s.deviceService.Track(createdUser.ID, client); err != nil {
+		logger.Error("failed to track device for user %d: %v", createdUser.ID, err)
+	}
+
 	return createdUser, tokens, nil
 }
 
-func (s *AuthService) Logout(refreshToken string) error {
-	return s.tokenService.DeleteRefreshToken(refreshToken)
+func (s *AuthService) Logout(refreshToken string, client ClientInfo) error {
+	storedToken, err := s.tokenService.FindRefreshToken(refreshToken)
+	if err != nil {
+		return err
+	}
+	if storedToken == nil {
+		return errors.New("token not found")
+	}
+
+	if err := s.tokenService.DeleteRefreshToken(refreshToken); err != nil {
+		return err
+	}
+
+	s.securityEventService.Record(model.SecurityEventLogout, storedToken.UserID, "", client)
+	return nil
 }
 
-func (s *AuthService) RefreshAuth(refreshToken string) (*AuthTokens, error) {
+func (s *AuthService) RefreshAuth(refreshToken string, client ClientInfo) (*AuthTokens, error) {
 	// Verify token signature and type
 	claims, err := s.tokenService.VerifyToken(refreshToken)
 	if err != nil || claims.Type != model.TokenTypeRefresh {
 		return nil, errors.New("invalid refresh token")
 	}
 
-	// Verify token exists in DB (not blacklisted/deleted)
+	// Verify token exists in DB (not blacklisted/deleted).
+	// A validly signed token that is no longer stored has already been rotated.
 	storedToken, err := s.tokenService.FindRefreshToken(refreshToken)
 	if err != nil || storedToken == nil {
+		s.securityEventService.Record(model.SecurityEventTokenReuse, claims.UserID, "", client)
 		return nil, errors.New("refresh token not found or reused")
 	}
 
@@ -73,10 +206,156 @@ func (s *AuthService) RefreshAuth(refreshToken string) (*AuthTokens, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := s.userService.CheckStatus(user); err != nil {
+		return nil, err
+	}
 
 	// Delete old token
 	s.tokenService.DeleteRefreshToken(refreshToken)
 
-	// Generate new pair
-	return s.tokenService.GenerateAuthTokens(user)
-}
\ No newline at end of file
+	// Generate new pair, carrying over when the user last entered their password
+	var authTime time.Time
+	if claims.AuthTime != nil {
+		authTime = claims.AuthTime.Time
+	}
+	tokens, err := s.issueTokens(user, authTime, claims.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.securityEventService.Record(model.SecurityEventRefresh, user.ID, user.Email, client)
+
+	return tokens, nil
+}
+
+// ForgotPassword emails a password reset link. Unknown emails are silently ignored
+// so the endpoint cannot be used to discover which addresses are registered.
+func (s *AuthService) ForgotPassword(email string) error {
+	user, err := s.userService.GetUserByEmail(email)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return nil
+	}
+
+	resetToken, err := s.tokenService.GenerateResetPasswordToken(user)
+	if err != nil {
+		return err
+	}
+	return s.emailService.SendResetPasswordEmail(user.Email, resetToken)
+}
+
+func (s *AuthService) ResetPassword(resetToken, newPassword string, client ClientInfo) error {
+	storedToken, err := s.tokenService.VerifyStoredToken(resetToken, model.TokenTypeResetPassword)
+	if err != nil {
+		return errors.New("password reset failed")
+	}
+
+	user, err := s.userService.GetUserByID(storedToken.UserID)
+	if err != nil {
+		return errors.New("password reset failed")
+	}
+
+	if err := s.userService.SetPassword(user, newPassword); err != nil {
+		return err
+	}
+
+	// A reset invalidates every outstanding reset link and signs out all sessions
+	if err := s.tokenService.DeleteUserTokens(user.ID, model.TokenTypeResetPassword); err != nil {
+		return err
+	}
+	if err := s.userService.RevokeSessions(user); err != nil {
+		return err
+	}
+
+	s.securityEventService.Record(model.SecurityEventPasswordReset, user.ID, user.Email, client)
+	return nil
+}
+
+// RevokeSessions handles the "this wasn't me" link from a new device email.
+// It signs the user out everywhere, blocks login until the password is reset
+// and sends a fresh reset link.
+func (s *AuthService) RevokeSessions(revokeToken string, client ClientInfo) error {
+	storedToken, err := s.tokenService.VerifyStoredToken(revokeToken, model.TokenTypeRevokeSessions)
+	if err != nil {
+		return errors.New("invalid or expired link")
+	}
+
+	user, err := s.userService.GetUserByID(storedToken.UserID)
+	if err != nil {
+		return errors.New("invalid or expired link")
+	}
+
+	if err := s.tokenService.DeleteUserTokens(user.ID, model.TokenTypeRevokeSessions); err != nil {
+		return err
+	}
+	user.PasswordResetRequired = true
+	if err := s.userService.RevokeSessions(user); err != nil {
+		return err
+	}
+
+	s.securityEventService.Record(model.SecurityEventSessionsRevoked, user.ID, user.Email, client)
+
+	resetToken, err := s.tokenService.GenerateResetPasswordToken(user)
+	if err != nil {
+		return err
+	}
+	return s.emailService.SendResetPasswordEmail(user.Email, resetToken)
+}
+
+// AcceptInvite activates an invited account with the chosen password and logs the invitee in
+func (s *AuthService) AcceptInvite(inviteToken, password string, client ClientInfo) (*model.User, *AuthTokens, error) {
+	user, err := s.invitationService.Accept(inviteToken, password)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tokens, err := s.issueTokens(user, time.Now(), 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.securityEventService.Record(model.SecurityEventLoginSuccess, user.ID, user.Email, client)
+	if _, _, err := s.deviceService.Track(user.ID, client); err != nil {
+		logger.Error("failed to track device for user %d: %v", user.ID, err)
+	}
+
+	return user, tokens, nil
+}
+
+// Reauthenticate confirms the password of an already logged-in user and issues
+// tokens with a fresh auth_time, unlocking operations guarded by RequireRecentAuth.
+func (s *AuthService) Reauthenticate(userID uint, password string, orgID uint, client ClientInfo) (*AuthTokens, error) {
+	user, err := s.userService.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		s.securityEventService.Record(model.SecurityEventLoginFailure, user.ID, user.Email, client)
+		return nil, errors.New("incorrect password")
+	}
+
+	tokens, err := s.issueTokens(user, time.Now(), orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.securityEventService.Record(model.SecurityEventReauthenticate, user.ID, user.Email, client)
+	return tokens, nil
+}
+
+// SwitchOrganization makes organizationID the user's current organization and issues
+// tokens acting in it. The time of the last password entry is carried over.
+func (s *AuthService) SwitchOrganization(user *model.User, claims *Claims, organizationID uint) (*AuthTokens, error) {
+	if err := s.organizationService.SwitchOrganization(user, organizationID); err != nil {
+		return nil, err
+	}
+
+	var authTime time.Time
+	if claims.AuthTime != nil {
+		authTime = claims.AuthTime.Time
+	}
+	return s.issueTokens(user, authTime, organizationID)
+}
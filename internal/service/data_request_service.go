@@ -0,0 +1,519 @@
+package service
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/config"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/model"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/repository"
+)
+
+// DataRequestService handles data subject requests: users can download a copy of
+// everything stored about them and ask for their personal data to be erased.
+type DataRequestService struct {
+	dataRequestRepo   repository.DataRequestRepository
+	userRepo          repository.UserRepository
+	membershipRepo    repository.MembershipRepository
+	groupRepo         repository.GroupRepository
+	tokenRepo         repository.TokenRepository
+	securityEventRepo repository.SecurityEventRepository
+	deviceRepo        repository.DeviceRepository
+	userService       *UserService
+	emailService      *EmailService
+	config            *config.Config
+}
+
+func NewDataRequestService(
+	dataRequestRepo repository.DataRequestRepository,
+	userRepo repository.UserRepository,
+	membershipRepo repository.MembershipRepository,
+	groupRepo repository.GroupRepository,
+	tokenRepo repository.TokenRepository,
+	securityEventRepo repository.SecurityEventRepository,
+	deviceRepo repository.DeviceRepository,
+	userService *UserService,
+	emailService *EmailService,
+	cfg *config.Config,
+) *DataRequestService {
+	return &DataRequestService{
+		dataRequestRepo:   dataRequestRepo,
+		userRepo:          userRepo,
+		membershipRepo:    membershipRepo,
+		groupRepo:         groupRepo,
+		tokenRepo:         tokenRepo,
+		securityEventRepo: securityEventRepo,
+		deviceRepo:        deviceRepo,
+		userService:       userService,
+		emailService:      emailService,
+		config:            cfg,
+	}
+}
+
+// RequestExport starts building an export bundle for the user in the background
+func (s *DataRequestService) RequestExport(user *model.User) (*model.DataRequest, error) {
+	request, err := s.create(user, model.DataRequestTypeExport, "")
+	if err != nil {
+		return nil, err
+	}
+	go s.buildExport(request.ID)
+	return request, nil
+}
+
+// RequestErasure queues the erasure of the user's personal data for review by an admin
+func (s *DataRequestService) RequestErasure(user *model.User, reason string) (*model.DataRequest, error) {
+	return s.create(user, model.DataRequestTypeErasure, reason)
+}
+
+func (s *DataRequestService) create(user *model.User, requestType, reason string) (*model.DataRequest, error) {
+	active, err := s.dataRequestRepo.FindActive(user.ID, requestType)
+	if err != nil {
+		return nil, err
+	}
+	if active != nil {
+		return nil, ErrDataRequestInProgress
+	}
+
+	request := &model.DataRequest{
+		UserID:         user.ID,
+		Type:           requestType,
+		Status:         model.DataRequestStatusPending,
+		OrganizationID: user.TenantID,
+		Reason:         reason,
+	}
+	if err := s.dataRequestRepo.Create(request); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// GetMyRequests lists the user's own data requests, newest first
+func (s *DataRequestService) GetMyRequests(user *model.User, page, limit int) ([]model.DataRequestResponse, int64, error) {
+	return s.list(repository.DataRequestFilter{Page: page, Limit: limit, UserID: user.ID})
+}
+
+// CancelRequest withdraws one of the user's erasure requests that has not been reviewed yet
+func (s *DataRequestService) CancelRequest(user *model.User, id uint) (*model.DataRequest, error) {
+	request, err := s.dataRequestRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if request == nil || request.UserID != user.ID {
+		return nil, ErrDataRequestNotFound
+	}
+	if request.Type != model.DataRequestTypeErasure || request.Status != model.DataRequestStatusPending {
+		return nil, errors.New("only pending erasure requests can be cancelled")
+	}
+
+	request.Status = model.DataRequestStatusCancelled
+	if err := s.dataRequestRepo.Update(request); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// ExportFile returns the path of a finished export bundle of the user
+func (s *DataRequestService) ExportFile(user *model.User, id uint) (string, error) {
+	request, err := s.dataRequestRepo.FindByID(id)
+	if err != nil {
+		return "", err
+	}
+	if request == nil || request.UserID != user.ID || request.Type != model.DataRequestTypeExport {
+		return "", ErrDataRequestNotFound
+	}
+	if request.Status != model.DataRequestStatusCompleted {
+		return "", fmt.Errorf("export is %s", request.Status)
+	}
+	if request.FilePath == "" || (request.ExpiresAt != nil && request.ExpiresAt.Before(time.Now())) {
+		return "", ErrExportExpired
+	}
+	return request.FilePath, nil
+}
+
+// GetRequests lists the data requests made by users of the actor's organization
+func (s *DataRequestService) GetRequests(actor *model.User, filter repository.DataRequestFilter) ([]model.DataRequestResponse, int64, error) {
+	filter.OrganizationID = actor.TenantID
+	return s.list(filter)
+}
+
+// GetRequest returns a data request made in the actor's organization
+func (s *DataRequestService) GetRequest(actor *model.User, id uint) (*model.DataRequest, error) {
+	request, err := s.dataRequestRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if request == nil || request.OrganizationID != actor.TenantID {
+		return nil, ErrDataRequestNotFound
+	}
+	return request, nil
+}
+
+// ApproveErasure anonymizes and deletes the user of a pending erasure request.
+// The actor must be allowed to delete the user; the last admin cannot be erased.
+func (s *DataRequestService) ApproveErasure(actor *model.User, id uint) (*model.DataRequest, error) {
+	request, err := s.pendingErasure(actor, id)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.Global().FindByID(request.UserID)
+	if err == nil && user == nil {
+		user, err = s.userRepo.Global().FindDeletedByID(request.UserID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	if _, err := s.userService.authorize(actor, model.PermissionUsersDelete, user); err != nil {
+		return nil, err
+	}
+	if user.Role == model.RoleAdmin {
+		admins, err := s.userRepo.Global().CountByRole(model.RoleAdmin)
+		if err != nil {
+			return nil, err
+		}
+		if admins <= 1 {
+			return nil, ErrLastAdmin
+		}
+	}
+
+	if err := s.erase(user); err != nil {
+		return nil, err
+	}
+	return request, s.resolve(request, actor, model.DataRequestStatusCompleted, "")
+}
+
+// RejectErasure declines a pending erasure request, e.g. because the data must be retained by law
+func (s *DataRequestService) RejectErasure(actor *model.User, id uint, resolution string) (*model.DataRequest, error) {
+	request, err := s.pendingErasure(actor, id)
+	if err != nil {
+		return nil, err
+	}
+	return request, s.resolve(request, actor, model.DataRequestStatusRejected, resolution)
+}
+
+// FailInterruptedExports marks exports cut short by a restart as failed, so their
+// users can request new ones
+func (s *DataRequestService) FailInterruptedExports() (int64, error) {
+	return s.dataRequestRepo.FailUnfinishedExports("the export was interrupted by a restart, please request a new one")
+}
+
+// DeleteExpiredExports removes export bundles whose download period has ended
+func (s *DataRequestService) DeleteExpiredExports() error {
+	now := time.Now()
+	requests, err := s.dataRequestRepo.FindExportsWithFiles(0, &now)
+	if err != nil {
+		return err
+	}
+	return s.removeExportFiles(requests)
+}
+
+func (s *DataRequestService) list(filter repository.DataRequestFilter) ([]model.DataRequestResponse, int64, error) {
+	requests, total, err := s.dataRequestRepo.FindAll(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	response := make([]model.DataRequestResponse, 0, len(requests))
+	for i := range requests {
+		response = append(response, requests[i].ToResponse())
+	}
+	return response, total, nil
+}
+
+func (s *DataRequestService) pendingErasure(actor *model.User, id uint) (*model.DataRequest, error) {
+	request, err := s.GetRequest(actor, id)
+	if err != nil {
+		return nil, err
+	}
+	if request.Type != model.DataRequestTypeErasure {
+		return nil, errors.New("only erasure requests need a review")
+	}
+	if request.Status != model.DataRequestStatusPending {
+		return nil, fmt.Errorf("request is already %s", request.Status)
+	}
+	return request, nil
+}
+
+func (s *DataRequestService) resolve(request *model.DataRequest, actor *model.User, status, resolution string) error {
+	now := time.Now()
+	request.Status = status
+	request.Resolution = resolution
+	request.ProcessedByID = &actor.ID
+	request.CompletedAt = &now
+	return s.dataRequestRepo.Update(request)
+}
+
+// erase replaces everything that identifies the user and then soft-deletes the row.
+// The ID survives so audit records stay consistent; the retention job purges it later.
+func (s *DataRequestService) erase(user *model.User) error {
+	email := user.Email
+
+	exports, err := s.dataRequestRepo.FindExportsWithFiles(user.ID, nil)
+	if err != nil {
+		return err
+	}
+
+	user.Name = "Erased User"
+	user.Email = fmt.Sprintf("erased-%d@erased.invalid", user.ID)
+	user.Password = ""
+	user.PendingEmail = ""
+	user.IsEmailVerified = false
+	user.StatusReason = ""
+	user.LastOrganizationID = nil
+	user.TokenVersion++
+	if err := s.dataRequestRepo.Erase(user, email); err != nil {
+		return err
+	}
+
+	// Files cannot be rolled back, so they only go once the erasure is committed
+	for _, export := range exports {
+		if err := os.Remove(export.FilePath); err != nil && !os.IsNotExist(err) {
+			log.Printf("⚠️ Failed to remove data export %d of erased user %d: %v", export.ID, user.ID, err)
+		}
+	}
+
+	if err := s.emailService.SendErasureCompletedEmail(email); err != nil {
+		log.Printf("⚠️ Failed to send erasure confirmation for user %d: %v", user.ID, err)
+	}
+	return nil
+}
+
+func (s *DataRequestService) removeExportFiles(requests []model.DataRequest) error {
+	for i := range requests {
+		if err := os.Remove(requests[i].FilePath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		requests[i].FilePath = ""
+		if err := s.dataRequestRepo.Update(&requests[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildExport runs in the background and records the outcome on the request
+func (s *DataRequestService) buildExport(id uint) {
+	request, err := s.dataRequestRepo.FindByID(id)
+	if err != nil || request == nil {
+		log.Printf("⚠️ Data export %d not found: %v", id, err)
+		return
+	}
+
+	request.Status = model.DataRequestStatusProcessing
+	if err := s.dataRequestRepo.Update(request); err != nil {
+		log.Printf("⚠️ Data export %d failed: %v", id, err)
+		return
+	}
+
+	user, path, err := s.writeExport(request)
+	now := time.Now()
+	request.CompletedAt = &now
+	if err != nil {
+		log.Printf("⚠️ Data export %d failed: %v", id, err)
+		request.Status = model.DataRequestStatusFailed
+		request.Resolution = "the export could not be created, please request a new one"
+	} else {
+		expires := now.Add(s.config.DataExportExpiration)
+		request.Status = model.DataRequestStatusCompleted
+		request.FilePath = path
+		request.ExpiresAt = &expires
+	}
+	if err := s.dataRequestRepo.Update(request); err != nil {
+		log.Printf("⚠️ Data export %d could not be saved: %v", id, err)
+		return
+	}
+
+	if request.Status == model.DataRequestStatusCompleted {
+		if err := s.emailService.SendDataExportReadyEmail(user.Email, request.ID, *request.ExpiresAt); err != nil {
+			log.Printf("⚠️ Failed to send data export email for user %d: %v", user.ID, err)
+		}
+	}
+}
+
+// exportDocument is one JSON file of an export bundle
+type exportDocument struct {
+	name string
+	data interface{}
+}
+
+type exportProfile struct {
+	model.UserResponse
+	Preferences struct {
+		LastOrganizationID *uint `json:"lastOrganizationId"`
+	} `json:"preferences"`
+}
+
+type exportOrganization struct {
+	OrganizationID uint      `json:"organizationId"`
+	Name           string    `json:"name"`
+	Role           string    `json:"role"`
+	JoinedAt       time.Time `json:"joinedAt"`
+}
+
+type exportGroup struct {
+	OrganizationID uint     `json:"organizationId"`
+	Name           string   `json:"name"`
+	Permissions    []string `json:"permissions"`
+}
+
+// exportToken describes a stored token without its secret value
+type exportToken struct {
+	Type        string    `json:"type"`
+	Expires     time.Time `json:"expires"`
+	Blacklisted bool      `json:"blacklisted"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type exportDevice struct {
+	IP          string    `json:"ip"`
+	UserAgent   string    `json:"userAgent"`
+	FirstSeenAt time.Time `json:"firstSeenAt"`
+	LastSeenAt  time.Time `json:"lastSeenAt"`
+}
+
+// writeExport collects the user's data and writes it as a ZIP of JSON documents
+func (s *DataRequestService) writeExport(request *model.DataRequest) (*model.User, string, error) {
+	user, err := s.userRepo.Global().FindByID(request.UserID)
+	if err != nil {
+		return nil, "", err
+	}
+	if user == nil {
+		return nil, "", ErrUserNotFound
+	}
+
+	documents, err := s.collect(user)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := os.MkdirAll(s.config.DataExportDir, 0o700); err != nil {
+		return nil, "", err
+	}
+	path := filepath.Join(s.config.DataExportDir, fmt.Sprintf("export-%d.zip", request.ID))
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return nil, "", err
+	}
+
+	archive := zip.NewWriter(file)
+	for _, document := range documents {
+		if err = writeJSON(archive, document); err != nil {
+			break
+		}
+	}
+	if closeErr := archive.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return nil, "", err
+	}
+	return user, path, nil
+}
+
+func writeJSON(archive *zip.Writer, document exportDocument) error {
+	w, err := archive.CreateHeader(&zip.FileHeader{
+		Name:     document.name,
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(document.data)
+}
+
+// collect gathers everything stored about the user
+func (s *DataRequestService) collect(user *model.User) ([]exportDocument, error) {
+	profile := exportProfile{UserResponse: user.ToResponse()}
+	profile.Preferences.LastOrganizationID = user.LastOrganizationID
+
+	memberships, err := s.membershipRepo.FindByUserID(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	organizations := make([]exportOrganization, 0, len(memberships))
+	for _, m := range memberships {
+		organizations = append(organizations, exportOrganization{
+			OrganizationID: m.OrganizationID,
+			Name:           m.Organization.Name,
+			Role:           m.Role,
+			JoinedAt:       m.CreatedAt,
+		})
+	}
+
+	groupRows, err := s.groupRepo.FindByUserID(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	groups := make([]exportGroup, 0, len(groupRows))
+	for i := range groupRows {
+		groups = append(groups, exportGroup{
+			OrganizationID: groupRows[i].OrganizationID,
+			Name:           groupRows[i].Name,
+			Permissions:    groupRows[i].PermissionNames(),
+		})
+	}
+
+	tokenRows, err := s.tokenRepo.FindByUserID(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	tokens := make([]exportToken, 0, len(tokenRows))
+	for _, t := range tokenRows {
+		tokens = append(tokens, exportToken{
+			Type:        t.Type,
+			Expires:     t.Expires,
+			Blacklisted: t.Blacklisted,
+			CreatedAt:   t.CreatedAt,
+		})
+	}
+
+	deviceRows, err := s.deviceRepo.FindByUserID(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	devices := make([]exportDevice, 0, len(deviceRows))
+	for _, d := range deviceRows {
+		devices = append(devices, exportDevice{
+			IP:          d.IP,
+			UserAgent:   d.UserAgent,
+			FirstSeenAt: d.CreatedAt,
+			LastSeenAt:  d.LastSeenAt,
+		})
+	}
+
+	events, err := s.securityEventRepo.FindByUserID(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	requests, _, err := s.list(repository.DataRequestFilter{Page: 1, Limit: -1, UserID: user.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	return []exportDocument{
+		{name: "profile.json", data: profile},
+		{name: "organizations.json", data: organizations},
+		{name: "groups.json", data: groups},
+		{name: "tokens.json", data: tokens},
+		{name: "devices.json", data: devices},
+		{name: "security_events.json", data: events},
+		{name: "data_requests.json", data: requests},
+	}, nil
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/config"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/database"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/model"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/repository"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/pkg/mailer"
+)
+
+func TestFailInterruptedExportsAllowsNewExport(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{
+		DBDriver:             "sqlite",
+		DBSource:             filepath.Join(dir, "test.db"),
+		DataExportDir:        filepath.Join(dir, "exports"),
+		DataExportExpiration: time.Hour,
+	}
+	db := database.ConnectDB(cfg)
+
+	dataRequestRepo := repository.NewDataRequestRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	s := NewDataRequestService(
+		dataRequestRepo,
+		userRepo,
+		repository.NewMembershipRepository(db),
+		repository.NewGroupRepository(db),
+		repository.NewTokenRepository(db),
+		repository.NewSecurityEventRepository(db),
+		repository.NewDeviceRepository(db),
+		nil,
+		NewEmailService(mailer.NewLogMailer("test@example.com"), cfg),
+		cfg,
+	)
+
+	user := &model.User{Name: "Export User", Email: "export@example.com", Role: "user"}
+	if err := userRepo.Global().Create(user); err != nil {
+		t.Fatalf("creating user: %v", err)
+	}
+
+	// An export the server was building when it stopped
+	interrupted := &model.DataRequest{
+		UserID: user.ID,
+		Type:   model.DataRequestTypeExport,
+		Status: model.DataRequestStatusProcessing,
+	}
+	if err := dataRequestRepo.Create(interrupted); err != nil {
+		t.Fatalf("creating export: %v", err)
+	}
+
+	if _, err := s.RequestExport(user); !errors.Is(err, ErrDataRequestInProgress) {
+		t.Fatalf("expected ErrDataRequestInProgress before recovery, got %v", err)
+	}
+
+	failed, err := s.FailInterruptedExports()
+	if err != nil {
+		t.Fatalf("failing interrupted exports: %v", err)
+	}
+	if failed != 1 {
+		t.Fatalf("expected 1 interrupted export, got %d", failed)
+	}
+
+	stored, err := dataRequestRepo.FindByID(interrupted.ID)
+	if err != nil {
+		t.Fatalf("reading export: %v", err)
+	}
+	if stored.Status != model.DataRequestStatusFailed || stored.CompletedAt == nil {
+		t.Fatalf("expected the interrupted export to be failed, got status %q", stored.Status)
+	}
+
+	request, err := s.RequestExport(user)
+	if err != nil {
+		t.Fatalf("requesting a new export: %v", err)
+	}
+
+	// Let the background build finish before the database goes away
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		current, err := dataRequestRepo.FindByID(request.ID)
+		if err != nil {
+			t.Fatalf("reading new export: %v", err)
+		}
+		if current.Status == model.DataRequestStatusCompleted {
+			break
+		}
+		if current.Status == model.DataRequestStatusFailed {
+			t.Fatalf("new export failed: %s", current.Resolution)
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("new export still %q", current.Status)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
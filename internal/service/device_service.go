@@ -0,0 +1,74 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"time"
+
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/model"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/repository"
+)
+
+type DeviceService struct {
+	deviceRepo repository.DeviceRepository
+}
+
+func NewDeviceService(deviceRepo repository.DeviceRepository) *DeviceService {
+	return &DeviceService{
+		deviceRepo: deviceRepo,
+	}
+}
+
+// Track records that the user logged in from the given client.
+// It reports whether the device is new for a user who already had known devices,
+// so the very first login after registration (or after this feature shipped) is not flagged.
+func (s *DeviceService) Track(userID uint, client ClientInfo) (device *model.UserDevice, isNew bool, err error) {
+	fingerprint := Fingerprint(client)
+
+	device, err = s.deviceRepo.FindByFingerprint(userID, fingerprint)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if device != nil {
+		device.IP = client.IP
+		device.LastSeenAt = time.Now()
+		return device, false, s.deviceRepo.Update(device)
+	}
+
+	known, err := s.deviceRepo.CountByUserID(userID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	device = &model.UserDevice{
+		UserID:      userID,
+		Fingerprint: fingerprint,
+		IP:          client.IP,
+		UserAgent:   client.UserAgent,
+		LastSeenAt:  time.Now(),
+	}
+	if err := s.deviceRepo.Create(device); err != nil {
+		return nil, false, err
+	}
+
+	return device, known > 0, nil
+}
+
+// Fingerprint identifies a device by its user agent and network.
+// The IP is reduced to its /24 (IPv4) or /64 (IPv6) network so that
+// ordinary DHCP churn does not look like a new device.
+func Fingerprint(client ClientInfo) string {
+	network := client.IP
+	if ip := net.ParseIP(client.IP); ip != nil {
+		if v4 := ip.To4(); v4 != nil {
+			network = v4.Mask(net.CIDRMask(24, 32)).String()
+		} else {
+			network = ip.Mask(net.CIDRMask(64, 128)).String()
+		}
+	}
+
+	sum := sha256.Sum256([]byte(network + "|" + client.UserAgent))
+	return hex.EncodeToString(sum[:])
+}
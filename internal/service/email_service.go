@@ -0,0 +1,133 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/config"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/model"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/pkg/mailer"
+)
+
+type EmailService struct {
+	mailer mailer.Mailer
+	config *config.Config
+}
+
+func NewEmailService(m mailer.Mailer, cfg *config.Config) *EmailService {
+	return &EmailService{
+		mailer: m,
+		config: cfg,
+	}
+}
+
+// link builds an absolute URL to an API endpoint carrying a one-time token
+func (s *EmailService) link(path, token string) string {
+	return fmt.Sprintf("%s/v1%s?token=%s", s.config.AppURL, path, url.QueryEscape(token))
+}
+
+func (s *EmailService) SendNewDeviceEmail(to string, device *model.UserDevice, revokeToken string) error {
+	subject := "New sign-in to your account"
+	body := fmt.Sprintf(`Hello,
+
+We noticed a new sign-in to your account.
+
+  Time:       %s
+  IP address: %s
+  Device:     %s
+
+If this was you, you can ignore this email.
+
+If this wasn't you, open the link below and confirm. It will sign you out
+everywhere and require a password reset before anyone can log in again:
+
+%s
+`, device.LastSeenAt.UTC().Format(time.RFC1123), device.IP, device.UserAgent, s.link("/auth/revoke-sessions", revokeToken))
+
+	return s.mailer.Send(to, subject, body)
+}
+
+func (s *EmailService) SendResetPasswordEmail(to, resetToken string) error {
+	subject := "Reset your password"
+	body := fmt.Sprintf(`Hello,
+
+To reset your password, send your new password to:
+
+POST %s
+
+If you did not request a password reset, you can ignore this email.
+`, s.link("/auth/reset-password", resetToken))
+
+	return s.mailer.Send(to, subject, body)
+}
+
+func (s *EmailService) SendInvitationEmail(to, name, inviteToken string, expires time.Time) error {
+	subject := "You have been invited"
+	body := fmt.Sprintf(`Hello %s,
+
+An administrator has created an account for you. To activate it, choose
+your password by sending it to:
+
+POST %s
+
+This invitation expires on %s.
+`, name, s.link("/auth/accept-invite", inviteToken), expires.UTC().Format(time.RFC1123))
+
+	return s.mailer.Send(to, subject, body)
+}
+
+func (s *EmailService) SendEmailChangeConfirmationEmail(to, confirmToken string) error {
+	subject := "Confirm your new email address"
+	body := fmt.Sprintf(`Hello,
+
+A request was made to use this address for your account. To confirm it, send a request to:
+
+POST %s
+
+Until you confirm, your account keeps using its current email address.
+`, s.link("/auth/confirm-email-change", confirmToken))
+
+	return s.mailer.Send(to, subject, body)
+}
+
+func (s *EmailService) SendEmailChangeNoticeEmail(to, newEmail, cancelToken string) error {
+	subject := "Your email address is being changed"
+	body := fmt.Sprintf(`Hello,
+
+A request was made to change the email address of your account to %s.
+The change only takes effect once the new address is confirmed.
+
+If you did not request this, cancel it by sending a request to:
+
+POST %s
+`, newEmail, s.link("/auth/cancel-email-change", cancelToken))
+
+	return s.mailer.Send(to, subject, body)
+}
+
+func (s *EmailService) SendDataExportReadyEmail(to string, requestID uint, expires time.Time) error {
+	subject := "Your data export is ready"
+	body := fmt.Sprintf(`Hello,
+
+The copy of your account data you requested is ready. Download it while
+signed in from:
+
+GET %s/v1/users/me/data-requests/%d/download
+
+The download is available until %s.
+`, s.config.AppURL, requestID, expires.UTC().Format(time.RFC1123))
+
+	return s.mailer.Send(to, subject, body)
+}
+
+func (s *EmailService) SendErasureCompletedEmail(to string) error {
+	subject := "Your account data has been erased"
+	body := `Hello,
+
+As you requested, your account has been closed and the personal data we held
+about you has been erased. This is the last email you will receive from us.
+`
+
+	return s.mailer.Send(to, subject, body)
+}
@@ -0,0 +1,27 @@
+package service
+
+import "errors"
+
+// Errors that handlers map to specific HTTP status codes
+var (
+	ErrLastAdmin = errors.New("cannot remove the admin role from the last admin")
+	ErrForbidden = errors.New("forbidden by access policy")
+
+	ErrReauthenticationRequired = errors.New("this operation requires a recent login; please confirm your password")
+
+	ErrUserNotFound = errors.New("user not found")
+
+	ErrOrganizationNotFound = errors.New("organization not found")
+	ErrMemberNotFound       = errors.New("member not found")
+	ErrLastOwner            = errors.New("cannot remove the last owner of an organization")
+
+	ErrGroupNotFound = errors.New("group not found")
+
+	ErrAccountPending   = errors.New("account is not activated")
+	ErrAccountSuspended = errors.New("account is suspended")
+	ErrAccountBanned    = errors.New("account is banned")
+
+	ErrDataRequestNotFound   = errors.New("data request not found")
+	ErrDataRequestInProgress = errors.New("a request of this type is already in progress")
+	ErrExportExpired         = errors.New("export is no longer available")
+)
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/model"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/repository"
+)
+
+type GroupService struct {
+	groupRepo   repository.GroupRepository
+	roleService *RoleService
+	userService *UserService
+}
+
+func NewGroupService(groupRepo repository.GroupRepository, roleService *RoleService, userService *UserService) *GroupService {
+	return &GroupService{
+		groupRepo:   groupRepo,
+		roleService: roleService,
+		userService: userService,
+	}
+}
+
+// GetGroups lists the groups of the actor's organization
+func (s *GroupService) GetGroups(actor *model.User) ([]model.GroupResponse, error) {
+	groups, err := s.groupRepo.FindAll(actor.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := []model.GroupResponse{}
+	for i := range groups {
+		response = append(response, groups[i].ToResponse())
+	}
+	return response, nil
+}
+
+func (s *GroupService) GetGroup(actor *model.User, id uint) (*model.Group, error) {
+	group, err := s.groupRepo.FindByID(actor.TenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if group == nil {
+		return nil, ErrGroupNotFound
+	}
+	return group, nil
+}
+
+// CreateGroup creates a group in the actor's organization. The actor can only
+// grant permissions they hold themselves.
+func (s *GroupService) CreateGroup(actor *model.User, name, description string, permissionNames []string) (*model.Group, error) {
+	if actor.TenantID == 0 {
+		return nil, ErrOrganizationNotFound
+	}
+	if err := s.ensureNameAvailable(actor.TenantID, name, 0); err != nil {
+		return nil, err
+	}
+
+	permissions, err := s.grantablePermissions(actor, permissionNames)
+	if err != nil {
+		return nil, err
+	}
+
+	group := &model.Group{
+		OrganizationID: actor.TenantID,
+		Name:           name,
+		Description:    description,
+		Permissions:    permissions,
+	}
+	if err := s.groupRepo.Create(group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// UpdateGroup changes the name, description and/or permission set of a group.
+// Nil arguments are left unchanged.
+func (s *GroupService) UpdateGroup(actor *model.User, id uint, name, description *string, permissionNames []string) (*model.Group, error) {
+	group, err := s.GetGroup(actor, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if name != nil && *name != group.Name {
+		if err := s.ensureNameAvailable(group.OrganizationID, *name, group.ID); err != nil {
+			return nil, err
+		}
+		group.Name = *name
+	}
+	if description != nil {
+		group.Description = *description
+	}
+	if permissionNames != nil {
+		permissions, err := s.grantablePermissions(actor, permissionNames)
+		if err != nil {
+			return nil, err
+		}
+		group.Permissions = permissions
+	}
+
+	if err := s.groupRepo.Update(group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// DeleteGroup removes a group. Its members lose the permissions it granted.
+func (s *GroupService) DeleteGroup(actor *model.User, id uint) error {
+	group, err := s.GetGroup(actor, id)
+	if err != nil {
+		return err
+	}
+	return s.groupRepo.Delete(group.ID)
+}
+
+func (s *GroupService) GetMembers(actor *model.User, id uint, page, limit int) ([]model.UserResponse, int64, error) {
+	group, err := s.GetGroup(actor, id)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	users, total, err := s.groupRepo.FindMembers(group.ID, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	response := []model.UserResponse{}
+	for i := range users {
+		response = append(response, users[i].ToResponse())
+	}
+	return response, total, nil
+}
+
+// AddMember puts a member of the organization into the group. Since this grants the
+// group's permissions, the actor must hold all of them.
+func (s *GroupService) AddMember(actor *model.User, id, userID uint) error {
+	group, err := s.GetGroup(actor, id)
+	if err != nil {
+		return err
+	}
+	if _, err := s.grantablePermissions(actor, group.PermissionNames()); err != nil {
+		return err
+	}
+
+	membership, err := s.userService.GetMembership(group.OrganizationID, userID)
+	if err != nil {
+		return err
+	}
+	if membership == nil {
+		return ErrMemberNotFound
+	}
+
+	isMember, err := s.groupRepo.IsMember(group.ID, userID)
+	if err != nil {
+		return err
+	}
+	if isMember {
+		return errors.New("user is already in the group")
+	}
+	return s.groupRepo.AddMember(group.ID, userID)
+}
+
+func (s *GroupService) RemoveMember(actor *model.User, id, userID uint) error {
+	group, err := s.GetGroup(actor, id)
+	if err != nil {
+		return err
+	}
+
+	isMember, err := s.groupRepo.IsMember(group.ID, userID)
+	if err != nil {
+		return err
+	}
+	if !isMember {
+		return ErrMemberNotFound
+	}
+	return s.groupRepo.RemoveMember(group.ID, userID)
+}
+
+func (s *GroupService) ensureNameAvailable(organizationID uint, name string, excludeID uint) error {
+	existing, err := s.groupRepo.FindByName(organizationID, name)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.ID != excludeID {
+		return errors.New("group already exists")
+	}
+	return nil
+}
+
+// grantablePermissions resolves permission names, refusing any the actor does not hold.
+// Otherwise a group manager could escalate their own privileges through a group.
+func (s *GroupService) grantablePermissions(actor *model.User, names []string) ([]model.Permission, error) {
+	permissions, err := s.roleService.resolvePermissions(names)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return permissions, nil
+	}
+
+	allowed, err := s.roleService.UserHasPermissions(actor, names...)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrForbidden
+	}
+	return permissions, nil
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/config"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/model"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/repository"
+)
+
+type InvitationService struct {
+	invitationRepo repository.InvitationRepository
+	membershipRepo repository.MembershipRepository
+	userService    *UserService
+	tokenService   *TokenService
+	emailService   *EmailService
+	config         *config.Config
+}
+
+func NewInvitationService(
+	invitationRepo repository.InvitationRepository,
+	membershipRepo repository.MembershipRepository,
+	userService *UserService,
+	tokenService *TokenService,
+	emailService *EmailService,
+	cfg *config.Config,
+) *InvitationService {
+	return &InvitationService{
+		invitationRepo: invitationRepo,
+		membershipRepo: membershipRepo,
+		userService:    userService,
+		tokenService:   tokenService,
+		emailService:   emailService,
+		config:         cfg,
+	}
+}
+
+// Invite creates a pending user with the given role as a member of the organization
+// and emails them an invite link. Choosing the role requires the 'roles:assign'
+// permission. Nothing is left behind if it fails, so the address can be invited again.
+func (s *InvitationService) Invite(actor *model.User, organizationID uint, name, email, role, orgRole string) (*model.Invitation, error) {
+	if role != "" {
+		if err := s.userService.requireRoleAssignment(actor); err != nil {
+			return nil, err
+		}
+	}
+	user, err := s.userService.NewPendingUser(name, email, role)
+	if err != nil {
+		return nil, err
+	}
+
+	invitation := &model.Invitation{
+		User:           *user,
+		InvitedByID:    actor.ID,
+		OrganizationID: organizationID,
+	}
+	if err := s.invitationRepo.Create(invitation, orgRole); err != nil {
+		return nil, err
+	}
+
+	if err := s.send(invitation); err != nil {
+		if cleanupErr := s.remove(invitation); cleanupErr != nil {
+			return nil, errors.Join(err, cleanupErr)
+		}
+		return nil, err
+	}
+	return invitation, nil
+}
+
+// GetPendingInvitations lists the pending invitations of the actor's organization
+func (s *InvitationService) GetPendingInvitations(actor *model.User, page, limit int) ([]model.InvitationResponse, int64, error) {
+	invitations, total, err := s.invitationRepo.FindAllPending(actor.TenantID, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	response := []model.InvitationResponse{}
+	for _, i := range invitations {
+		response = append(response, i.ToResponse())
+	}
+	return response, total, nil
+}
+
+// Resend replaces the invite token with a fresh one and restarts the expiry window
+func (s *InvitationService) Resend(actor *model.User, id uint) (*model.Invitation, error) {
+	invitation, err := s.findPending(actor, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.send(invitation); err != nil {
+		return nil, err
+	}
+	return invitation, nil
+}
+
+// Revoke cancels a pending invitation and removes the pending user from the organization.
+// The pending user is purged unless another organization has added them meanwhile.
+func (s *InvitationService) Revoke(actor *model.User, id uint) error {
+	invitation, err := s.findPending(actor, id)
+	if err != nil {
+		return err
+	}
+	return s.remove(invitation)
+}
+
+// remove deletes an invitation with its tokens and membership, and purges the
+// pending user unless they belong to another organization
+func (s *InvitationService) remove(invitation *model.Invitation) error {
+	if err := s.tokenService.DeleteUserTokens(invitation.UserID, model.TokenTypeInvite); err != nil {
+		return err
+	}
+	if err := s.invitationRepo.Delete(invitation.ID); err != nil {
+		return err
+	}
+	if err := s.membershipRepo.Delete(invitation.OrganizationID, invitation.UserID); err != nil {
+		return err
+	}
+
+	remaining, err := s.membershipRepo.FindByUserID(invitation.UserID)
+	if err != nil {
+		return err
+	}
+	if len(remaining) > 0 {
+		return nil
+	}
+	return s.userService.PurgeUser(invitation.UserID)
+}
+
+// Accept sets the invitee's password and marks the invitation as used.
+// The email counts as verified because the invite link was delivered to it.
+func (s *InvitationService) Accept(inviteToken, password string) (*model.User, error) {
+	storedToken, err := s.tokenService.VerifyStoredToken(inviteToken, model.TokenTypeInvite)
+	if err != nil {
+		return nil, errors.New("invalid or expired invitation")
+	}
+
+	invitation, err := s.invitationRepo.FindPendingByUserID(storedToken.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if invitation == nil {
+		return nil, errors.New("invalid or expired invitation")
+	}
+
+	user := &invitation.User
+	if user.Status == model.UserStatusBanned {
+		return nil, ErrAccountBanned
+	}
+	user.IsEmailVerified = true
+	user.Status = model.UserStatusActive
+	if err := s.userService.SetPassword(user, password); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	invitation.AcceptedAt = &now
+	if err := s.invitationRepo.Update(invitation); err != nil {
+		return nil, err
+	}
+	if err := s.tokenService.DeleteUserTokens(user.ID, model.TokenTypeInvite); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *InvitationService) findPending(actor *model.User, id uint) (*model.Invitation, error) {
+	invitation, err := s.invitationRepo.FindPendingByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if invitation == nil || invitation.OrganizationID != actor.TenantID {
+		return nil, errors.New("invitation not found")
+	}
+	return invitation, nil
+}
+
+// send issues a new invite token, invalidating earlier ones, and emails it
+func (s *InvitationService) send(invitation *model.Invitation) error {
+	if err := s.tokenService.DeleteUserTokens(invitation.UserID, model.TokenTypeInvite); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	invitation.Expires = now.Add(s.config.InvitationExpiration)
+	invitation.LastSentAt = now
+
+	inviteToken, err := s.tokenService.GenerateInviteToken(&invitation.User, invitation.Expires)
+	if err != nil {
+		return err
+	}
+	if err := s.invitationRepo.Update(invitation); err != nil {
+		return err
+	}
+
+	return s.emailService.SendInvitationEmail(invitation.User.Email, invitation.User.Name, inviteToken, invitation.Expires)
+}
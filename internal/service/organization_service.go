@@ -0,0 +1,322 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/model"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/repository"
+)
+
+type OrganizationService struct {
+	organizationRepo  repository.OrganizationRepository
+	membershipRepo    repository.MembershipRepository
+	userService       *UserService
+	invitationService *InvitationService
+}
+
+func NewOrganizationService(
+	organizationRepo repository.OrganizationRepository,
+	membershipRepo repository.MembershipRepository,
+	userService *UserService,
+	invitationService *InvitationService,
+) *OrganizationService {
+	return &OrganizationService{
+		organizationRepo:  organizationRepo,
+		membershipRepo:    membershipRepo,
+		userService:       userService,
+		invitationService: invitationService,
+	}
+}
+
+// CreateOrganization creates an organization owned by the user
+func (s *OrganizationService) CreateOrganization(owner *model.User, name string) (*model.OrganizationResponse, error) {
+	organization := &model.Organization{Name: name}
+	if err := s.organizationRepo.Create(organization, owner.ID); err != nil {
+		return nil, err
+	}
+
+	response := model.OrganizationResponse{
+		ID:        organization.ID,
+		Name:      organization.Name,
+		Role:      model.OrgRoleOwner,
+		Current:   organization.ID == owner.TenantID,
+		CreatedAt: organization.CreatedAt,
+	}
+	return &response, nil
+}
+
+// CreatePersonalOrganization gives a newly registered user an organization of their own
+func (s *OrganizationService) CreatePersonalOrganization(user *model.User) error {
+	_, err := s.CreateOrganization(user, fmt.Sprintf("%s's Organization", user.Name))
+	return err
+}
+
+// GetOrganizations lists the organizations the user belongs to
+func (s *OrganizationService) GetOrganizations(user *model.User) ([]model.OrganizationResponse, error) {
+	memberships, err := s.membershipRepo.FindByUserID(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := []model.OrganizationResponse{}
+	for i := range memberships {
+		response = append(response, memberships[i].ToOrganizationResponse(user.TenantID))
+	}
+	return response, nil
+}
+
+func (s *OrganizationService) GetOrganization(user *model.User, organizationID uint) (*model.OrganizationResponse, error) {
+	membership, err := s.membership(organizationID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	response := membership.ToOrganizationResponse(user.TenantID)
+	return &response, nil
+}
+
+// UpdateOrganization renames an organization. Requires the owner or admin organization role.
+func (s *OrganizationService) UpdateOrganization(user *model.User, organizationID uint, name string) (*model.OrganizationResponse, error) {
+	membership, err := s.membership(organizationID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireOrgRole(membership, model.OrgRoleOwner, model.OrgRoleAdmin); err != nil {
+		return nil, err
+	}
+
+	membership.Organization.Name = name
+	if err := s.organizationRepo.Update(&membership.Organization); err != nil {
+		return nil, err
+	}
+	response := membership.ToOrganizationResponse(user.TenantID)
+	return &response, nil
+}
+
+// DeleteOrganization removes an organization and all of its memberships. Only owners may do this.
+func (s *OrganizationService) DeleteOrganization(user *model.User, organizationID uint) error {
+	membership, err := s.membership(organizationID, user.ID)
+	if err != nil {
+		return err
+	}
+	if err := requireOrgRole(membership, model.OrgRoleOwner); err != nil {
+		return err
+	}
+	return s.organizationRepo.Delete(organizationID)
+}
+
+func (s *OrganizationService) GetMembers(user *model.User, organizationID uint, page, limit int) ([]model.MemberResponse, int64, error) {
+	if _, err := s.membership(organizationID, user.ID); err != nil {
+		return nil, 0, err
+	}
+
+	memberships, total, err := s.membershipRepo.FindByOrganizationID(organizationID, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	response := []model.MemberResponse{}
+	for i := range memberships {
+		response = append(response, memberships[i].ToResponse())
+	}
+	return response, total, nil
+}
+
+// InviteMember adds a user to the organization. Existing accounts join immediately;
+// unknown email addresses receive an invitation to create their account.
+func (s *OrganizationService) InviteMember(user *model.User, organizationID uint, name, email, role string) (*model.MemberResponse, error) {
+	membership, err := s.membership(organizationID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkRoleChange(membership, "", role); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.userService.GetUserByEmail(email)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing == nil {
+		if name == "" {
+			return nil, errors.New("name is required to invite a new user")
+		}
+		invitation, err := s.invitationService.Invite(user, organizationID, name, email, "", role)
+		if err != nil {
+			return nil, err
+		}
+		existing = &invitation.User
+	} else {
+		current, err := s.membershipRepo.Find(organizationID, existing.ID)
+		if err != nil {
+			return nil, err
+		}
+		if current != nil {
+			return nil, errors.New("user is already a member")
+		}
+		if err := s.membershipRepo.Create(&model.Membership{
+			OrganizationID: organizationID,
+			UserID:         existing.ID,
+			Role:           role,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	created, err := s.membershipRepo.Find(organizationID, existing.ID)
+	if err != nil {
+		return nil, err
+	}
+	created.User = *existing
+	response := created.ToResponse()
+	return &response, nil
+}
+
+// UpdateMemberRole changes a member's organization role. Only owners may grant or
+// revoke ownership, and the last owner cannot be demoted.
+func (s *OrganizationService) UpdateMemberRole(user *model.User, organizationID, memberID uint, role string) (*model.MemberResponse, error) {
+	membership, err := s.membership(organizationID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.member(organizationID, memberID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkRoleChange(membership, target.Role, role); err != nil {
+		return nil, err
+	}
+	if target.Role == model.OrgRoleOwner && role != model.OrgRoleOwner {
+		if err := s.ensureAnotherOwner(organizationID); err != nil {
+			return nil, err
+		}
+	}
+
+	target.Role = role
+	if err := s.membershipRepo.Update(target); err != nil {
+		return nil, err
+	}
+
+	member, err := s.userService.GetUserByID(memberID)
+	if err != nil {
+		return nil, err
+	}
+	target.User = *member
+	response := target.ToResponse()
+	return &response, nil
+}
+
+// RemoveMember removes a user from the organization. Members may always leave;
+// removing others requires the owner or admin role. The last owner cannot leave.
+func (s *OrganizationService) RemoveMember(user *model.User, organizationID, memberID uint) error {
+	membership, err := s.membership(organizationID, user.ID)
+	if err != nil {
+		return err
+	}
+	target, err := s.member(organizationID, memberID)
+	if err != nil {
+		return err
+	}
+	if memberID != user.ID {
+		if err := s.checkRoleChange(membership, target.Role, ""); err != nil {
+			return err
+		}
+	}
+	if target.Role == model.OrgRoleOwner {
+		if err := s.ensureAnotherOwner(organizationID); err != nil {
+			return err
+		}
+	}
+	return s.membershipRepo.Delete(organizationID, memberID)
+}
+
+// SwitchOrganization remembers the organization as the user's current one
+func (s *OrganizationService) SwitchOrganization(user *model.User, organizationID uint) error {
+	if _, err := s.membership(organizationID, user.ID); err != nil {
+		return err
+	}
+	return s.userService.SetLastOrganization(user, organizationID)
+}
+
+// ResolveOrganizationID picks the organization a new token is issued for: the preferred
+// one if the user is still a member, otherwise the last selected one, otherwise the oldest.
+// It returns 0 when the user belongs to no organization.
+func (s *OrganizationService) ResolveOrganizationID(user *model.User, preferredID uint) (uint, error) {
+	memberships, err := s.membershipRepo.FindByUserID(user.ID)
+	if err != nil || len(memberships) == 0 {
+		return 0, err
+	}
+
+	candidates := []uint{preferredID}
+	if user.LastOrganizationID != nil {
+		candidates = append(candidates, *user.LastOrganizationID)
+	}
+	for _, id := range candidates {
+		for _, m := range memberships {
+			if id != 0 && m.OrganizationID == id {
+				return id, nil
+			}
+		}
+	}
+	return memberships[0].OrganizationID, nil
+}
+
+// membership returns the user's membership. Non-members are told the organization
+// does not exist so organization IDs cannot be probed.
+func (s *OrganizationService) membership(organizationID, userID uint) (*model.Membership, error) {
+	membership, err := s.membershipRepo.Find(organizationID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if membership == nil {
+		return nil, ErrOrganizationNotFound
+	}
+	return membership, nil
+}
+
+func (s *OrganizationService) member(organizationID, userID uint) (*model.Membership, error) {
+	membership, err := s.membershipRepo.Find(organizationID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if membership == nil {
+		return nil, ErrMemberNotFound
+	}
+	return membership, nil
+}
+
+// checkRoleChange verifies the actor may move a member from one organization role to
+// another. An empty role stands for "not a member".
+func (s *OrganizationService) checkRoleChange(actor *model.Membership, from, to string) error {
+	if to != "" && to != model.OrgRoleOwner && to != model.OrgRoleAdmin && to != model.OrgRoleMember {
+		return fmt.Errorf("unknown organization role: %s", to)
+	}
+	if err := requireOrgRole(actor, model.OrgRoleOwner, model.OrgRoleAdmin); err != nil {
+		return err
+	}
+	if (from == model.OrgRoleOwner || to == model.OrgRoleOwner) && actor.Role != model.OrgRoleOwner {
+		return ErrForbidden
+	}
+	return nil
+}
+
+func (s *OrganizationService) ensureAnotherOwner(organizationID uint) error {
+	owners, err := s.membershipRepo.CountByRole(organizationID, model.OrgRoleOwner)
+	if err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return ErrLastOwner
+	}
+	return nil
+}
+
+func requireOrgRole(membership *model.Membership, roles ...string) error {
+	for _, role := range roles {
+		if membership.Role == role {
+			return nil
+		}
+	}
+	return ErrForbidden
+}
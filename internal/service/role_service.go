@@ -0,0 +1,304 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/model"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/repository"
+)
+
+type RoleService struct {
+	roleRepo       repository.RoleRepository
+	permissionRepo repository.PermissionRepository
+	userRepo       repository.UserRepository
+	groupRepo      repository.GroupRepository
+
+	// cache maps role name -> granted permission names. Permission checks run on
+	// every protected request, so they are served from memory and the cache is
+	// dropped whenever a role changes.
+	mu         sync.RWMutex
+	cache      map[string]map[string]bool
+	generation uint64
+}
+
+func NewRoleService(roleRepo repository.RoleRepository, permissionRepo repository.PermissionRepository, userRepo repository.UserRepository, groupRepo repository.GroupRepository) *RoleService {
+	return &RoleService{
+		roleRepo:       roleRepo,
+		permissionRepo: permissionRepo,
+		userRepo:       userRepo,
+		groupRepo:      groupRepo,
+	}
+}
+
+// HasPermissions reports whether the role grants every one of the given permissions
+func (s *RoleService) HasPermissions(roleName string, permissions ...string) (bool, error) {
+	granted, err := s.rolePermissions(roleName)
+	if err != nil {
+		return false, err
+	}
+	for _, p := range permissions {
+		if !granted[p] {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// PermissionsOf lists the permissions granted by the role, sorted by name
+func (s *RoleService) PermissionsOf(roleName string) ([]string, error) {
+	granted, err := s.rolePermissions(roleName)
+	if err != nil {
+		return nil, err
+	}
+	permissions := make([]string, 0, len(granted))
+	for p := range granted {
+		permissions = append(permissions, p)
+	}
+	sort.Strings(permissions)
+	return permissions, nil
+}
+
+// UserHasPermissions reports whether the user's role, together with the groups they
+// belong to in the organization they act in, grants every one of the given permissions
+func (s *RoleService) UserHasPermissions(user *model.User, permissions ...string) (bool, error) {
+	granted, err := s.rolePermissions(user.Role)
+	if err != nil {
+		return false, err
+	}
+
+	var missing []string
+	for _, p := range permissions {
+		if !granted[p] {
+			missing = append(missing, p)
+		}
+	}
+	if len(missing) == 0 {
+		return true, nil
+	}
+	if user.TenantID == 0 {
+		return false, nil
+	}
+
+	// Group permissions are not cached, so they are only looked up when the role falls short
+	groupPermissions, err := s.groupRepo.PermissionsOf(user.TenantID, user.ID)
+	if err != nil {
+		return false, err
+	}
+	fromGroups := make(map[string]bool, len(groupPermissions))
+	for _, p := range groupPermissions {
+		fromGroups[p] = true
+	}
+	for _, p := range missing {
+		if !fromGroups[p] {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// UserPermissions lists the permissions granted to the user by their role and by the
+// groups they belong to in the organization they act in, sorted by name
+func (s *RoleService) UserPermissions(user *model.User) ([]string, error) {
+	granted, err := s.rolePermissions(user.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	all := make(map[string]bool, len(granted))
+	for p := range granted {
+		all[p] = true
+	}
+	if user.TenantID != 0 {
+		groupPermissions, err := s.groupRepo.PermissionsOf(user.TenantID, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range groupPermissions {
+			all[p] = true
+		}
+	}
+
+	permissions := make([]string, 0, len(all))
+	for p := range all {
+		permissions = append(permissions, p)
+	}
+	sort.Strings(permissions)
+	return permissions, nil
+}
+
+// RoleExists reports whether a role with the given name is defined
+func (s *RoleService) RoleExists(roleName string) (bool, error) {
+	granted, err := s.rolePermissions(roleName)
+	return granted != nil, err
+}
+
+func (s *RoleService) rolePermissions(roleName string) (map[string]bool, error) {
+	s.mu.RLock()
+	cache, generation := s.cache, s.generation
+	s.mu.RUnlock()
+
+	if cache == nil {
+		roles, err := s.roleRepo.FindAll()
+		if err != nil {
+			return nil, err
+		}
+
+		cache = make(map[string]map[string]bool, len(roles))
+		for _, role := range roles {
+			granted := make(map[string]bool, len(role.Permissions))
+			for _, p := range role.Permissions {
+				granted[p.Name] = true
+			}
+			cache[role.Name] = granted
+		}
+
+		// Only publish if no role changed while loading, otherwise the data may be stale
+		s.mu.Lock()
+		if s.generation == generation {
+			s.cache = cache
+		}
+		s.mu.Unlock()
+	}
+
+	return cache[roleName], nil
+}
+
+func (s *RoleService) invalidateCache() {
+	s.mu.Lock()
+	s.cache = nil
+	s.generation++
+	s.mu.Unlock()
+}
+
+func (s *RoleService) GetRoles() ([]model.RoleResponse, error) {
+	roles, err := s.roleRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	response := []model.RoleResponse{}
+	for _, r := range roles {
+		response = append(response, r.ToResponse())
+	}
+	return response, nil
+}
+
+func (s *RoleService) GetRoleByID(id uint) (*model.Role, error) {
+	role, err := s.roleRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, errors.New("role not found")
+	}
+	return role, nil
+}
+
+func (s *RoleService) GetPermissions() ([]model.Permission, error) {
+	return s.permissionRepo.FindAll()
+}
+
+func (s *RoleService) CreateRole(name, description string, permissionNames []string) (*model.Role, error) {
+	existing, err := s.roleRepo.FindByName(name)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, errors.New("role already exists")
+	}
+
+	permissions, err := s.resolvePermissions(permissionNames)
+	if err != nil {
+		return nil, err
+	}
+
+	role := &model.Role{
+		Name:        name,
+		Description: description,
+		Permissions: permissions,
+	}
+	if err := s.roleRepo.Create(role); err != nil {
+		return nil, err
+	}
+
+	s.invalidateCache()
+	return role, nil
+}
+
+// UpdateRole changes the description and/or permission set of a role.
+// Role names are immutable because users reference roles by name.
+func (s *RoleService) UpdateRole(id uint, description *string, permissionNames []string) (*model.Role, error) {
+	role, err := s.GetRoleByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if description != nil {
+		role.Description = *description
+	}
+
+	if permissionNames != nil {
+		if role.Name == model.RoleAdmin {
+			return nil, errors.New("the admin role always has every permission")
+		}
+		permissions, err := s.resolvePermissions(permissionNames)
+		if err != nil {
+			return nil, err
+		}
+		role.Permissions = permissions
+	}
+
+	if err := s.roleRepo.Update(role); err != nil {
+		return nil, err
+	}
+
+	s.invalidateCache()
+	return role, nil
+}
+
+func (s *RoleService) DeleteRole(id uint) error {
+	role, err := s.GetRoleByID(id)
+	if err != nil {
+		return err
+	}
+	if role.IsSystem {
+		return errors.New("built-in roles cannot be deleted")
+	}
+
+	count, err := s.userRepo.Global().CountByRole(role.Name)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("role is still assigned to %d user(s)", count)
+	}
+
+	if err := s.roleRepo.Delete(role.ID); err != nil {
+		return err
+	}
+
+	s.invalidateCache()
+	return nil
+}
+
+// resolvePermissions loads permissions by name and rejects unknown ones
+func (s *RoleService) resolvePermissions(names []string) ([]model.Permission, error) {
+	permissions, err := s.permissionRepo.FindByNames(names)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]bool, len(permissions))
+	for _, p := range permissions {
+		found[p.Name] = true
+	}
+	for _, name := range names {
+		if !found[name] {
+			return nil, fmt.Errorf("unknown permission: %s", name)
+		}
+	}
+	return permissions, nil
+}
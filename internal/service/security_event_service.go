@@ -0,0 +1,64 @@
+package service
+
+import (
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/model"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/repository"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/pkg/logger"
+)
+
+// ClientInfo describes the HTTP client that triggered an action
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+type SecurityEventService struct {
+	eventRepo repository.SecurityEventRepository
+}
+
+func NewSecurityEventService(eventRepo repository.SecurityEventRepository) *SecurityEventService {
+	return &SecurityEventService{
+		eventRepo: eventRepo,
+	}
+}
+
+// Record stores a security event. Failures are logged rather than returned so
+// that auditing never blocks the authentication flow itself.
+func (s *SecurityEventService) Record(eventType string, userID uint, email string, client ClientInfo) {
+	s.create(&model.SecurityEvent{
+		UserID:    userID,
+		Type:      eventType,
+		Email:     email,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+	})
+}
+
+// RecordAction stores a security event caused by another user, such as an admin changing a role
+func (s *SecurityEventService) RecordAction(eventType string, userID, actorID uint, details string, client ClientInfo) {
+	s.create(&model.SecurityEvent{
+		UserID:    userID,
+		Type:      eventType,
+		ActorID:   actorID,
+		Details:   details,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+	})
+}
+
+func (s *SecurityEventService) create(event *model.SecurityEvent) {
+	if err := s.eventRepo.Create(event); err != nil {
+		logger.Error("failed to record security event %s for user %d: %v", event.Type, event.UserID, err)
+	}
+}
+
+func (s *SecurityEventService) GetEvents(filter repository.SecurityEventFilter) ([]model.SecurityEvent, int64, error) {
+	events, total, err := s.eventRepo.FindAll(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	if events == nil {
+		events = []model.SecurityEvent{}
+	}
+	return events, total, nil
+}
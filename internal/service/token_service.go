@@ -1,7 +1,10 @@
 package service
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -10,6 +13,9 @@ import (
 	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/repository"
 )
 
+// revokeSessionsTokenTTL bounds how long a "this wasn't me" link stays usable
+const revokeSessionsTokenTTL = 7 * 24 * time.Hour
+
 type TokenService struct {
 	tokenRepo repository.TokenRepository
 	config    *config.Config
@@ -23,15 +29,43 @@ type AuthTokens struct {
 type AuthTokenResponse struct {
 	Token   string    `json:"token"`
 	Expires time.Time `json:"expires"`
+	Scope   string    `json:"scope,omitempty"`
 }
 
 type Claims struct {
 	UserID uint   `json:"user_id"`
 	Role   string `json:"role"`
 	Type   string `json:"type"`
+	// AuthTime is when the user last proved their identity with a password.
+	// It survives token refreshes so sensitive operations can demand a recent login.
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
+	// Version must match the user's TokenVersion for the access token to be accepted
+	Version uint `json:"ver,omitempty"`
+	// Scope is the space-separated list of scopes the access token may be used for
+	Scope string `json:"scope,omitempty"`
+	// OrgID is the organization (tenant) the token acts in
+	OrgID uint `json:"org_id,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// HasScopes reports whether the token was granted every one of the given scopes
+func (c *Claims) HasScopes(scopes ...string) bool {
+	granted := strings.Fields(c.Scope)
+	for _, scope := range scopes {
+		found := false
+		for _, g := range granted {
+			if g == scope {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
 func NewTokenService(tokenRepo repository.TokenRepository, cfg *config.Config) *TokenService {
 	return &TokenService{
 		tokenRepo: tokenRepo,
@@ -40,28 +74,62 @@ func NewTokenService(tokenRepo repository.TokenRepository, cfg *config.Config) *
 }
 
 func (s *TokenService) GenerateToken(userID uint, role, tokenType string, expires time.Time) (string, error) {
-	claims := &Claims{
+	return s.signToken(&Claims{
 		UserID: userID,
 		Role:   role,
 		Type:   tokenType,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expires),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
+	}, expires)
+}
+
+// signToken fills in the registered claims and signs the token
+func (s *TokenService) signToken(claims *Claims, expires time.Time) (string, error) {
+	// A random ID keeps tokens unique even when issued within the same second,
+	// so rotating or resending a token always invalidates the previous one.
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", err
+	}
+
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        hex.EncodeToString(jti),
+		ExpiresAt: jwt.NewNumericDate(expires),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(s.config.JWTSecret))
 }
 
-func (s *TokenService) GenerateAuthTokens(user *model.User) (*AuthTokens, error) {
+// GenerateAuthTokens issues an access/refresh pair. authTime is the moment the user
+// last authenticated with their password; pass a zero time when it is unknown.
+// scopes limit what the access token may be used for and orgID selects its tenant.
+func (s *TokenService) GenerateAuthTokens(user *model.User, authTime time.Time, scopes []string, orgID uint) (*AuthTokens, error) {
+	var authTimeClaim *jwt.NumericDate
+	if !authTime.IsZero() {
+		authTimeClaim = jwt.NewNumericDate(authTime)
+	}
+
 	accessExpires := time.Now().Add(s.config.JWTAccessExpirationMinutes)
-	accessToken, err := s.GenerateToken(user.ID, user.Role, model.TokenTypeAccess, accessExpires)
+	accessToken, err := s.signToken(&Claims{
+		UserID:   user.ID,
+		Role:     user.Role,
+		Type:     model.TokenTypeAccess,
+		AuthTime: authTimeClaim,
+		Version:  user.TokenVersion,
+		Scope:    strings.Join(scopes, " "),
+		OrgID:    orgID,
+	}, accessExpires)
 	if err != nil {
 		return nil, err
 	}
 
 	refreshExpires := time.Now().Add(s.config.JWTRefreshExpirationDays)
-	refreshToken, err := s.GenerateToken(user.ID, user.Role, model.TokenTypeRefresh, refreshExpires)
+	refreshToken, err := s.signToken(&Claims{
+		UserID:   user.ID,
+		Role:     user.Role,
+		Type:     model.TokenTypeRefresh,
+		AuthTime: authTimeClaim,
+		OrgID:    orgID,
+	}, refreshExpires)
 	if err != nil {
 		return nil, err
 	}
@@ -78,11 +146,29 @@ func (s *TokenService) GenerateAuthTokens(user *model.User) (*AuthTokens, error)
 	}
 
 	return &AuthTokens{
-		Access:  AuthTokenResponse{Token: accessToken, Expires: accessExpires},
+		Access:  AuthTokenResponse{Token: accessToken, Expires: accessExpires, Scope: strings.Join(scopes, " ")},
 		Refresh: AuthTokenResponse{Token: refreshToken, Expires: refreshExpires},
 	}, nil
 }
 
+// GenerateScopedToken issues a standalone access token limited to the given scopes.
+// It has no refresh token and no auth_time, so it cannot be used for sensitive operations.
+func (s *TokenService) GenerateScopedToken(user *model.User, scopes []string, orgID uint, expires time.Time) (*AuthTokenResponse, error) {
+	scope := strings.Join(scopes, " ")
+	token, err := s.signToken(&Claims{
+		UserID:  user.ID,
+		Role:    user.Role,
+		Type:    model.TokenTypeAccess,
+		Version: user.TokenVersion,
+		Scope:   scope,
+		OrgID:   orgID,
+	}, expires)
+	if err != nil {
+		return nil, err
+	}
+	return &AuthTokenResponse{Token: token, Expires: expires, Scope: scope}, nil
+}
+
 func (s *TokenService) VerifyToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		return []byte(s.config.JWTSecret), nil
@@ -105,4 +191,86 @@ func (s *TokenService) DeleteRefreshToken(token string) error {
 
 func (s *TokenService) FindRefreshToken(token string) (*model.Token, error) {
 	return s.tokenRepo.FindOne(token, model.TokenTypeRefresh, false)
-}
\ No newline at end of file
+}
+
+// generateStoredToken signs a single-purpose token and persists it so it can be revoked
+func (s *TokenService) generateStoredToken(user *model.User, tokenType string, expires time.Time) (string, error) {
+	token, err := s.GenerateToken(user.ID, user.Role, tokenType, expires)
+	if err != nil {
+		return "", err
+	}
+
+	err = s.tokenRepo.Create(&model.Token{
+		Token:   token,
+		UserID:  user.ID,
+		Type:    tokenType,
+		Expires: expires,
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (s *TokenService) GenerateResetPasswordToken(user *model.User) (string, error) {
+	return s.generateStoredToken(user, model.TokenTypeResetPassword, time.Now().Add(s.config.JWTResetPasswordExpiration))
+}
+
+func (s *TokenService) GenerateRevokeSessionsToken(user *model.User) (string, error) {
+	return s.generateStoredToken(user, model.TokenTypeRevokeSessions, time.Now().Add(revokeSessionsTokenTTL))
+}
+
+func (s *TokenService) GenerateInviteToken(user *model.User, expires time.Time) (string, error) {
+	return s.generateStoredToken(user, model.TokenTypeInvite, expires)
+}
+
+// GenerateEmailChangeTokens issues the confirmation token for the new address and
+// the cancellation token for the old one. Both expire with the reset password window.
+func (s *TokenService) GenerateEmailChangeTokens(user *model.User) (confirmToken, cancelToken string, err error) {
+	expires := time.Now().Add(s.config.JWTResetPasswordExpiration)
+	confirmToken, err = s.generateStoredToken(user, model.TokenTypeEmailChange, expires)
+	if err != nil {
+		return "", "", err
+	}
+	cancelToken, err = s.generateStoredToken(user, model.TokenTypeEmailChangeCancel, expires)
+	if err != nil {
+		return "", "", err
+	}
+	return confirmToken, cancelToken, nil
+}
+
+// VerifyStoredToken checks the signature and type of a token and that it is still stored and not blacklisted
+func (s *TokenService) VerifyStoredToken(token, tokenType string) (*model.Token, error) {
+	claims, err := s.VerifyToken(token)
+	if err != nil || claims.Type != tokenType {
+		return nil, errors.New("invalid token")
+	}
+
+	storedToken, err := s.tokenRepo.FindOne(token, tokenType, false)
+	if err != nil {
+		return nil, err
+	}
+	if storedToken == nil || storedToken.UserID != claims.UserID {
+		return nil, errors.New("token not found")
+	}
+	return storedToken, nil
+}
+
+func (s *TokenService) DeleteUserTokens(userID uint, tokenType string) error {
+	return s.tokenRepo.DeleteByUserID(userID, tokenType)
+}
+
+// PurgeUserTokens permanently removes every stored token of the user, whatever its type
+func (s *TokenService) PurgeUserTokens(userID uint) error {
+	return s.tokenRepo.PurgeByUserID(userID)
+}
+
+// ActiveSessions lists the unexpired refresh tokens of the users, i.e. where they are signed in
+func (s *TokenService) ActiveSessions(userIDs []uint) ([]model.Token, error) {
+	return s.tokenRepo.FindActive(userIDs, model.TokenTypeRefresh)
+}
+
+// RevokeAllSessions deletes every refresh token of the user, signing them out on all devices
+func (s *TokenService) RevokeAllSessions(userID uint) error {
+	return s.tokenRepo.DeleteByUserID(userID, model.TokenTypeRefresh)
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/model"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/repository"
+)
+
+// ErrInvalidBulk is returned for bulk requests that are malformed as a whole
+var ErrInvalidBulk = errors.New("invalid bulk request")
+
+// Bulk operations, each doing what the matching single-user endpoint does
+const (
+	BulkOpDelete = "delete"
+	BulkOpUpdate = "update"
+	BulkOpRole   = "role"
+	BulkOpStatus = "status"
+)
+
+// Bulk modes: atomic stores every change or none of them, bestEffort stores
+// each change that succeeds
+const (
+	BulkModeAtomic     = "atomic"
+	BulkModeBestEffort = "bestEffort"
+)
+
+// Outcomes of a bulk item. Items are skipped when an atomic request fails elsewhere.
+const (
+	BulkItemOK      = "ok"
+	BulkItemFailed  = "failed"
+	BulkItemSkipped = "skipped"
+)
+
+// bulkMaxItems caps the user IDs of one bulk request, counted across its operations
+const bulkMaxItems = 1000
+
+// BulkOperation applies one change to many users. Data holds the fields of an update,
+// Role the new role, and Status, Reason and SuspendedUntil a status change.
+type BulkOperation struct {
+	Op             string                 `json:"op" validate:"required,oneof=delete update role status" example:"status"`
+	IDs            []uint                 `json:"ids" validate:"required,min=1"`
+	Data           map[string]interface{} `json:"data,omitempty"`
+	Role           string                 `json:"role,omitempty"`
+	Status         string                 `json:"status,omitempty" validate:"omitempty,oneof=active suspended banned" example:"suspended"`
+	Reason         string                 `json:"reason,omitempty" validate:"max=500"`
+	SuspendedUntil *time.Time             `json:"suspendedUntil,omitempty"`
+}
+
+// BulkItemResult is the outcome of one operation on one user
+type BulkItemResult struct {
+	Operation int    `json:"operation"`
+	Op        string `json:"op"`
+	ID        uint   `json:"id"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+}
+
+// BulkResult reports every item of a bulk request, in request order
+type BulkResult struct {
+	Mode      string           `json:"mode"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Skipped   int              `json:"skipped"`
+	Results   []BulkItemResult `json:"results"`
+}
+
+// plannedChange links a checked change to its item in the result
+type plannedChange struct {
+	change *userChange
+	item   int
+}
+
+// ApplyBulk runs the operations in order over users of the actor's organization.
+// Every item is checked like the single-user endpoint would check it. In atomic mode
+// nothing is stored unless all items pass, and then all changes are stored in one
+// transaction; in best-effort mode each item is stored on its own.
+func (s *UserService) ApplyBulk(actor *model.User, mode string, operations []BulkOperation, client ClientInfo) (*BulkResult, error) {
+	if mode == "" {
+		mode = BulkModeAtomic
+	}
+	if err := validateBulk(mode, operations); err != nil {
+		return nil, err
+	}
+	atomic := mode == BulkModeAtomic
+
+	result := &BulkResult{Mode: mode, Results: []BulkItemResult{}}
+	repo := s.tenantUsers(actor)
+	// Users are loaded once, so later operations see the changes of earlier ones
+	users := map[uint]*model.User{}
+	deleted := map[uint]bool{}
+	var planned []plannedChange
+	var adminsLeaving int64
+	failed := false
+
+	for i, operation := range operations {
+		for _, id := range operation.IDs {
+			item := BulkItemResult{Operation: i, Op: operation.Op, ID: id, Status: BulkItemOK}
+
+			change, err := s.planBulkItem(actor, repo, users, deleted, operation, id, adminsLeaving, client)
+			if err == nil && !atomic {
+				err = s.apply(repo, change)
+			}
+			if err != nil {
+				// A user left half-changed by a failed check is read again when needed
+				delete(users, id)
+				item.Status, item.Error = BulkItemFailed, err.Error()
+				failed = true
+				result.Results = append(result.Results, item)
+				continue
+			}
+
+			if change.delete {
+				deleted[id] = true
+			}
+			if atomic {
+				if change.demotesAdmin {
+					adminsLeaving++
+				}
+				planned = append(planned, plannedChange{change: change, item: len(result.Results)})
+			}
+			result.Results = append(result.Results, item)
+		}
+	}
+
+	if atomic {
+		if failed {
+			for i := range result.Results {
+				if result.Results[i].Status == BulkItemOK {
+					result.Results[i].Status = BulkItemSkipped
+					result.Results[i].Error = "not applied because other items failed"
+				}
+			}
+		} else {
+			s.storeBulk(repo, planned, result)
+		}
+	}
+
+	for _, item := range result.Results {
+		switch item.Status {
+		case BulkItemOK:
+			result.Succeeded++
+		case BulkItemFailed:
+			result.Failed++
+		case BulkItemSkipped:
+			result.Skipped++
+		}
+	}
+	return result, nil
+}
+
+func validateBulk(mode string, operations []BulkOperation) error {
+	if mode != BulkModeAtomic && mode != BulkModeBestEffort {
+		return fmt.Errorf("%w: unknown mode %q (use %s or %s)", ErrInvalidBulk, mode, BulkModeAtomic, BulkModeBestEffort)
+	}
+	if len(operations) == 0 {
+		return fmt.Errorf("%w: no operations", ErrInvalidBulk)
+	}
+
+	items := 0
+	for i, operation := range operations {
+		items += len(operation.IDs)
+		switch {
+		case len(operation.IDs) == 0:
+			return fmt.Errorf("%w: operation %d has no ids", ErrInvalidBulk, i)
+		case operation.Op == BulkOpUpdate && len(operation.Data) == 0:
+			return fmt.Errorf("%w: operation %d needs data", ErrInvalidBulk, i)
+		case operation.Op == BulkOpRole && operation.Role == "":
+			return fmt.Errorf("%w: operation %d needs a role", ErrInvalidBulk, i)
+		case operation.Op == BulkOpStatus && operation.Status == "":
+			return fmt.Errorf("%w: operation %d needs a status", ErrInvalidBulk, i)
+		}
+	}
+	if items > bulkMaxItems {
+		return fmt.Errorf("%w: %d items exceed the limit of %d", ErrInvalidBulk, items, bulkMaxItems)
+	}
+	return nil
+}
+
+// planBulkItem checks one operation on one user, reusing the user loaded by
+// earlier items of the same request
+func (s *UserService) planBulkItem(actor *model.User, repo repository.UserRepository, users map[uint]*model.User, deleted map[uint]bool,
+	operation BulkOperation, id uint, adminsLeaving int64, client ClientInfo) (*userChange, error) {
+	if deleted[id] {
+		return nil, ErrUserNotFound
+	}
+	user, ok := users[id]
+	if !ok {
+		var err error
+		if user, err = findUser(repo, id); err != nil {
+			return nil, err
+		}
+		users[id] = user
+	}
+
+	switch operation.Op {
+	case BulkOpDelete:
+		return s.planDelete(actor, user)
+	case BulkOpUpdate:
+		// Update data is read like the merge patch of a single-user PATCH
+		patch, err := json.Marshal(operation.Data)
+		if err != nil {
+			return nil, err
+		}
+		return s.planUpdate(actor, user, PatchFormatMerge, patch, client)
+	case BulkOpRole:
+		return s.planRoleChange(actor, user, operation.Role, adminsLeaving, client)
+	case BulkOpStatus:
+		return s.planStatusChange(actor, user, operation.Status, operation.Reason, operation.SuspendedUntil, client)
+	}
+	return nil, fmt.Errorf("%w: unknown operation %q", ErrInvalidBulk, operation.Op)
+}
+
+// storeBulk stores the changes of an atomic request in one transaction through the
+// repository the users were found in, then runs their follow-ups, recording failures on the items
+func (s *UserService) storeBulk(repo repository.UserRepository, planned []plannedChange, result *BulkResult) {
+	var saves []*model.User
+	var deleted []*model.User
+	saved := map[*model.User]bool{}
+	for _, p := range planned {
+		switch {
+		case p.change.delete:
+			deleted = append(deleted, p.change.user)
+		case p.change.save && !saved[p.change.user]:
+			saved[p.change.user] = true
+			saves = append(saves, p.change.user)
+		}
+	}
+
+	if err := repo.SaveAll(saves, deleted); err != nil {
+		for _, p := range planned {
+			result.Results[p.item].Status = BulkItemFailed
+			result.Results[p.item].Error = err.Error()
+		}
+		return
+	}
+
+	for _, p := range planned {
+		if err := s.finish(p.change); err != nil {
+			result.Results[p.item].Status = BulkItemFailed
+			result.Results[p.item].Error = err.Error()
+		}
+	}
+}
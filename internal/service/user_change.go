@@ -0,0 +1,73 @@
+package service
+
+import (
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/model"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/repository"
+)
+
+// userChange is a checked change to a user that has not been stored yet. Checking
+// and storing are separate steps so bulk operations can store many changes at once.
+type userChange struct {
+	user   *model.User
+	save   bool
+	delete bool
+	// demotesAdmin is set when an admin loses the role, see ErrLastAdmin
+	demotesAdmin bool
+	// Follow-ups once the change is stored
+	revokeSessions bool
+	emailChange    bool
+	events         []func()
+}
+
+// record queues a security event for when the change is stored
+func (c *userChange) record(event func()) {
+	c.events = append(c.events, event)
+}
+
+// signOut revokes the user's sessions with the change: access tokens issued before
+// it are rejected as soon as it is stored, and refresh tokens are deleted afterwards
+func (c *userChange) signOut() {
+	c.user.TokenVersion++
+	c.revokeSessions = true
+}
+
+// checkVersion fails unless the user is at the version a client expects to change;
+// 0 expects any version
+func checkVersion(user *model.User, version uint) error {
+	if version != 0 && user.Version != version {
+		return repository.ErrVersionConflict
+	}
+	return nil
+}
+
+// apply stores a single change through the repository the user was found in and
+// runs its follow-ups
+func (s *UserService) apply(users repository.UserRepository, change *userChange) error {
+	switch {
+	case change.delete:
+		if err := users.Delete(change.user); err != nil {
+			return err
+		}
+	case change.save:
+		if err := users.Update(change.user); err != nil {
+			return err
+		}
+	}
+	return s.finish(change)
+}
+
+// finish runs the follow-ups of a stored change
+func (s *UserService) finish(change *userChange) error {
+	if change.revokeSessions {
+		if err := s.tokenService.RevokeAllSessions(change.user.ID); err != nil {
+			return err
+		}
+	}
+	for _, event := range change.events {
+		event()
+	}
+	if change.emailChange {
+		return s.startEmailChange(change.user)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/model"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/policy"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/repository"
+)
+
+// exportBatchSize is how many users an export reads from the database at a time
+const exportBatchSize = 500
+
+// UserExport is a list of users prepared for streaming to a file
+type UserExport struct {
+	service *UserService
+	subject policy.Attributes
+	repo    repository.UserRepository
+	filter  repository.UserFilter
+	view    UserView
+}
+
+// ExportUsers prepares an export of every user GetUsers would list for the filter,
+// ignoring its pagination. Nothing is read until Each is called.
+func (s *UserService) ExportUsers(actor *model.User, filter repository.UserFilter, view UserView) (*UserExport, error) {
+	if len(view.Include) > 0 {
+		return nil, fmt.Errorf("%w: include is not supported for exports", ErrInvalidFieldset)
+	}
+	subject, err := s.readableUsers(actor, &filter)
+	if err != nil {
+		return nil, err
+	}
+
+	filter.Page, filter.Limit = 0, exportBatchSize
+	filter.After, filter.Before = nil, nil
+	filter.SkipCount = true
+	return &UserExport{service: s, subject: subject, repo: s.tenantUsers(actor), filter: filter, view: view}, nil
+}
+
+// Columns returns the fields of the exported users, in the order they should appear
+func (e *UserExport) Columns() []string {
+	if len(e.view.Fields) > 0 {
+		return e.view.Fields
+	}
+	return userViewFields
+}
+
+// Each reads the users batch by batch, in the filter's sort order, and passes each
+// batch to fn as redacted responses. It stops at the first error.
+func (e *UserExport) Each(fn func(batch []map[string]interface{}) error) error {
+	filter := e.filter
+	for {
+		users, hasMore, err := e.repo.FindByCursor(filter)
+		if err != nil {
+			return err
+		}
+		batch, err := e.service.redactUsers(e.subject, users)
+		if err != nil {
+			return err
+		}
+		for _, result := range batch {
+			if len(e.view.Fields) > 0 {
+				trimFields(result, e.view.Fields)
+			}
+		}
+		if err := fn(batch); err != nil {
+			return err
+		}
+		if !hasMore {
+			return nil
+		}
+
+		// Keyset pagination continues after the last row without rereading skipped ones
+		filter.After = repository.UserCursor(&users[len(users)-1], filter.Sort)
+	}
+}
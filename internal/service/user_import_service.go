@@ -0,0 +1,402 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/config"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/model"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/repository"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/pkg/utils"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrInvalidImport is returned for import files that cannot be read at all
+	ErrInvalidImport     = errors.New("invalid import file")
+	ErrImportJobNotFound = errors.New("import job not found")
+)
+
+// importChunkSize is how many users are inserted per transaction
+const importChunkSize = 100
+
+// ImportUserRow is one user of an import file. Users without a password are
+// invited and choose one themselves.
+type ImportUserRow struct {
+	Name     string `json:"name" validate:"required,max=100"`
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"omitempty,min=8"`
+	Role     string `json:"role"`
+}
+
+// UserImportService creates many users of an organization at once from a CSV or NDJSON file
+type UserImportService struct {
+	importJobRepo     repository.ImportJobRepository
+	userRepo          repository.UserRepository
+	userService       *UserService
+	roleService       *RoleService
+	invitationService *InvitationService
+	config            *config.Config
+}
+
+func NewUserImportService(
+	importJobRepo repository.ImportJobRepository,
+	userRepo repository.UserRepository,
+	userService *UserService,
+	roleService *RoleService,
+	invitationService *InvitationService,
+	cfg *config.Config,
+) *UserImportService {
+	return &UserImportService{
+		importJobRepo:     importJobRepo,
+		userRepo:          userRepo,
+		userService:       userService,
+		roleService:       roleService,
+		invitationService: invitationService,
+		config:            cfg,
+	}
+}
+
+// parseRows reads the users of an import file. CSV files need a header row naming
+// the columns name, email and optionally password and role; NDJSON files hold one
+// JSON object per line.
+func (s *UserImportService) parseRows(format string, data []byte) ([]ImportUserRow, error) {
+	var rows []ImportUserRow
+	var err error
+	switch format {
+	case model.ImportFormatCSV:
+		rows, err = parseCSVRows(data)
+	case model.ImportFormatNDJSON:
+		rows, err = parseNDJSONRows(data)
+	case "":
+		return nil, fmt.Errorf("%w: unknown file format, pass format=csv or format=ndjson", ErrInvalidImport)
+	default:
+		return nil, fmt.Errorf("%w: unsupported format %q (use csv or ndjson)", ErrInvalidImport, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: the file contains no users", ErrInvalidImport)
+	}
+	if len(rows) > s.config.ImportMaxRows {
+		return nil, fmt.Errorf("%w: %d rows exceed the limit of %d", ErrInvalidImport, len(rows), s.config.ImportMaxRows)
+	}
+	return rows, nil
+}
+
+func parseCSVRows(data []byte) ([]ImportUserRow, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: missing CSV header", ErrInvalidImport)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		switch name {
+		case "name", "email", "password", "role":
+			columns[name] = i
+		default:
+			return nil, fmt.Errorf("%w: unknown CSV column %q (allowed: name, email, password, role)", ErrInvalidImport, name)
+		}
+	}
+	if _, ok := columns["email"]; !ok {
+		return nil, fmt.Errorf("%w: the CSV header needs an email column", ErrInvalidImport)
+	}
+
+	value := func(record []string, column string) string {
+		if i, ok := columns[column]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var rows []ImportUserRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+		}
+		rows = append(rows, ImportUserRow{
+			Name:     value(record, "name"),
+			Email:    value(record, "email"),
+			Password: value(record, "password"),
+			Role:     value(record, "role"),
+		})
+	}
+	return rows, nil
+}
+
+func parseNDJSONRows(data []byte) ([]ImportUserRow, error) {
+	var rows []ImportUserRow
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var row ImportUserRow
+		if err := json.Unmarshal([]byte(text), &row); err != nil {
+			return nil, fmt.Errorf("%w: line %d is not a JSON object", ErrInvalidImport, line)
+		}
+		row.Email = strings.TrimSpace(row.Email)
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+	return rows, nil
+}
+
+// Import creates the users of a CSV or NDJSON file as members of the actor's
+// organization. A dry run only checks the rows and is not stored. Small imports
+// finish before Import returns; larger ones continue in the background and are
+// followed with GetJob.
+func (s *UserImportService) Import(actor *model.User, format string, data []byte, dryRun bool) (*model.ImportJob, error) {
+	if actor.TenantID == 0 {
+		return nil, ErrOrganizationNotFound
+	}
+
+	rows, err := s.parseRows(format, data)
+	if err != nil {
+		return nil, err
+	}
+	results, err := s.check(actor, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &model.ImportJob{
+		OrganizationID: actor.TenantID,
+		CreatedByID:    actor.ID,
+		Status:         model.ImportJobStatusPending,
+		DryRun:         dryRun,
+		Total:          len(rows),
+	}
+	if dryRun {
+		now := time.Now()
+		job.Status = model.ImportJobStatusCompleted
+		job.CreatedAt = now
+		job.CompletedAt = &now
+		tally(job, results)
+		return job, nil
+	}
+
+	if err := s.importJobRepo.Create(job); err != nil {
+		return nil, err
+	}
+	if len(rows) > s.config.ImportSyncRows {
+		// The caller gets a snapshot; the job itself belongs to the worker from now on
+		snapshot := *job
+		go s.run(job, actor, rows, results)
+		return &snapshot, nil
+	}
+	s.run(job, actor, rows, results)
+	return job, nil
+}
+
+// GetJob returns an import job of the actor's organization
+func (s *UserImportService) GetJob(actor *model.User, id uint) (*model.ImportJob, error) {
+	job, err := s.importJobRepo.FindByID(actor.TenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		return nil, ErrImportJobNotFound
+	}
+	return job, nil
+}
+
+// FailInterruptedJobs marks jobs cut short by a restart as failed
+func (s *UserImportService) FailInterruptedJobs() (int64, error) {
+	return s.importJobRepo.FailUnfinished("the import was interrupted by a restart; rows reported as created or invited were imported")
+}
+
+// check validates every row and reports each as valid or failed
+func (s *UserImportService) check(actor *model.User, rows []ImportUserRow) ([]model.ImportRowResult, error) {
+	canAssignRoles, err := s.userService.CanAssignRoles(actor)
+	if err != nil {
+		return nil, err
+	}
+
+	// Emails already taken are looked up in one query
+	emails := make([]string, 0, len(rows))
+	for _, row := range rows {
+		if row.Email != "" {
+			emails = append(emails, row.Email)
+		}
+	}
+	taken, err := s.userRepo.Global().FindTakenEmails(emails)
+	if err != nil {
+		return nil, err
+	}
+	takenEmails := make(map[string]bool, len(taken))
+	for _, email := range taken {
+		takenEmails[strings.ToLower(email)] = true
+	}
+
+	results := make([]model.ImportRowResult, len(rows))
+	firstRow := map[string]int{}
+	for i, row := range rows {
+		result := model.ImportRowResult{Row: i + 1, Email: row.Email, Status: model.ImportRowValid}
+
+		for _, validationError := range utils.ValidateStruct(&row) {
+			result.Errors = append(result.Errors, validationError.Field+": "+validationError.Message)
+		}
+
+		key := strings.ToLower(row.Email)
+		if key != "" {
+			if first, seen := firstRow[key]; seen {
+				result.Errors = append(result.Errors, fmt.Sprintf("email: Duplicate of row %d", first))
+			} else {
+				firstRow[key] = i + 1
+				if takenEmails[key] {
+					result.Errors = append(result.Errors, "email: Email already taken")
+				}
+			}
+		}
+
+		if row.Role != "" {
+			if !canAssignRoles {
+				result.Errors = append(result.Errors, "role: Setting a role requires the '"+model.PermissionRolesAssign+"' permission")
+			} else if err := s.userService.validateRole(row.Role); err != nil {
+				result.Errors = append(result.Errors, "role: "+err.Error())
+			}
+		}
+
+		if len(result.Errors) > 0 {
+			result.Status = model.ImportRowFailed
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+// run imports the valid rows chunk by chunk and records the outcome on the job
+func (s *UserImportService) run(job *model.ImportJob, actor *model.User, rows []ImportUserRow, results []model.ImportRowResult) {
+	job.Status = model.ImportJobStatusProcessing
+	tally(job, results)
+	if err := s.importJobRepo.Update(job); err != nil {
+		log.Printf("⚠️ User import %d failed: %v", job.ID, err)
+		return
+	}
+
+	var pending []int
+	for i := range results {
+		if results[i].Status == model.ImportRowValid {
+			pending = append(pending, i)
+		}
+	}
+	for start := 0; start < len(pending); start += importChunkSize {
+		end := start + importChunkSize
+		if end > len(pending) {
+			end = len(pending)
+		}
+		s.importChunk(actor, rows, results, pending[start:end])
+
+		// Progress is saved after every chunk so it can be followed with GetJob
+		if end < len(pending) {
+			tally(job, results)
+			if err := s.importJobRepo.Update(job); err != nil {
+				log.Printf("⚠️ User import %d progress could not be saved: %v", job.ID, err)
+			}
+		}
+	}
+
+	now := time.Now()
+	job.Status = model.ImportJobStatusCompleted
+	job.CompletedAt = &now
+	tally(job, results)
+	if err := s.importJobRepo.Update(job); err != nil {
+		log.Printf("⚠️ User import %d could not be saved: %v", job.ID, err)
+	}
+}
+
+// tally records the row results and their counts on the job
+func tally(job *model.ImportJob, results []model.ImportRowResult) {
+	job.Report = encodeReport(results)
+	job.Created, job.Invited, job.Failed = 0, 0, 0
+	for _, result := range results {
+		switch result.Status {
+		case model.ImportRowCreated:
+			job.Created++
+		case model.ImportRowInvited:
+			job.Invited++
+		case model.ImportRowFailed:
+			job.Failed++
+		}
+	}
+}
+
+// importChunk creates the users of the given rows that come with a password in one
+// transaction, and invites the others
+func (s *UserImportService) importChunk(actor *model.User, rows []ImportUserRow, results []model.ImportRowResult, indexes []int) {
+	var users []*model.User
+	var created []int
+	for _, i := range indexes {
+		row := rows[i]
+		if row.Password == "" {
+			invitation, err := s.invitationService.Invite(actor, actor.TenantID, row.Name, row.Email, row.Role, model.OrgRoleMember)
+			if err != nil {
+				results[i].Status = model.ImportRowFailed
+				results[i].Errors = append(results[i].Errors, err.Error())
+				continue
+			}
+			results[i].Status = model.ImportRowInvited
+			results[i].UserID = invitation.UserID
+			continue
+		}
+
+		hashed, err := bcrypt.GenerateFromPassword([]byte(row.Password), 12)
+		if err != nil {
+			results[i].Status = model.ImportRowFailed
+			results[i].Errors = append(results[i].Errors, err.Error())
+			continue
+		}
+		role := row.Role
+		if role == "" {
+			role = model.RoleUser
+		}
+		users = append(users, &model.User{Name: row.Name, Email: row.Email, Password: string(hashed), Role: role})
+		created = append(created, i)
+	}
+	if len(users) == 0 {
+		return
+	}
+
+	// Either the whole chunk is created or none of it
+	if err := s.userRepo.CreateMembers(users, actor.TenantID, model.OrgRoleMember); err != nil {
+		for _, i := range created {
+			results[i].Status = model.ImportRowFailed
+			results[i].Errors = append(results[i].Errors, "not imported: "+err.Error())
+		}
+		return
+	}
+	for n, i := range created {
+		results[i].Status = model.ImportRowCreated
+		results[i].UserID = users[n].ID
+	}
+}
+
+func encodeReport(results []model.ImportRowResult) string {
+	data, _ := json.Marshal(results)
+	return string(data)
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/model"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/pkg/jsonpatch"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/pkg/utils"
+)
+
+// Formats of a user patch: a JSON Merge Patch (RFC 7396), which plain JSON bodies
+// are read as, or a JSON Patch (RFC 6902)
+const (
+	PatchFormatMerge = "merge"
+	PatchFormatJSON  = "json"
+)
+
+// ErrInvalidPatch is returned for patches that are malformed or cannot be applied
+var ErrInvalidPatch = jsonpatch.ErrInvalidPatch
+
+// ErrPatchTestFailed is returned when a test operation of a JSON Patch does not match
+var ErrPatchTestFailed = jsonpatch.ErrTestFailed
+
+// PatchError lists the fields a patch was rejected for
+type PatchError struct {
+	Fields []*utils.ValidationErrorResponse
+}
+
+func (e *PatchError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		messages[i] = field.Field + ": " + field.Message
+	}
+	return "invalid patch: " + strings.Join(messages, "; ")
+}
+
+func (e *PatchError) Unwrap() error {
+	return ErrInvalidPatch
+}
+
+// UserDocument is the part of a user that patches edit. Password is write-only: it
+// is never part of the document read, so a JSON Patch sets it with add.
+type UserDocument struct {
+	Name     string `json:"name" validate:"required,max=100"`
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password,omitempty" validate:"omitempty,min=8"`
+}
+
+// readOnlyUserFields are the fields of a user response that patches may not touch,
+// with where to change them instead
+var readOnlyUserFields = map[string]string{
+	"id":              "Field is read-only",
+	"isEmailVerified": "Field is read-only",
+	"pendingEmail":    "Field is read-only; change email instead",
+	"createdAt":       "Field is read-only",
+	"deletedAt":       "Field is read-only; use DELETE or the restore endpoint",
+	"version":         "Field is read-only; send it as If-Match",
+	"role":            "Field is read-only; use PUT /v1/users/{id}/role",
+	"status":          "Field is read-only; use PUT /v1/users/{id}/status",
+	"statusReason":    "Field is read-only; use PUT /v1/users/{id}/status",
+	"suspendedUntil":  "Field is read-only; use PUT /v1/users/{id}/status",
+}
+
+// patchUser applies a patch to the editable document of the user and returns the
+// result, checked against the rules for new users. Unknown, read-only and nulled
+// fields are rejected with a PatchError.
+func patchUser(user *model.User, format string, patch []byte) (*UserDocument, error) {
+	doc := map[string]interface{}{"name": user.Name, "email": user.Email}
+
+	var result interface{}
+	switch format {
+	case PatchFormatMerge:
+		var merge interface{}
+		if err := json.Unmarshal(patch, &merge); err != nil {
+			return nil, fmt.Errorf("%w: the body is not valid JSON", ErrInvalidPatch)
+		}
+		members, ok := merge.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%w: a merge patch must be a JSON object", ErrInvalidPatch)
+		}
+		fields := map[string]string{}
+		for name, value := range members {
+			if message := patchFieldError(name); message != "" {
+				fields[name] = message
+			} else if value == nil {
+				fields[name] = "Field cannot be null"
+			}
+		}
+		if len(fields) > 0 {
+			return nil, newPatchError(fields)
+		}
+		result = jsonpatch.Merge(doc, merge)
+	case PatchFormatJSON:
+		operations, err := jsonpatch.Decode(patch)
+		if err != nil {
+			return nil, err
+		}
+		fields := map[string]string{}
+		for _, operation := range operations {
+			for _, path := range operation.Paths() {
+				tokens, err := jsonpatch.Tokens(path)
+				if err != nil {
+					return nil, err
+				}
+				if len(tokens) > 0 {
+					if message := patchFieldError(tokens[0]); message != "" {
+						fields[tokens[0]] = message
+					}
+				}
+			}
+		}
+		if len(fields) > 0 {
+			return nil, newPatchError(fields)
+		}
+		if result, err = jsonpatch.Apply(doc, operations); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown format %q", ErrInvalidPatch, format)
+	}
+
+	return decodeUserDocument(result)
+}
+
+// decodeUserDocument reads a patched document into its typed form and validates it
+func decodeUserDocument(result interface{}) (*UserDocument, error) {
+	members, ok := result.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: the patched user must be a JSON object", ErrInvalidPatch)
+	}
+	fields := map[string]string{}
+	for name, value := range members {
+		if message := patchFieldError(name); message != "" {
+			fields[name] = message
+			continue
+		}
+		switch value.(type) {
+		case nil:
+			fields[name] = "Field cannot be null"
+		case string:
+		default:
+			fields[name] = "Field must be a string"
+		}
+	}
+
+	// Members rejected above are left out, so the rules report the remaining ones
+	var document UserDocument
+	document.Name, _ = members["name"].(string)
+	document.Email, _ = members["email"].(string)
+	document.Password, _ = members["password"].(string)
+	for _, validationError := range utils.ValidateStruct(&document) {
+		if _, ok := fields[validationError.Field]; !ok {
+			fields[validationError.Field] = validationError.Message
+		}
+	}
+
+	if len(fields) > 0 {
+		return nil, newPatchError(fields)
+	}
+	return &document, nil
+}
+
+// patchFieldError explains why a field cannot be patched, or returns "" if it can
+func patchFieldError(name string) string {
+	switch name {
+	case "name", "email", "password":
+		return ""
+	}
+	if message, ok := readOnlyUserFields[name]; ok {
+		return message
+	}
+	return "Unknown field"
+}
+
+// newPatchError turns field messages into a PatchError, ordered by field
+func newPatchError(fields map[string]string) error {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	patchError := &PatchError{}
+	for _, name := range names {
+		patchError.Fields = append(patchError.Fields, &utils.ValidationErrorResponse{Field: name, Message: fields[name]})
+	}
+	return patchError
+}
@@ -1,26 +1,65 @@
 package service
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
 	"errors"
+	"fmt"
+	"slices"
+	"time"
 
 	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/model"
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/policy"
 	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/repository"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 type UserService struct {
-	userRepo repository.UserRepository
+	userRepo             repository.UserRepository
+	membershipRepo       repository.MembershipRepository
+	groupRepo            repository.GroupRepository
+	securityEventService *SecurityEventService
+	tokenService         *TokenService
+	emailService         *EmailService
+	roleService          *RoleService
+	policy               *policy.Engine
 }
 
-func NewUserService(userRepo repository.UserRepository) *UserService {
+// userColumns maps user resource attributes to columns for policy row filters
+var userColumns = map[string]string{
+	"id":              "id",
+	"role":            "role",
+	"email":           "email",
+	"isEmailVerified": "is_email_verified",
+	"status":          "status",
+}
+
+func NewUserService(
+	userRepo repository.UserRepository,
+	membershipRepo repository.MembershipRepository,
+	groupRepo repository.GroupRepository,
+	securityEventService *SecurityEventService,
+	tokenService *TokenService,
+	emailService *EmailService,
+	roleService *RoleService,
+	policyEngine *policy.Engine,
+) *UserService {
 	return &UserService{
-		userRepo: userRepo,
+		userRepo:             userRepo,
+		membershipRepo:       membershipRepo,
+		groupRepo:            groupRepo,
+		securityEventService: securityEventService,
+		tokenService:         tokenService,
+		emailService:         emailService,
+		roleService:          roleService,
+		policy:               policyEngine,
 	}
 }
 
 func (s *UserService) CreateUser(user *model.User) (*model.User, error) {
 	// Check if email exists
-	exists, err := s.userRepo.IsEmailTaken(user.Email, 0)
+	exists, err := s.userRepo.Global().IsEmailTaken(user.Email, 0)
 	if err != nil {
 		return nil, err
 	}
@@ -37,86 +76,870 @@ func (s *UserService) CreateUser(user *model.User) (*model.User, error) {
 
 	// Set Defaults
 	if user.Role == "" {
-		user.Role = "user"
+		user.Role = model.RoleUser
+	}
+	if err := s.validateRole(user.Role); err != nil {
+		return nil, err
 	}
 
 	err = s.userRepo.Create(user)
 	return user, err
 }
 
+// CreateMember creates a user as a member of the actor's organization. Choosing
+// their role requires the 'roles:assign' permission.
+func (s *UserService) CreateMember(actor *model.User, user *model.User) (*model.User, error) {
+	if actor.TenantID == 0 {
+		return nil, ErrOrganizationNotFound
+	}
+	if user.Role != "" {
+		if err := s.requireRoleAssignment(actor); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := s.CreateUser(user); err != nil {
+		return nil, err
+	}
+	err := s.membershipRepo.Create(&model.Membership{
+		OrganizationID: actor.TenantID,
+		UserID:         user.ID,
+		Role:           model.OrgRoleMember,
+	})
+	return user, err
+}
+
+// NewPendingUser checks and prepares an account without a usable password, for the
+// caller to store. Login is impossible until a password is set, e.g. by accepting an invitation.
+func (s *UserService) NewPendingUser(name, email, role string) (*model.User, error) {
+	exists, err := s.userRepo.Global().IsEmailTaken(email, 0)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, errors.New("email already taken")
+	}
+
+	if role == "" {
+		role = model.RoleUser
+	}
+	if err := s.validateRole(role); err != nil {
+		return nil, err
+	}
+
+	return &model.User{
+		Name:   name,
+		Email:  email,
+		Role:   role,
+		Status: model.UserStatusPending,
+	}, nil
+}
+
 func (s *UserService) GetUserByEmail(email string) (*model.User, error) {
-	return s.userRepo.FindByEmail(email)
+	return s.userRepo.Global().FindByEmail(email)
 }
 
 func (s *UserService) GetUserByID(id uint) (*model.User, error) {
-	user, err := s.userRepo.FindByID(id)
+	return findUser(s.userRepo.Global(), id)
+}
+
+// tenantUsers is the user repository restricted to the actor's organization.
+// Operations on other users go through it so they never cross tenants.
+func (s *UserService) tenantUsers(actor *model.User) repository.UserRepository {
+	return s.userRepo.WithTenant(actor.TenantID)
+}
+
+func findUser(repo repository.UserRepository, id uint) (*model.User, error) {
+	user, err := repo.FindByID(id)
 	if err != nil {
 		return nil, err
 	}
 	if user == nil {
-		return nil, errors.New("user not found")
+		return nil, ErrUserNotFound
 	}
 	return user, nil
 }
 
-func (s *UserService) GetUsers(page, limit int, search, scope, role, sortBy string) ([]model.UserResponse, int64, error) {
-	// Construct Filter
-	filter := repository.UserFilter{
+// GetMember returns a user of the actor's organization as they would act in it,
+// i.e. with their organization role and group permissions in effect
+func (s *UserService) GetMember(actor *model.User, id uint) (*model.User, error) {
+	user, err := findUser(s.tenantUsers(actor), id)
+	if err != nil {
+		return nil, err
+	}
+	membership, err := s.membershipRepo.Find(actor.TenantID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if membership != nil {
+		user.TenantID = membership.OrganizationID
+		user.TenantRole = membership.Role
+	}
+	return user, nil
+}
+
+// GetMembership returns the user's membership in the organization, or nil
+func (s *UserService) GetMembership(organizationID, userID uint) (*model.Membership, error) {
+	return s.membershipRepo.Find(organizationID, userID)
+}
+
+// SetLastOrganization remembers the organization new logins should start in
+func (s *UserService) SetLastOrganization(user *model.User, organizationID uint) error {
+	user.LastOrganizationID = &organizationID
+	return s.userRepo.Global().Update(user)
+}
+
+// GetUsers lists the users the actor may read, without the fields the policy hides
+// from them, shaped by view
+func (s *UserService) GetUsers(actor *model.User, filter repository.UserFilter, view UserView) ([]map[string]interface{}, int64, error) {
+	subject, err := s.readableUsers(actor, &filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	repo := s.tenantUsers(actor)
+	users, total, err := repo.FindAll(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	response, err := s.redactUsers(subject, users)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := addHighlights(repo, filter, users, response); err != nil {
+		return nil, 0, err
+	}
+	if err := s.shapeUsers(actor, view, users, response); err != nil {
+		return nil, 0, err
+	}
+	return response, total, nil
+}
+
+// UserCursorPage is one page of a keyset-paginated user list. The cursors are
+// empty when there are no rows in that direction; Total is only set when counted.
+type UserCursorPage struct {
+	Results    []map[string]interface{}
+	NextCursor string
+	PrevCursor string
+	Total      int64
+}
+
+// GetUsersByCursor lists users like GetUsers, continuing after filter.After or
+// before filter.Before instead of skipping to a page number
+func (s *UserService) GetUsersByCursor(actor *model.User, filter repository.UserFilter, view UserView) (*UserCursorPage, error) {
+	subject, err := s.readableUsers(actor, &filter)
+	if err != nil {
+		return nil, err
+	}
+
+	repo := s.tenantUsers(actor)
+	users, hasMore, err := repo.FindByCursor(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &UserCursorPage{}
+	if !filter.SkipCount {
+		if page.Total, err = repo.Count(filter); err != nil {
+			return nil, err
+		}
+	}
+	if page.Results, err = s.redactUsers(subject, users); err != nil {
+		return nil, err
+	}
+	if err := addHighlights(repo, filter, users, page.Results); err != nil {
+		return nil, err
+	}
+	if err := s.shapeUsers(actor, view, users, page.Results); err != nil {
+		return nil, err
+	}
+
+	// Coming from a cursor means there are rows on its side; hasMore tells about the other
+	if len(users) > 0 {
+		first := repository.UserCursor(&users[0], filter.Sort).Encode(s.cursorKey())
+		last := repository.UserCursor(&users[len(users)-1], filter.Sort).Encode(s.cursorKey())
+		if filter.Before != nil {
+			page.NextCursor = last
+			if hasMore {
+				page.PrevCursor = first
+			}
+		} else {
+			if hasMore {
+				page.NextCursor = last
+			}
+			if filter.After != nil {
+				page.PrevCursor = first
+			}
+		}
+	}
+	return page, nil
+}
+
+// requireRecentLogin fails with ErrReauthenticationRequired unless the actor entered
+// their password within the reauthentication window
+func (s *UserService) requireRecentLogin(actor *model.User) error {
+	if actor.AuthTime == nil || time.Since(*actor.AuthTime) > s.tokenService.config.ReauthMaxAge {
+		return ErrReauthenticationRequired
+	}
+	return nil
+}
+
+// DecodeCursor reads a cursor of a user list received from a client
+func (s *UserService) DecodeCursor(raw string) (*repository.Cursor, error) {
+	return repository.DecodeCursor(s.cursorKey(), raw)
+}
+
+// cursorKey signs the cursors of user lists. It is derived from the token secret,
+// so cursors from before a secret rotation stop working along with the tokens.
+func (s *UserService) cursorKey() []byte {
+	mac := hmac.New(sha256.New, []byte(s.tokenService.config.JWTSecret))
+	mac.Write([]byte("user list cursor"))
+	return mac.Sum(nil)
+}
+
+// UserFields returns the fields the actor may filter and sort user lists by: the
+// whitelisted ones, less those the access policy may hide from them
+func (s *UserService) UserFields(actor *model.User) (repository.Fields, error) {
+	subject, err := s.subjectAttributes(actor)
+	if err != nil {
+		return nil, err
+	}
+	return repository.UserFields.Without(s.policy.RedactableFields(subject, model.PermissionUsersRead)...), nil
+}
+
+// readableUsers restricts filter to the users the policy lets the actor read
+// and returns the actor's subject attributes for redacting them
+func (s *UserService) readableUsers(actor *model.User, filter *repository.UserFilter) (policy.Attributes, error) {
+	subject, err := s.subjectAttributes(actor)
+	if err != nil {
+		return nil, err
+	}
+	rowFilter, err := s.policy.Filter(subject, model.PermissionUsersRead).Scope(userColumns)
+	if err != nil {
+		return nil, err
+	}
+
+	filter.Scopes = append(filter.Scopes, rowFilter)
+	return subject, nil
+}
+
+// GetDeletedUsers lists the soft-deleted users the actor may delete, i.e. restore or purge
+func (s *UserService) GetDeletedUsers(actor *model.User, page, limit int) ([]map[string]interface{}, int64, error) {
+	subject, err := s.subjectAttributes(actor)
+	if err != nil {
+		return nil, 0, err
+	}
+	rowFilter, err := s.policy.Filter(subject, model.PermissionUsersDelete).Scope(userColumns)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	users, total, err := s.tenantUsers(actor).FindDeleted(repository.UserFilter{
 		Page:   page,
 		Limit:  limit,
-		Search: search,
-		Scope:  scope,
-		Role:   role,
-		SortBy: sortBy,
+		Scopes: []func(*gorm.DB) *gorm.DB{rowFilter},
+	})
+	if err != nil {
+		return nil, 0, err
 	}
 
-	users, total, err := s.userRepo.FindAll(filter)
+	response, err := s.redactUsers(subject, users)
 	if err != nil {
 		return nil, 0, err
 	}
+	return response, total, nil
+}
 
-	// Convert to DTOs
-	var response []model.UserResponse
-	for _, u := range users {
-		response = append(response, u.ToResponse())
+// redactUsers converts users to DTOs without the fields the policy hides from the subject
+func (s *UserService) redactUsers(subject policy.Attributes, users []model.User) ([]map[string]interface{}, error) {
+	response := make([]map[string]interface{}, 0, len(users))
+	for i := range users {
+		decision := s.policy.Decide(policy.Request{
+			Subject:  subject,
+			Action:   model.PermissionUsersRead,
+			Resource: userAttributes(&users[i]),
+		})
+		view, err := policy.Redact(users[i].ToResponse(), decision.RedactedFields)
+		if err != nil {
+			return nil, err
+		}
+		response = append(response, view)
 	}
+	return response, nil
+}
 
-	return response, total, nil
+// addHighlights attaches the matches of a search to the results as "highlights",
+// leaving out fields the policy redacted so they cannot leak through the markup
+func addHighlights(repo repository.UserRepository, filter repository.UserFilter, users []model.User, results []map[string]interface{}) error {
+	if filter.Search == "" || len(users) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(users))
+	for i := range users {
+		ids[i] = users[i].ID
+	}
+	highlights, err := repo.SearchHighlights(filter.Search, filter.Scope, ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range users {
+		marked := map[string]string{}
+		for field, text := range highlights[users[i].ID] {
+			if _, visible := results[i][field]; visible {
+				marked[field] = text
+			}
+		}
+		if len(marked) > 0 {
+			results[i]["highlights"] = marked
+		}
+	}
+	return nil
+}
+
+// ViewUser returns a user the actor may read, without the fields the policy hides
+// from them, shaped by view. The user it was built from is returned with it, along
+// with the entity tag of the response, see UserEntityTag.
+func (s *UserService) ViewUser(actor *model.User, id uint, view UserView) (map[string]interface{}, *model.User, string, error) {
+	user, err := findUser(s.tenantUsers(actor), id)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	decision, err := s.authorize(actor, model.PermissionUsersRead, user)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	response, err := policy.Redact(user.ToResponse(), decision.RedactedFields)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if err := s.shapeUsers(actor, view, []model.User{*user}, []map[string]interface{}{response}); err != nil {
+		return nil, nil, "", err
+	}
+	return response, user, UserEntityTag(user.Version, view.Fields, decision.RedactedFields), nil
 }
 
-func (s *UserService) UpdateUser(id uint, updateData map[string]interface{}) (*model.User, error) {
-	user, err := s.userRepo.FindByID(id)
-	if err != nil || user == nil {
-		return nil, errors.New("user not found")
+// Decide evaluates the access policy for the actor performing action on target.
+// target may be nil for actions that do not concern a specific user.
+func (s *UserService) Decide(actor *model.User, action string, target *model.User) (policy.Decision, error) {
+	subject, err := s.subjectAttributes(actor)
+	if err != nil {
+		return policy.Decision{}, err
+	}
+	var resource policy.Attributes
+	if target != nil {
+		resource = userAttributes(target)
 	}
+	return s.policy.Decide(policy.Request{Subject: subject, Action: action, Resource: resource}), nil
+}
 
-	if email, ok := updateData["email"].(string); ok && email != user.Email {
-		taken, err := s.userRepo.IsEmailTaken(email, id)
+// authorize is Decide, failing with ErrForbidden when access is denied
+func (s *UserService) authorize(actor *model.User, action string, target *model.User) (policy.Decision, error) {
+	decision, err := s.Decide(actor, action, target)
+	if err != nil {
+		return decision, err
+	}
+	if !decision.Allowed {
+		return decision, ErrForbidden
+	}
+	return decision, nil
+}
+
+// CanAssignRoles reports whether the actor may choose the role of an account: their
+// role or groups must grant 'roles:assign', and so must their token's scopes
+func (s *UserService) CanAssignRoles(actor *model.User) (bool, error) {
+	if actor.Scopes != nil && !slices.Contains(actor.Scopes, model.PermissionRolesAssign) {
+		return false, nil
+	}
+	return s.roleService.UserHasPermissions(actor, model.PermissionRolesAssign)
+}
+
+// requireRoleAssignment is CanAssignRoles, failing with ErrForbidden when it is denied
+func (s *UserService) requireRoleAssignment(actor *model.User) error {
+	allowed, err := s.CanAssignRoles(actor)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return fmt.Errorf("%w: setting a role requires the '%s' permission", ErrForbidden, model.PermissionRolesAssign)
+	}
+	return nil
+}
+
+// DefaultScopes are granted to tokens of a normal login: the user's own
+// profile plus every permission of their role and groups
+func (s *UserService) DefaultScopes(user *model.User) ([]string, error) {
+	permissions, err := s.roleService.UserPermissions(user)
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{model.ScopeProfile}, permissions...), nil
+}
+
+func (s *UserService) subjectAttributes(actor *model.User) (policy.Attributes, error) {
+	permissions, err := s.roleService.UserPermissions(actor)
+	if err != nil {
+		return nil, err
+	}
+	return policy.Attributes{
+		"id":          actor.ID,
+		"role":        actor.Role,
+		"permissions": permissions,
+		"orgId":       actor.TenantID,
+		"orgRole":     actor.TenantRole,
+	}, nil
+}
+
+func userAttributes(user *model.User) policy.Attributes {
+	return policy.Attributes{
+		"id":              user.ID,
+		"role":            user.Role,
+		"email":           user.Email,
+		"isEmailVerified": user.IsEmailVerified,
+		"status":          user.Status,
+	}
+}
+
+// UpdateUser applies a patch in the given format to a user that is still at the
+// given version; 0 accepts any version
+func (s *UserService) UpdateUser(actor *model.User, id, version uint, format string, patch []byte, client ClientInfo) (*model.User, error) {
+	users := s.tenantUsers(actor)
+	user, err := findUser(users, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(user, version); err != nil {
+		return nil, err
+	}
+	change, err := s.planUpdate(actor, user, format, patch, client)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.apply(users, change); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// planUpdate checks a patch of a user and makes the change on the struct only
+func (s *UserService) planUpdate(actor *model.User, user *model.User, format string, patch []byte, client ClientInfo) (*userChange, error) {
+	if _, err := s.authorize(actor, model.PermissionUsersWrite, user); err != nil {
+		return nil, err
+	}
+	document, err := patchUser(user, format, patch)
+	if err != nil {
+		return nil, err
+	}
+	// Credentials only change after a recent login, whichever way the patch was written
+	if document.Email != user.Email || document.Password != "" {
+		if err := s.requireRecentLogin(actor); err != nil {
+			return nil, err
+		}
+	}
+	change := &userChange{user: user, save: true}
+
+	// A new email is only stored as pending; it replaces Email once confirmed.
+	// Emails are unique across organizations, so every user is checked.
+	if email := document.Email; email != user.Email {
+		taken, err := s.userRepo.Global().IsEmailTaken(email, user.ID)
 		if err != nil {
 			return nil, err
 		}
 		if taken {
 			return nil, errors.New("email already taken")
 		}
-		user.Email = email
+		user.PendingEmail = email
+		change.emailChange = true
 	}
 
-	if name, ok := updateData["name"].(string); ok {
-		user.Name = name
-	}
+	user.Name = document.Name
 
-	if password, ok := updateData["password"].(string); ok {
+	if password := document.Password; password != "" {
 		hashed, err := bcrypt.GenerateFromPassword([]byte(password), 12)
 		if err != nil {
 			return nil, err
 		}
 		user.Password = string(hashed)
+		change.record(func() {
+			s.securityEventService.Record(model.SecurityEventPasswordChange, user.ID, user.Email, client)
+		})
 	}
 
-	err = s.userRepo.Update(user)
-	return user, err
+	return change, nil
+}
+
+// startEmailChange replaces any earlier pending change and emails both addresses
+func (s *UserService) startEmailChange(user *model.User) error {
+	if err := s.deleteEmailChangeTokens(user.ID); err != nil {
+		return err
+	}
+
+	confirmToken, cancelToken, err := s.tokenService.GenerateEmailChangeTokens(user)
+	if err != nil {
+		return err
+	}
+
+	if err := s.emailService.SendEmailChangeConfirmationEmail(user.PendingEmail, confirmToken); err != nil {
+		return err
+	}
+	return s.emailService.SendEmailChangeNoticeEmail(user.Email, user.PendingEmail, cancelToken)
+}
+
+// ConfirmEmailChange swaps in the pending email. Following the link proves ownership
+// of the new address, so the account is marked as verified.
+func (s *UserService) ConfirmEmailChange(confirmToken string, client ClientInfo) (*model.User, error) {
+	user, err := s.findByStoredToken(confirmToken, model.TokenTypeEmailChange)
+	if err != nil {
+		return nil, err
+	}
+
+	// The address may have been registered by someone else while the change was pending
+	taken, err := s.userRepo.Global().IsEmailTaken(user.PendingEmail, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if taken {
+		return nil, errors.New("email already taken")
+	}
+
+	oldEmail := user.Email
+	user.Email = user.PendingEmail
+	user.PendingEmail = ""
+	user.IsEmailVerified = true
+	if err := s.userRepo.Global().Update(user); err != nil {
+		return nil, err
+	}
+	if err := s.deleteEmailChangeTokens(user.ID); err != nil {
+		return nil, err
+	}
+
+	s.securityEventService.Record(model.SecurityEventEmailChange, user.ID, oldEmail, client)
+	return user, nil
+}
+
+// CancelEmailChange discards the pending email using the link sent to the old address
+func (s *UserService) CancelEmailChange(cancelToken string, client ClientInfo) error {
+	user, err := s.findByStoredToken(cancelToken, model.TokenTypeEmailChangeCancel)
+	if err != nil {
+		return err
+	}
+
+	user.PendingEmail = ""
+	if err := s.userRepo.Global().Update(user); err != nil {
+		return err
+	}
+	if err := s.deleteEmailChangeTokens(user.ID); err != nil {
+		return err
+	}
+
+	s.securityEventService.Record(model.SecurityEventEmailChangeCancel, user.ID, user.Email, client)
+	return nil
+}
+
+func (s *UserService) findByStoredToken(token, tokenType string) (*model.User, error) {
+	storedToken, err := s.tokenService.VerifyStoredToken(token, tokenType)
+	if err != nil {
+		return nil, errors.New("invalid or expired link")
+	}
+
+	user, err := s.userRepo.Global().FindByID(storedToken.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.PendingEmail == "" {
+		return nil, errors.New("invalid or expired link")
+	}
+	return user, nil
+}
+
+func (s *UserService) deleteEmailChangeTokens(userID uint) error {
+	if err := s.tokenService.DeleteUserTokens(userID, model.TokenTypeEmailChange); err != nil {
+		return err
+	}
+	return s.tokenService.DeleteUserTokens(userID, model.TokenTypeEmailChangeCancel)
+}
+
+// SetPassword hashes and stores a new password, lifting any forced password reset
+func (s *UserService) SetPassword(user *model.User, password string) error {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), 12)
+	if err != nil {
+		return err
+	}
+	user.Password = string(hashed)
+	user.PasswordResetRequired = false
+	return s.userRepo.Global().Update(user)
+}
+
+// DeleteUser soft-deletes a user that is still at the given version; 0 accepts any version
+func (s *UserService) DeleteUser(actor *model.User, id, version uint) error {
+	users := s.tenantUsers(actor)
+	user, err := findUser(users, id)
+	if err != nil {
+		return err
+	}
+	if err := checkVersion(user, version); err != nil {
+		return err
+	}
+	change, err := s.planDelete(actor, user)
+	if err != nil {
+		return err
+	}
+	return s.apply(users, change)
+}
+
+func (s *UserService) planDelete(actor *model.User, user *model.User) (*userChange, error) {
+	if _, err := s.authorize(actor, model.PermissionUsersDelete, user); err != nil {
+		return nil, err
+	}
+	return &userChange{user: user, delete: true}, nil
+}
+
+// ChangeRole assigns a new role to a user. The last remaining admin cannot be demoted.
+// Existing sessions of the user are revoked so the new role applies immediately.
+func (s *UserService) ChangeRole(actor *model.User, id uint, role string, client ClientInfo) (*model.User, error) {
+	users := s.tenantUsers(actor)
+	user, err := findUser(users, id)
+	if err != nil {
+		return nil, err
+	}
+	change, err := s.planRoleChange(actor, user, role, 0, client)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.apply(users, change); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// planRoleChange checks a role change and makes it on the struct only.
+// adminsLeaving counts admins that changes planned alongside it already demote.
+func (s *UserService) planRoleChange(actor *model.User, user *model.User, role string, adminsLeaving int64, client ClientInfo) (*userChange, error) {
+	if _, err := s.authorize(actor, model.PermissionRolesAssign, user); err != nil {
+		return nil, err
+	}
+	if err := s.validateRole(role); err != nil {
+		return nil, err
+	}
+	if user.Role == role {
+		return &userChange{user: user}, nil
+	}
+
+	// Roles apply in every organization, so admins are counted across all of them
+	if user.Role == model.RoleAdmin {
+		admins, err := s.userRepo.Global().CountByRole(model.RoleAdmin)
+		if err != nil {
+			return nil, err
+		}
+		if admins-adminsLeaving <= 1 {
+			return nil, ErrLastAdmin
+		}
+	}
+
+	previousRole := user.Role
+	user.Role = role
+	change := &userChange{user: user, save: true, demotesAdmin: previousRole == model.RoleAdmin}
+	change.signOut()
+	change.record(func() {
+		s.securityEventService.RecordAction(model.SecurityEventRoleChange, user.ID, actor.ID,
+			fmt.Sprintf("%s -> %s", previousRole, role), client)
+	})
+	return change, nil
+}
+
+// ChangeStatus moves a user through the account lifecycle. Suspensions may carry an
+// expiry after which the user is active again. Leaving the active status revokes all
+// sessions; the auth middleware also rejects inactive users on every request.
+func (s *UserService) ChangeStatus(actor *model.User, id uint, status, reason string, until *time.Time, client ClientInfo) (*model.User, error) {
+	users := s.tenantUsers(actor)
+	user, err := findUser(users, id)
+	if err != nil {
+		return nil, err
+	}
+	change, err := s.planStatusChange(actor, user, status, reason, until, client)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.apply(users, change); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// planStatusChange checks a status change and makes it on the struct only
+func (s *UserService) planStatusChange(actor *model.User, user *model.User, status, reason string, until *time.Time, client ClientInfo) (*userChange, error) {
+	if _, err := s.authorize(actor, model.PermissionUsersWrite, user); err != nil {
+		return nil, err
+	}
+	if user.ID == actor.ID {
+		return nil, errors.New("you cannot change your own status")
+	}
+	clearExpiredSuspension(user)
+
+	if !model.CanTransitionStatus(user.Status, status) {
+		return nil, fmt.Errorf("cannot change status from %s to %s", user.Status, status)
+	}
+	if until != nil {
+		if status != model.UserStatusSuspended {
+			return nil, errors.New("only suspensions can expire")
+		}
+		if !until.After(time.Now()) {
+			return nil, errors.New("suspension expiry must be in the future")
+		}
+	}
+
+	previousStatus := user.Status
+	user.Status = status
+	user.StatusReason = reason
+	user.SuspendedUntil = until
+	if status == model.UserStatusActive {
+		user.StatusReason = ""
+	}
+
+	details := fmt.Sprintf("%s -> %s", previousStatus, status)
+	if until != nil {
+		details += " until " + until.UTC().Format(time.RFC3339)
+	}
+	if reason != "" {
+		details += ": " + reason
+	}
+	change := &userChange{user: user, save: true}
+	if status != model.UserStatusActive {
+		change.signOut()
+	}
+	change.record(func() {
+		s.securityEventService.RecordAction(model.SecurityEventStatusChange, user.ID, actor.ID, details, client)
+	})
+	return change, nil
+}
+
+// CheckStatus returns an error unless the account may be used right now.
+// A suspension whose expiry has passed is lifted on the way.
+func (s *UserService) CheckStatus(user *model.User) error {
+	if err := s.liftExpiredSuspension(user); err != nil {
+		return err
+	}
+
+	switch user.Status {
+	case model.UserStatusActive:
+		return nil
+	case model.UserStatusPending:
+		return ErrAccountPending
+	case model.UserStatusSuspended:
+		return ErrAccountSuspended
+	}
+	return ErrAccountBanned
+}
+
+func (s *UserService) liftExpiredSuspension(user *model.User) error {
+	if !clearExpiredSuspension(user) {
+		return nil
+	}
+	// A concurrent request may have lifted it first, which is just as good
+	if err := s.userRepo.Global().Update(user); err != nil && !errors.Is(err, repository.ErrVersionConflict) {
+		return err
+	}
+	return nil
+}
+
+// clearExpiredSuspension makes a user active again on the struct once their
+// suspension has expired, and reports whether it did
+func clearExpiredSuspension(user *model.User) bool {
+	if user.Status != model.UserStatusSuspended || user.SuspendedUntil == nil || user.SuspendedUntil.After(time.Now()) {
+		return false
+	}
+	user.Status = model.UserStatusActive
+	user.StatusReason = ""
+	user.SuspendedUntil = nil
+	return true
+}
+
+// RevokeSessions signs the user out everywhere: refresh tokens are deleted and
+// access tokens issued until now are rejected by the auth middleware.
+// Pending changes to the user are saved along the way.
+func (s *UserService) RevokeSessions(user *model.User) error {
+	user.TokenVersion++
+	if err := s.userRepo.Global().Update(user); err != nil {
+		return err
+	}
+	return s.tokenService.RevokeAllSessions(user.ID)
+}
+
+func (s *UserService) validateRole(role string) error {
+	exists, err := s.roleService.RoleExists(role)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("unknown role: %s", role)
+	}
+	return nil
+}
+
+// findDeletedUser looks up a soft-deleted user of the actor's organization the actor may delete
+func (s *UserService) findDeletedUser(actor *model.User, id uint) (*model.User, error) {
+	user, err := s.tenantUsers(actor).FindDeletedByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	if _, err := s.authorize(actor, model.PermissionUsersDelete, user); err != nil {
+		return nil, err
+	}
+	return user, nil
 }
 
-func (s *UserService) DeleteUser(id uint) error {
-	return s.userRepo.Delete(id)
+// RestoreUser undoes the deletion of a user. Sessions from before the deletion
+// are revoked, so the user has to log in again.
+func (s *UserService) RestoreUser(actor *model.User, id uint) (*model.User, error) {
+	user, err := s.findDeletedUser(actor, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.tenantUsers(actor).Restore(user); err != nil {
+		return nil, err
+	}
+	user.DeletedAt = gorm.DeletedAt{}
+	if err := s.RevokeSessions(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// PurgeDeletedUser permanently deletes a user that was soft-deleted before
+func (s *UserService) PurgeDeletedUser(actor *model.User, id uint) error {
+	user, err := s.findDeletedUser(actor, id)
+	if err != nil {
+		return err
+	}
+	return s.PurgeUser(user.ID)
+}
+
+// PurgeDeletedBefore permanently deletes every user soft-deleted before the cutoff
+// and returns how many were removed
+func (s *UserService) PurgeDeletedBefore(cutoff time.Time) (int, error) {
+	users, err := s.userRepo.Global().FindDeletedBefore(cutoff)
+	if err != nil {
+		return 0, err
+	}
+	for i, user := range users {
+		if err := s.PurgeUser(user.ID); err != nil {
+			return i, err
+		}
+	}
+	return len(users), nil
+}
+
+// PurgeUser permanently deletes a user together with their tokens and memberships,
+// so the email can be registered again
+func (s *UserService) PurgeUser(id uint) error {
+	if err := s.tokenService.PurgeUserTokens(id); err != nil {
+		return err
+	}
+	if err := s.membershipRepo.DeleteByUserID(id); err != nil {
+		return err
+	}
+	return s.userRepo.Global().Purge(id)
 }
\ No newline at end of file
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mnabielap/starter-kit-restapi-gofasthttp/internal/model"
+)
+
+// ErrInvalidFieldset is returned for fields or includes outside the whitelist
+var ErrInvalidFieldset = errors.New("invalid fieldset")
+
+// Related resources that can be embedded in user responses
+const (
+	IncludeGroups   = "groups"
+	IncludeSessions = "sessions"
+)
+
+// userViewFields whitelists the fields of model.UserResponse clients may select
+var userViewFields = []string{
+	"id", "name", "email", "role", "isEmailVerified", "pendingEmail",
+	"status", "statusReason", "suspendedUntil", "createdAt", "deletedAt", "version",
+}
+
+// includePermissions are required to embed a resource for anyone but oneself
+var includePermissions = map[string]string{
+	IncludeGroups:   model.PermissionGroupsRead,
+	IncludeSessions: model.PermissionSecurityEventsRead,
+}
+
+// UserView shapes user responses: Fields trims them to a sparse fieldset and
+// Include embeds related resources. The zero value returns full responses.
+type UserView struct {
+	Fields  []string
+	Include []string
+}
+
+// ParseUserView parses the comma-separated fields and include query parameters
+func ParseUserView(fields, include string) (UserView, error) {
+	var view UserView
+	for _, field := range splitList(fields) {
+		if !contains(userViewFields, field) {
+			return UserView{}, fmt.Errorf("%w: unknown field %q (allowed: %s)", ErrInvalidFieldset, field, strings.Join(userViewFields, ", "))
+		}
+		view.Fields = append(view.Fields, field)
+	}
+	for _, name := range splitList(include) {
+		if _, ok := includePermissions[name]; !ok {
+			return UserView{}, fmt.Errorf("%w: unknown include %q (allowed: %s, %s)", ErrInvalidFieldset, name, IncludeGroups, IncludeSessions)
+		}
+		if !contains(view.Include, name) {
+			view.Include = append(view.Include, name)
+		}
+	}
+	return view, nil
+}
+
+// UserEntityTag is the strong entity tag of a user response: the user's version in
+// quotes, e.g. "3". Responses trimmed to a fieldset or redacted by the policy are
+// other representations of that version, so a hash of the fields selected and
+// hidden is appended, e.g. "3-1f2e3d4c5b6a7988". Either form is accepted as If-Match.
+func UserEntityTag(version uint, fields, redacted []string) string {
+	tag := strconv.FormatUint(uint64(version), 10)
+	if len(fields) == 0 && len(redacted) == 0 {
+		return `"` + tag + `"`
+	}
+	fields = append([]string{}, fields...)
+	redacted = append([]string{}, redacted...)
+	sort.Strings(fields)
+	sort.Strings(redacted)
+	sum := sha256.Sum256([]byte(strings.Join(fields, ",") + "|" + strings.Join(redacted, ",")))
+	return `"` + tag + "-" + hex.EncodeToString(sum[:8]) + `"`
+}
+
+func splitList(raw string) []string {
+	var items []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// shapeUsers applies the view to redacted user responses, where results[i] belongs to users[i]
+func (s *UserService) shapeUsers(actor *model.User, view UserView, users []model.User, results []map[string]interface{}) error {
+	if len(view.Fields) > 0 {
+		for _, result := range results {
+			trimFields(result, view.Fields)
+		}
+	}
+	if len(view.Include) == 0 || len(users) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(users))
+	for i := range users {
+		ids[i] = users[i].ID
+	}
+	for _, name := range view.Include {
+		if err := s.authorizeInclude(actor, name, ids); err != nil {
+			return err
+		}
+	}
+
+	if contains(view.Include, IncludeGroups) {
+		groups, err := s.groupRepo.FindByMembers(actor.TenantID, ids)
+		if err != nil {
+			return err
+		}
+		for i := range users {
+			embedded := make([]model.GroupResponse, 0, len(groups[users[i].ID]))
+			for _, group := range groups[users[i].ID] {
+				embedded = append(embedded, group.ToResponse())
+			}
+			results[i][IncludeGroups] = embedded
+		}
+	}
+
+	if contains(view.Include, IncludeSessions) {
+		tokens, err := s.tokenService.ActiveSessions(ids)
+		if err != nil {
+			return err
+		}
+		sessions := make(map[uint][]model.SessionResponse, len(users))
+		for i := range tokens {
+			sessions[tokens[i].UserID] = append(sessions[tokens[i].UserID], tokens[i].ToSessionResponse())
+		}
+		for i := range users {
+			embedded := sessions[users[i].ID]
+			if embedded == nil {
+				embedded = []model.SessionResponse{}
+			}
+			results[i][IncludeSessions] = embedded
+		}
+	}
+	return nil
+}
+
+// authorizeInclude lets anyone embed resources of their own account, and otherwise
+// requires the permission that guards the resource elsewhere, as a scope too for
+// scoped tokens
+func (s *UserService) authorizeInclude(actor *model.User, name string, ids []uint) error {
+	self := true
+	for _, id := range ids {
+		if id != actor.ID {
+			self = false
+			break
+		}
+	}
+	if self {
+		return nil
+	}
+	permission := includePermissions[name]
+	// A scoped token must carry the permission as a scope as well
+	if actor.Scopes != nil && !slices.Contains(actor.Scopes, permission) {
+		return fmt.Errorf("%w: include=%s requires the '%s' scope", ErrForbidden, name, permission)
+	}
+	allowed, err := s.roleService.UserHasPermissions(actor, permission)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return fmt.Errorf("%w: include=%s requires the '%s' permission", ErrForbidden, name, permission)
+	}
+	return nil
+}
+
+// trimFields removes every field not in fields from a user response, highlights included
+func trimFields(result map[string]interface{}, fields []string) {
+	for key := range result {
+		if key != "highlights" && !contains(fields, key) {
+			delete(result, key)
+		}
+	}
+	if highlights, ok := result["highlights"].(map[string]string); ok {
+		for key := range highlights {
+			if !contains(fields, key) {
+				delete(highlights, key)
+			}
+		}
+		if len(highlights) == 0 {
+			delete(result, "highlights")
+		}
+	}
+}
@@ -0,0 +1,320 @@
+// Package jsonpatch applies JSON Merge Patches (RFC 7396) and JSON Patches
+// (RFC 6902) to documents decoded with encoding/json into interface{} values.
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Media types of the two patch formats
+const (
+	MediaTypeMergePatch = "application/merge-patch+json"
+	MediaTypeJSONPatch  = "application/json-patch+json"
+)
+
+var (
+	// ErrInvalidPatch is returned for patches that are malformed or cannot be applied
+	ErrInvalidPatch = errors.New("invalid patch")
+	// ErrTestFailed is returned when a test operation does not match the document
+	ErrTestFailed = errors.New("patch test failed")
+)
+
+// Operation is one step of a JSON Patch. Value is kept raw so that a missing value
+// can be told apart from null.
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Merge applies a JSON Merge Patch to target: members of a patch object replace
+// those of the target, null removes them, and anything but an object replaces the
+// whole target.
+func Merge(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+
+	result := make(map[string]interface{}, len(targetObject))
+	for name, value := range targetObject {
+		result[name] = value
+	}
+	for name, value := range patchObject {
+		if value == nil {
+			delete(result, name)
+			continue
+		}
+		result[name] = Merge(result[name], value)
+	}
+	return result
+}
+
+// Decode reads the operations of a JSON Patch document
+func Decode(data []byte) ([]Operation, error) {
+	var operations []Operation
+	if err := json.Unmarshal(data, &operations); err != nil {
+		return nil, fmt.Errorf("%w: a JSON Patch must be an array of operations", ErrInvalidPatch)
+	}
+	return operations, nil
+}
+
+// Apply runs the operations in order on doc and returns the result. doc itself is
+// not changed. The patch applies completely or not at all.
+func Apply(doc interface{}, operations []Operation) (interface{}, error) {
+	doc = deepCopy(doc)
+	for i, operation := range operations {
+		var err error
+		if doc, err = applyOperation(doc, operation); err != nil {
+			if errors.Is(err, ErrTestFailed) {
+				return nil, fmt.Errorf("%w: operation %d (%s %s)", ErrTestFailed, i, operation.Op, operation.Path)
+			}
+			return nil, fmt.Errorf("%w: operation %d (%s %s): %v", ErrInvalidPatch, i, operation.Op, operation.Path, err)
+		}
+	}
+	return doc, nil
+}
+
+// Paths returns the paths an operation reads or writes
+func (o Operation) Paths() []string {
+	if o.Op == "move" || o.Op == "copy" {
+		return []string{o.From, o.Path}
+	}
+	return []string{o.Path}
+}
+
+// Tokens splits a JSON Pointer into its unescaped reference tokens; the root is empty
+func Tokens(pointer string) ([]string, error) {
+	tokens, err := splitPointer(pointer)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return tokens, nil
+}
+
+func splitPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("path %q must start with /", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+func applyOperation(doc interface{}, operation Operation) (interface{}, error) {
+	switch operation.Op {
+	case "add", "replace", "test":
+		value, err := operationValue(operation)
+		if err != nil {
+			return nil, err
+		}
+		switch operation.Op {
+		case "add":
+			return add(doc, operation.Path, value)
+		case "replace":
+			if doc, err = remove(doc, operation.Path); err != nil {
+				return nil, err
+			}
+			return add(doc, operation.Path, value)
+		}
+		current, err := get(doc, operation.Path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(current, value) {
+			return nil, ErrTestFailed
+		}
+		return doc, nil
+	case "remove":
+		return remove(doc, operation.Path)
+	case "move", "copy":
+		value, err := get(doc, operation.From)
+		if err != nil {
+			return nil, err
+		}
+		if operation.Op == "move" {
+			if strings.HasPrefix(operation.Path, operation.From+"/") {
+				return nil, errors.New("cannot move a value into itself")
+			}
+			if doc, err = remove(doc, operation.From); err != nil {
+				return nil, err
+			}
+		}
+		return add(doc, operation.Path, deepCopy(value))
+	}
+	return nil, fmt.Errorf("unknown op %q", operation.Op)
+}
+
+func operationValue(operation Operation) (interface{}, error) {
+	if len(operation.Value) == 0 {
+		return nil, errors.New("missing value")
+	}
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(operation.Value))
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// get returns the value the pointer refers to
+func get(doc interface{}, pointer string) (interface{}, error) {
+	tokens, err := splitPointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	for _, token := range tokens {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("path %q does not exist", pointer)
+			}
+			doc = value
+		case []interface{}:
+			index, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[index]
+		default:
+			return nil, fmt.Errorf("path %q does not exist", pointer)
+		}
+	}
+	return doc, nil
+}
+
+// add sets the member or inserts the array element the pointer refers to; the
+// parent must exist
+func add(doc interface{}, pointer string, value interface{}) (interface{}, error) {
+	tokens, err := splitPointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	return update(doc, pointer, tokens, func(parent interface{}, last string) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			node[last] = value
+			return node, nil
+		case []interface{}:
+			index := len(node)
+			if last != "-" {
+				if index, err = arrayIndex(last, len(node)); err != nil {
+					return nil, err
+				}
+			}
+			node = append(node, nil)
+			copy(node[index+1:], node[index:])
+			node[index] = value
+			return node, nil
+		}
+		return nil, fmt.Errorf("path %q does not exist", pointer)
+	})
+}
+
+// remove deletes the member or array element the pointer refers to
+func remove(doc interface{}, pointer string) (interface{}, error) {
+	tokens, err := splitPointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+	return update(doc, pointer, tokens, func(parent interface{}, last string) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			if _, ok := node[last]; !ok {
+				return nil, fmt.Errorf("path %q does not exist", pointer)
+			}
+			delete(node, last)
+			return node, nil
+		case []interface{}:
+			index, err := arrayIndex(last, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			return append(node[:index], node[index+1:]...), nil
+		}
+		return nil, fmt.Errorf("path %q does not exist", pointer)
+	})
+}
+
+// update walks to the parent of the last token and replaces it with the result of
+// change, storing it back in its own parent since arrays may grow or shrink
+func update(doc interface{}, pointer string, tokens []string, change func(parent interface{}, last string) (interface{}, error)) (interface{}, error) {
+	if len(tokens) == 1 {
+		return change(doc, tokens[0])
+	}
+	child, err := get(doc, pointerOf(tokens[:1]))
+	if err != nil {
+		return nil, fmt.Errorf("path %q does not exist", pointer)
+	}
+	changed, err := update(child, pointer, tokens[1:], change)
+	if err != nil {
+		return nil, err
+	}
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		node[tokens[0]] = changed
+	case []interface{}:
+		index, _ := arrayIndex(tokens[0], len(node)-1)
+		node[index] = changed
+	}
+	return doc, nil
+}
+
+// arrayIndex parses an array index token, which may be at most max
+func arrayIndex(token string, max int) (int, error) {
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || index > max || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	return index, nil
+}
+
+func pointerOf(tokens []string) string {
+	escaper := strings.NewReplacer("~", "~0", "/", "~1")
+	var pointer strings.Builder
+	for _, token := range tokens {
+		pointer.WriteString("/" + escaper.Replace(token))
+	}
+	return pointer.String()
+}
+
+func deepCopy(value interface{}) interface{} {
+	switch node := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(node))
+		for name, child := range node {
+			copied[name] = deepCopy(child)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(node))
+		for i, child := range node {
+			copied[i] = deepCopy(child)
+		}
+		return copied
+	}
+	return value
+}
@@ -0,0 +1,127 @@
+// Package tabular streams rows of named fields as CSV, NDJSON or XLSX files.
+// Rows are written as they come, so exports never hold the whole file in memory.
+package tabular
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Supported file formats
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+	FormatXLSX   = "xlsx"
+)
+
+// ErrUnsupportedFormat is returned for formats other than csv, ndjson and xlsx
+var ErrUnsupportedFormat = errors.New("unsupported format")
+
+var contentTypes = map[string]string{
+	FormatCSV:    "text/csv; charset=utf-8",
+	FormatNDJSON: "application/x-ndjson",
+	FormatXLSX:   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// Writer writes rows to a file. Flush pushes buffered rows to the underlying
+// writer, and Close completes the file; it does not close the underlying writer.
+type Writer interface {
+	WriteRow(row map[string]interface{}) error
+	Flush() error
+	Close() error
+}
+
+// ContentType returns the MIME type of a format
+func ContentType(format string) (string, error) {
+	contentType, ok := contentTypes[format]
+	if !ok {
+		return "", fmt.Errorf("%w %q (use csv, ndjson or xlsx)", ErrUnsupportedFormat, format)
+	}
+	return contentType, nil
+}
+
+// NewWriter starts a file of the given format on w. CSV and XLSX files get a header
+// row and one column per entry of columns; NDJSON files hold each row as a JSON object.
+func NewWriter(format string, w io.Writer, columns []string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, columns)
+	case FormatNDJSON:
+		return &ndjsonWriter{encoder: json.NewEncoder(w)}, nil
+	case FormatXLSX:
+		return newXLSXWriter(w, columns)
+	}
+	_, err := ContentType(format)
+	return nil, err
+}
+
+type csvWriter struct {
+	writer  *csv.Writer
+	columns []string
+}
+
+func newCSVWriter(w io.Writer, columns []string) (*csvWriter, error) {
+	writer := &csvWriter{writer: csv.NewWriter(w), columns: columns}
+	if err := writer.writer.Write(columns); err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+func (w *csvWriter) WriteRow(row map[string]interface{}) error {
+	record := make([]string, len(w.columns))
+	for i, column := range w.columns {
+		record[i] = cellText(row[column])
+		// Spreadsheets would run text starting like a formula, so it is quoted
+		if _, isText := row[column].(string); isText && record[i] != "" && strings.ContainsRune("=+-@\t\r", rune(record[i][0])) {
+			record[i] = "'" + record[i]
+		}
+	}
+	return w.writer.Write(record)
+}
+
+func (w *csvWriter) Flush() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+func (w *csvWriter) Close() error {
+	return w.Flush()
+}
+
+type ndjsonWriter struct {
+	encoder *json.Encoder
+}
+
+func (w *ndjsonWriter) WriteRow(row map[string]interface{}) error {
+	return w.encoder.Encode(row)
+}
+
+func (w *ndjsonWriter) Flush() error {
+	return nil
+}
+
+func (w *ndjsonWriter) Close() error {
+	return nil
+}
+
+// cellText formats a JSON-decoded value for a text cell; missing values stay empty
+func cellText(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
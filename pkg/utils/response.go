@@ -10,6 +10,7 @@ import (
 type Response struct {
 	Status  string      `json:"status"` // "success" or "error"
 	Message string      `json:"message,omitempty"`
+	Code    string      `json:"code,omitempty"` // machine-readable error code for clients to act on
 	Data    interface{} `json:"data,omitempty"`
 	Error   interface{} `json:"error,omitempty"`
 }
@@ -32,6 +33,15 @@ func WriteError(ctx *fasthttp.RequestCtx, statusCode int, message string) {
 	})
 }
 
+// WriteErrorCode sends a standard error response with a machine-readable code
+func WriteErrorCode(ctx *fasthttp.RequestCtx, statusCode int, code, message string) {
+	WriteJSON(ctx, statusCode, Response{
+		Status:  "error",
+		Code:    code,
+		Message: message,
+	})
+}
+
 // WriteSuccess sends a standard success response
 func WriteSuccess(ctx *fasthttp.RequestCtx, statusCode int, data interface{}) {
 	WriteJSON(ctx, statusCode, data)